@@ -0,0 +1,67 @@
+package uecho
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// acceptSpec Accept 头中的一项
+type acceptSpec struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept 解析 Accept 头，按 q 值从高到低排序，q 值相同时更具体的类型优先
+func parseAccept(header string) []acceptSpec {
+	var specs []acceptSpec
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		spec := acceptSpec{q: 1}
+		params := strings.Split(part, ";")
+		spec.mediaType = strings.ToLower(strings.TrimSpace(params[0]))
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) == 2 && strings.TrimSpace(kv[0]) == "q" {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64); err == nil {
+					spec.q = q
+				}
+			}
+		}
+		specs = append(specs, spec)
+	}
+
+	sort.SliceStable(specs, func(i, j int) bool {
+		if specs[i].q != specs[j].q {
+			return specs[i].q > specs[j].q
+		}
+		return specificity(specs[i].mediaType) > specificity(specs[j].mediaType)
+	})
+	return specs
+}
+
+func specificity(mediaType string) int {
+	switch {
+	case mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(mediaType, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// matchMediaType mediaType 是否满足 Accept 中的 pattern（支持 */* 与 type/*）
+func matchMediaType(pattern, mediaType string) bool {
+	if pattern == "*/*" || pattern == mediaType {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/*") {
+		return strings.HasPrefix(mediaType, pattern[:len(pattern)-1])
+	}
+	return false
+}
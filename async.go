@@ -0,0 +1,288 @@
+package uecho
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Job 异步任务，返回值作为任务结果保存
+type Job func(ctx context.Context) (interface{}, error)
+
+// JobStatus 任务状态
+type JobStatus string
+
+const (
+	JobPending   JobStatus = "pending"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// JobRecord 任务记录
+type JobRecord struct {
+	ID         string      `json:"id"`
+	Status     JobStatus   `json:"status"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	StartedAt  *time.Time  `json:"started_at,omitempty"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+}
+
+// JobStore 保存任务记录，多实例部署时可实现为 Redis、数据库等共享存储
+type JobStore interface {
+	Save(rec JobRecord) error
+	Get(id string) (JobRecord, bool, error)
+}
+
+// MemoryJobStore 进程内的 JobStore，结束超过 TTL 的记录在保存新记录时被清理（每个 TTL 最多清理一次）
+type MemoryJobStore struct {
+	TTL time.Duration
+
+	mu    sync.Mutex
+	recs  map[string]JobRecord
+	sweep time.Time
+}
+
+// NewMemoryJobStore 创建 MemoryJobStore
+func NewMemoryJobStore(ttl time.Duration) *MemoryJobStore {
+	return &MemoryJobStore{TTL: ttl, recs: make(map[string]JobRecord)}
+}
+
+func (s *MemoryJobStore) Save(rec JobRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now := time.Now(); s.TTL > 0 && now.After(s.sweep) {
+		s.sweep = now.Add(s.TTL)
+		for id, r := range s.recs {
+			if r.FinishedAt != nil && now.Sub(*r.FinishedAt) > s.TTL {
+				delete(s.recs, id)
+			}
+		}
+	}
+	s.recs[rec.ID] = rec
+	return nil
+}
+
+func (s *MemoryJobStore) Get(id string) (JobRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.recs[id]
+	return rec, ok, nil
+}
+
+type JobsConfig struct {
+	// Workers 并发执行任务的 worker 数量
+	// Optional. Default value runtime.NumCPU().
+	Workers int
+
+	// QueueSize 等待执行的任务数上限，队列已满时 Submit 返回 ErrJobQueueFull（c.Async 返回 503）
+	// Optional. Default value 1024.
+	QueueSize int
+
+	// Timeout 单个任务的执行时限，0 表示不限制
+	Timeout time.Duration
+
+	// Store 任务记录的存储
+	// Optional. Default value NewMemoryJobStore(time.Hour).
+	Store JobStore
+
+	// StatusPath 查询任务状态路由的前缀（例如 /jobs/），设置后 202 响应携带 Location: StatusPath + id
+	StatusPath string
+}
+
+// ErrJobQueueFull 任务队列已满
+var ErrJobQueueFull = errors.New("uecho: job queue is full")
+
+// ErrJobsClosed 任务池已关闭
+var ErrJobsClosed = errors.New("uecho: jobs closed")
+
+// Jobs 异步任务池
+type Jobs struct {
+	conf   JobsConfig
+	queue  chan asyncJob
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+type asyncJob struct {
+	rec JobRecord
+	job Job
+}
+
+// NewJobs 创建任务池并启动 worker
+func NewJobs(conf JobsConfig) *Jobs {
+	if conf.Workers <= 0 {
+		conf.Workers = runtime.NumCPU()
+	}
+	if conf.QueueSize <= 0 {
+		conf.QueueSize = 1024
+	}
+	if conf.Store == nil {
+		conf.Store = NewMemoryJobStore(time.Hour)
+	}
+
+	j := &Jobs{conf: conf, queue: make(chan asyncJob, conf.QueueSize)}
+	j.ctx, j.cancel = context.WithCancel(context.Background())
+	j.wg.Add(conf.Workers)
+	for i := 0; i < conf.Workers; i++ {
+		go j.work()
+	}
+	return j
+}
+
+// Submit 提交任务，返回 pending 状态的任务记录
+func (j *Jobs) Submit(job Job) (JobRecord, error) {
+	rec := JobRecord{ID: newJobID(), Status: JobPending, CreatedAt: time.Now()}
+	if err := j.conf.Store.Save(rec); err != nil {
+		return rec, err
+	}
+
+	j.mu.RLock()
+	err := ErrJobsClosed
+	if !j.closed {
+		select {
+		case j.queue <- asyncJob{rec: rec, job: job}:
+			err = nil
+		default:
+			err = ErrJobQueueFull
+		}
+	}
+	j.mu.RUnlock()
+
+	if err != nil {
+		now := time.Now()
+		rec.Status, rec.Error, rec.FinishedAt = JobFailed, err.Error(), &now
+		j.conf.Store.Save(rec)
+	}
+	return rec, err
+}
+
+// Get 返回任务记录
+func (j *Jobs) Get(id string) (JobRecord, bool, error) {
+	return j.conf.Store.Get(id)
+}
+
+// Close 不再接受新任务，等待队列中的任务执行完成；ctx 结束时取消正在执行的任务
+func (j *Jobs) Close(ctx context.Context) error {
+	j.mu.Lock()
+	if !j.closed {
+		j.closed = true
+		close(j.queue)
+	}
+	j.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		j.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		j.cancel()
+		return ctx.Err()
+	}
+}
+
+func (j *Jobs) work() {
+	defer j.wg.Done()
+	for aj := range j.queue {
+		j.run(aj)
+	}
+}
+
+func (j *Jobs) run(aj asyncJob) {
+	rec := aj.rec
+	started := time.Now()
+	rec.Status, rec.StartedAt = JobRunning, &started
+	j.conf.Store.Save(rec)
+
+	ctx, cancel := j.ctx, context.CancelFunc(func() {})
+	if j.conf.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, j.conf.Timeout)
+	}
+	result, err := runJob(ctx, aj.job)
+	cancel()
+
+	finished := time.Now()
+	rec.FinishedAt = &finished
+	if err != nil {
+		rec.Status, rec.Error = JobFailed, err.Error()
+	} else {
+		rec.Status, rec.Result = JobSucceeded, result
+	}
+	j.conf.Store.Save(rec)
+}
+
+// runJob 执行任务，panic 视为任务失败
+func runJob(ctx context.Context, job Job) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job(ctx)
+}
+
+func newJobID() string {
+	return randomHex(16)
+}
+
+// jobs 返回 UEcho 的任务池，未设置时以默认配置创建；Shutdown 之前没有创建过时返回 nil
+func (e *UEcho) jobs() *Jobs {
+	e.jobsOnce.Do(func() {
+		if e.Jobs == nil {
+			e.Jobs = NewJobs(JobsConfig{})
+		}
+	})
+	return e.Jobs
+}
+
+// Async 将 job 提交到任务池（UEcho.Jobs）异步执行，立即以 202 及任务记录响应
+// job 的 ctx 与请求无关，不应在 job 中使用 Context
+func (c *Context) Async(job Job) error {
+	jobs := c.ue.jobs()
+	if jobs == nil {
+		return c.Abort(ErrServiceUnavailable).WithErr(ErrJobsClosed)
+	}
+	rec, err := jobs.Submit(job)
+	if err != nil {
+		return c.Abort(ErrServiceUnavailable).WithErr(err)
+	}
+	if jobs.conf.StatusPath != "" {
+		c.SetRespHeader(echo.HeaderLocation, jobs.conf.StatusPath+rec.ID)
+	}
+	return c.SetPayload(Accepted.WithData(rec))
+}
+
+// JobStatusHandler 查询任务状态的处理函数，任务 id 为路径参数 id
+//
+//	e.GET("/jobs/:id", e.JobStatusHandler())
+func (e *UEcho) JobStatusHandler() HandlerFunc {
+	return func(c *Context) error {
+		jobs := e.jobs()
+		if jobs == nil {
+			return c.Abort(ErrNotFound)
+		}
+		rec, ok, err := jobs.Get(c.Param("id"))
+		if err != nil {
+			return c.Abort(ErrInternal).WithErr(err)
+		}
+		if !ok {
+			return c.Abort(ErrNotFound)
+		}
+		return c.SetPayload(OK.WithData(rec))
+	}
+}
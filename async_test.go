@@ -0,0 +1,92 @@
+package uecho
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAsync(t *testing.T) {
+	ue := New(nil)
+	ue.Jobs = NewJobs(JobsConfig{Workers: 1, QueueSize: 1, StatusPath: "/jobs/"})
+	release := make(chan struct{})
+	ue.POST("/reports", HandlerFunc(func(c *Context) error {
+		fail := c.QueryParam("fail") != ""
+		return c.Async(func(ctx context.Context) (interface{}, error) {
+			<-release
+			if fail {
+				return nil, errors.New("boom")
+			}
+			return map[string]int{"rows": 3}, nil
+		})
+	}))
+	ue.GET("/jobs/:id", ue.JobStatusHandler())
+
+	type envelope struct {
+		EC   int       `json:"ec"`
+		Data JobRecord `json:"data"`
+	}
+	do := func(method, path string) (*httptest.ResponseRecorder, envelope) {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		var env envelope
+		json.Unmarshal(rec.Body.Bytes(), &env)
+		return rec, env
+	}
+
+	rec, first := do(http.MethodPost, "/reports")
+	if rec.Code != http.StatusAccepted || first.EC != 202 || first.Data.Status != JobPending || rec.Header().Get("Location") != "/jobs/"+first.Data.ID {
+		t.Fatalf("got %d %s %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+
+	// 第一个任务执行中、第二个在队列中，第三个被拒绝
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(5 * time.Millisecond) {
+		if _, env := do(http.MethodGet, "/jobs/"+first.Data.ID); env.Data.Status == JobRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("job not started")
+		}
+	}
+	_, second := do(http.MethodPost, "/reports?fail=1")
+	if rec, _ := do(http.MethodPost, "/reports"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("queue full: got %d", rec.Code)
+	}
+
+	close(release)
+	if err := ue.Jobs.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, env := do(http.MethodGet, "/jobs/"+first.Data.ID); env.Data.Status != JobSucceeded || env.Data.FinishedAt == nil {
+		t.Errorf("first job: %+v", env.Data)
+	}
+	if _, env := do(http.MethodGet, "/jobs/"+second.Data.ID); env.Data.Status != JobFailed || env.Data.Error != "boom" {
+		t.Errorf("second job: %+v", env.Data)
+	}
+	if rec, _ := do(http.MethodGet, "/jobs/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("missing job: got %d", rec.Code)
+	}
+}
+
+func TestMemoryJobStoreSweep(t *testing.T) {
+	s := NewMemoryJobStore(20 * time.Millisecond)
+	finished := time.Now()
+	s.Save(JobRecord{ID: "old", Status: JobSucceeded, FinishedAt: &finished})
+	s.Save(JobRecord{ID: "running", Status: JobRunning})
+	if _, ok, _ := s.Get("old"); !ok {
+		t.Fatal("record removed before TTL")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	s.Save(JobRecord{ID: "new", Status: JobPending})
+	if _, ok, _ := s.Get("old"); ok {
+		t.Error("expired record not removed")
+	}
+	if _, ok, _ := s.Get("running"); !ok {
+		t.Error("unfinished record removed")
+	}
+}
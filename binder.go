@@ -0,0 +1,335 @@
+package uecho
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// FieldError 参数绑定（校验）失败的字段，作为 ErrIllegalparams 的 data 返回给客户端
+type FieldError struct {
+	Field   string `json:"field" xml:"field"`
+	Message string `json:"message" xml:"message"`
+}
+
+// DefaultTimeLayouts Binder 解析 time.Time 默认依次尝试的格式
+var DefaultTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	bindUnmarshalerType = reflect.TypeOf((*echo.BindUnmarshaler)(nil)).Elem()
+)
+
+// Binder 兼容 echo.DefaultBinder（param、query、form、header tag），另外支持：
+//   - time.Time：`layout:"2006-01-02"` tag 指定格式，否则依次尝试 TimeLayouts
+//   - time.Duration：time.ParseDuration 格式，例如 1m30s
+//   - 实现 echo.BindUnmarshaler 或 encoding.TextUnmarshaler 的类型（例如 uuid）
+//   - 切片：重复的参数（ids=1&ids=2）或逗号分隔（ids=1,2）
+//   - map：filter[status]=paid&filter[channel]=alipay 绑定到 `query:"filter"` 的 map[string]T
+//   - 默认值：query、header、form 参数缺失或为空时使用 `default:"..."` tag 的值，切片以逗号分隔，例如 `default:"a,b"`
+//
+// 绑定失败时返回 ErrIllegalparams，data 为全部失败字段的 []FieldError
+type Binder struct {
+	// TimeLayouts 解析 time.Time 依次尝试的格式
+	// Optional. Default value DefaultTimeLayouts.
+	TimeLayouts []string
+}
+
+var _ echo.Binder = (*Binder)(nil)
+
+// NewBinder 创建 Binder，New 默认使用该 Binder
+func NewBinder() *Binder {
+	return &Binder{TimeLayouts: DefaultTimeLayouts}
+}
+
+// Bind 依次绑定路径参数、query 参数（仅 GET/DELETE）及请求体，与 echo.DefaultBinder 相同
+func (b *Binder) Bind(i interface{}, c echo.Context) error {
+	var errs []FieldError
+	errs = append(errs, b.bindData(i, pathParams(c), "param")...)
+	if c.Request().Method == http.MethodGet || c.Request().Method == http.MethodDelete {
+		errs = append(errs, b.bindData(i, c.QueryParams(), "query")...)
+	}
+	if len(errs) > 0 {
+		return fieldErrors(errs)
+	}
+	return b.BindBody(c, i)
+}
+
+// BindPathParams 绑定路径参数（param tag）
+func (b *Binder) BindPathParams(c echo.Context, i interface{}) error {
+	return fieldErrors(b.bindData(i, pathParams(c), "param"))
+}
+
+// BindQueryParams 绑定 query 参数（query tag）
+func (b *Binder) BindQueryParams(c echo.Context, i interface{}) error {
+	return fieldErrors(b.bindData(i, c.QueryParams(), "query"))
+}
+
+// BindHeaders 绑定请求头（header tag）
+func (b *Binder) BindHeaders(c echo.Context, i interface{}) error {
+	return fieldErrors(b.bindData(i, c.Request().Header, "header"))
+}
+
+// BindBody 绑定请求体：表单（form tag）由 Binder 解析，JSON/XML 交给 echo.DefaultBinder
+func (b *Binder) BindBody(c echo.Context, i interface{}) error {
+	req := c.Request()
+	if req.ContentLength == 0 {
+		return nil
+	}
+
+	ctype := req.Header.Get(echo.HeaderContentType)
+	if strings.HasPrefix(ctype, echo.MIMEApplicationForm) || strings.HasPrefix(ctype, echo.MIMEMultipartForm) {
+		params, err := c.FormParams()
+		if err != nil {
+			return acquireErrReply(ErrIllegalparams).WithErr(err)
+		}
+		return fieldErrors(b.bindData(i, params, "form"))
+	}
+
+	err := new(echo.DefaultBinder).BindBody(c, i)
+	if he, ok := err.(*echo.HTTPError); ok && he.Code == http.StatusBadRequest {
+		return acquireErrReply(ErrIllegalparams).WithErr(err)
+	}
+	return err
+}
+
+func pathParams(c echo.Context) map[string][]string {
+	names, values := c.ParamNames(), c.ParamValues()
+	params := make(map[string][]string, len(names))
+	for i, name := range names {
+		if i < len(values) {
+			params[name] = []string{values[i]}
+		}
+	}
+	return params
+}
+
+// fieldErrors 将失败字段转换为 ErrIllegalparams，没有失败字段时返回 nil
+func fieldErrors(errs []FieldError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return acquireErrReply(ErrIllegalparams.WithData(errs)).WithField("fields", errs)
+}
+
+// bindData 将 data 按 tag 绑定到 i（结构体指针），返回全部失败的字段
+func (b *Binder) bindData(i interface{}, data map[string][]string, tag string) []FieldError {
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return b.bindStruct(v.Elem(), data, tag)
+}
+
+func (b *Binder) bindStruct(v reflect.Value, data map[string][]string, tag string) []FieldError {
+	var errs []FieldError
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf, field := t.Field(i), v.Field(i)
+		name := strings.Split(sf.Tag.Get(tag), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			// 没有 tag 的结构体字段（含未导出的匿名字段）继续绑定其中的字段
+			if field.Kind() == reflect.Struct && !isScalarType(field.Type()) && (field.CanSet() || sf.Anonymous) {
+				errs = append(errs, b.bindStruct(field, data, tag)...)
+			}
+			continue
+		}
+		if !field.CanSet() {
+			continue
+		}
+
+		if field.Kind() == reflect.Map {
+			if err := b.bindMap(field, name, data, sf.Tag.Get("layout")); err != nil {
+				errs = append(errs, FieldError{Field: name, Message: err.Error()})
+			}
+			continue
+		}
+
+		values, ok := lookup(data, name, tag)
+		if !ok {
+			def, hasDefault := sf.Tag.Lookup("default")
+			if !hasDefault || tag == "param" {
+				continue
+			}
+			values = []string{def}
+		}
+		if err := b.setField(field, values, sf.Tag.Get("layout")); err != nil {
+			errs = append(errs, FieldError{Field: name, Message: err.Error()})
+		}
+	}
+	return errs
+}
+
+// lookup 返回参数值，header 按规范化的名称查找；没有该参数或参数值均为空时返回 false
+func lookup(data map[string][]string, name, tag string) ([]string, bool) {
+	if tag == "header" {
+		name = http.CanonicalHeaderKey(name)
+	}
+	for _, v := range data[name] {
+		if v != "" {
+			return data[name], true
+		}
+	}
+	return nil, false
+}
+
+// bindMap 绑定 name[key]=value 形式的参数
+func (b *Binder) bindMap(field reflect.Value, name string, data map[string][]string, layout string) error {
+	if field.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("unsupported map key type %s", field.Type().Key())
+	}
+	prefix := name + "["
+	for key, values := range data {
+		if !strings.HasPrefix(key, prefix) || !strings.HasSuffix(key, "]") || len(values) == 0 {
+			continue
+		}
+		if field.IsNil() {
+			field.Set(reflect.MakeMap(field.Type()))
+		}
+		elem := reflect.New(field.Type().Elem()).Elem()
+		if err := b.setField(elem, values, layout); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+		field.SetMapIndex(reflect.ValueOf(key[len(prefix):len(key)-1]).Convert(field.Type().Key()), elem)
+	}
+	return nil
+}
+
+// setField 设置字段值，切片字段接受重复的参数及逗号分隔的值
+func (b *Binder) setField(field reflect.Value, values []string, layout string) error {
+	if field.Kind() == reflect.Slice && !isScalarType(field.Type()) {
+		var items []string
+		for _, v := range values {
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		}
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := b.setValue(slice.Index(i), item, layout); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return b.setValue(field, values[0], layout)
+}
+
+// isScalarType 按单个值解析的类型（time.Time、[]byte、实现了解析接口的类型）
+func isScalarType(t reflect.Type) bool {
+	if t == timeType || t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return true
+	}
+	pt := reflect.PtrTo(t)
+	return pt.Implements(bindUnmarshalerType) || pt.Implements(textUnmarshalerType)
+}
+
+func (b *Binder) setValue(v reflect.Value, s string, layout string) error {
+	if v.Kind() == reflect.Ptr {
+		if s == "" {
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return b.setValue(v.Elem(), s, layout)
+	}
+
+	switch v.Type() {
+	case timeType:
+		return b.setTime(v, s, layout)
+	case durationType:
+		if s == "" {
+			return nil
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	if v.CanAddr() {
+		if u, ok := v.Addr().Interface().(echo.BindUnmarshaler); ok {
+			return u.UnmarshalParam(s)
+		}
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(s))
+		}
+	}
+
+	if s == "" && v.Kind() != reflect.String {
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		x, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", s)
+		}
+		v.SetBool(x)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		v.SetInt(x)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", s)
+		}
+		v.SetUint(x)
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		v.SetFloat(x)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(s))
+			return nil
+		}
+		return fmt.Errorf("unsupported type %s", v.Type())
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func (b *Binder) setTime(v reflect.Value, s, layout string) error {
+	if s == "" {
+		return nil
+	}
+	layouts := b.TimeLayouts
+	if layout != "" {
+		layouts = []string{layout}
+	} else if len(layouts) == 0 {
+		layouts = DefaultTimeLayouts
+	}
+	for _, l := range layouts {
+		if t, err := time.Parse(l, s); err == nil {
+			v.Set(reflect.ValueOf(t))
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid time %q, expected layout %s", s, strings.Join(layouts, " or "))
+}
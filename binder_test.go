@@ -0,0 +1,171 @@
+package uecho
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testID [2]string
+
+func (id *testID) UnmarshalText(b []byte) error {
+	parts := strings.SplitN(string(b), "-", 2)
+	if len(parts) != 2 {
+		return errors.New("invalid id")
+	}
+	id[0], id[1] = parts[0], parts[1]
+	return nil
+}
+
+type bindPage struct {
+	Page int `query:"page"`
+}
+
+type bindQuery struct {
+	bindPage
+	ID      string            `param:"id"`
+	Trace   testID            `query:"trace"`
+	Since   time.Time         `query:"since"`
+	Day     time.Time         `query:"day" layout:"20060102"`
+	Wait    time.Duration     `query:"wait"`
+	IDs     []int64           `query:"ids"`
+	Tags    []string          `query:"tags"`
+	Filter  map[string]string `query:"filter"`
+	Limit   *int              `query:"limit"`
+	Request string            `header:"x-request-id"`
+}
+
+func TestBinder(t *testing.T) {
+	ue := New(nil)
+	var got bindQuery
+	ue.GET("/orders/:id", HandlerFunc(func(c *Context) error {
+		got = bindQuery{}
+		if err := c.Bind(&got); err != nil {
+			return err
+		}
+		return c.Echo().Binder.(*Binder).BindHeaders(c, &got)
+	}))
+
+	q := url.Values{}
+	q.Set("page", "2")
+	q.Set("trace", "a-b")
+	q.Set("since", "2021-09-01T10:00:00+08:00")
+	q.Set("day", "20210902")
+	q.Set("wait", "1m30s")
+	q.Add("ids", "1,2")
+	q.Add("ids", "3")
+	q.Add("tags", "x")
+	q.Set("filter[status]", "paid")
+	q.Set("limit", "10")
+	req := httptest.NewRequest(http.MethodGet, "/orders/42?"+q.Encode(), nil)
+	req.Header.Set("X-Request-Id", "rid")
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d %s", rec.Code, rec.Body.String())
+	}
+
+	if got.ID != "42" || got.Page != 2 || got.Trace != (testID{"a", "b"}) || got.Request != "rid" {
+		t.Errorf("unexpected scalars: %+v", got)
+	}
+	if got.Since.Unix() != time.Date(2021, 9, 1, 2, 0, 0, 0, time.UTC).Unix() || got.Day.Day() != 2 || got.Wait != 90*time.Second {
+		t.Errorf("unexpected times: %v %v %v", got.Since, got.Day, got.Wait)
+	}
+	if len(got.IDs) != 3 || got.IDs[2] != 3 || len(got.Tags) != 1 || got.Filter["status"] != "paid" || got.Limit == nil || *got.Limit != 10 {
+		t.Errorf("unexpected collections: %+v", got)
+	}
+}
+
+func TestBinderFieldErrors(t *testing.T) {
+	ue := New(nil)
+	ue.GET("/orders", HandlerFunc(func(c *Context) error {
+		var q bindQuery
+		return c.Bind(&q)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders?page=x&since=yesterday&ids=1,b&wait=soon", nil)
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("got %d, want 400", rec.Code)
+	}
+
+	var resp struct {
+		EC   int          `json:"ec"`
+		Data []FieldError `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.EC != ErrIllegalparams.EC() {
+		t.Errorf("ec = %d", resp.EC)
+	}
+	fields := map[string]bool{}
+	for _, fe := range resp.Data {
+		fields[fe.Field] = true
+	}
+	for _, f := range []string{"page", "since", "ids", "wait"} {
+		if !fields[f] {
+			t.Errorf("missing field error for %s: %s", f, rec.Body.String())
+		}
+	}
+}
+
+func TestBinderForm(t *testing.T) {
+	ue := New(nil)
+	type form struct {
+		Name string    `form:"name"`
+		At   time.Time `form:"at"`
+		IDs  []uint    `form:"ids"`
+	}
+	var got form
+	ue.POST("/form", HandlerFunc(func(c *Context) error {
+		return c.Bind(&got)
+	}))
+
+	body := url.Values{"name": {"n"}, "at": {"2021-09-01"}, "ids": {"1", "2"}}.Encode()
+	req := httptest.NewRequest(http.MethodPost, "/form", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || got.Name != "n" || got.At.Day() != 1 || len(got.IDs) != 2 {
+		t.Fatalf("got %d %+v", rec.Code, got)
+	}
+}
+
+func TestBinderDefault(t *testing.T) {
+	ue := New(nil)
+	type list struct {
+		Page    int      `query:"page" default:"1"`
+		Size    int      `query:"size" default:"20"`
+		Desc    bool     `query:"desc" default:"true"`
+		Status  []string `query:"status" default:"paid,refunded"`
+		Channel string   `header:"x-channel" default:"web"`
+		Name    string   `form:"name" default:"anonymous"`
+	}
+	var got list
+	ue.GET("/list", HandlerFunc(func(c *Context) error {
+		got = list{}
+		if err := c.Bind(&got); err != nil {
+			return err
+		}
+		return ue.Binder.(*Binder).BindHeaders(c, &got)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/list?size=5&desc=", nil)
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d %s", rec.Code, rec.Body.String())
+	}
+	want := list{Page: 1, Size: 5, Desc: true, Status: []string{"paid", "refunded"}, Channel: "web"}
+	if got.Page != want.Page || got.Size != want.Size || got.Desc != want.Desc || got.Channel != want.Channel ||
+		len(got.Status) != 2 || got.Status[1] != "refunded" || got.Name != "" {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package uecho
+
+import (
+	"strconv"
+	"sync"
+)
+
+// Catalog 命名空间下的 ec 描述信息：同一进程内不同模块（团队）各自维护自己的 ec 区间及翻译，互不冲突
+// 通过 Catalog.Reply 创建的 Reply 查找描述信息时先查命名空间，再查全局（RegisterMessage）
+//
+//	var payments = uecho.Namespace("payments").
+//		Register(4001, uecho.LANG_ZH_CN, "余额不足").
+//		Register(4001, uecho.LANG_EN_US, "Insufficient balance")
+//	var ErrInsufficientBalance = payments.Reply(http.StatusPaymentRequired, 4001, "insufficient balance")
+type Catalog struct {
+	ns string
+
+	mu   sync.RWMutex
+	msgs map[string]string
+}
+
+var (
+	catalogsMu sync.Mutex
+	catalogs   = make(map[string]*Catalog)
+)
+
+// Namespace 返回命名空间 ns 的 Catalog，不存在时创建
+func Namespace(ns string) *Catalog {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+	c, ok := catalogs[ns]
+	if !ok {
+		c = &Catalog{ns: ns, msgs: make(map[string]string)}
+		catalogs[ns] = c
+	}
+	return c
+}
+
+// lookupCatalog 返回已注册的命名空间
+func lookupCatalog(ns string) *Catalog {
+	catalogsMu.Lock()
+	defer catalogsMu.Unlock()
+	return catalogs[ns]
+}
+
+// Name 命名空间名称
+func (c *Catalog) Name() string {
+	return c.ns
+}
+
+// Register 注册 ec 在 lang 下的描述信息，覆盖全局的同名 ec
+func (c *Catalog) Register(ec int, lang, em string) *Catalog {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.msgs[i18nKey(ec, lang)] = em
+	return c
+}
+
+// Lookup 返回 ec 在 lang 下的描述信息，命名空间中没有时查找全局
+func (c *Catalog) Lookup(ec int, lang string) (string, bool) {
+	c.mu.RLock()
+	em, ok := c.msgs[i18nKey(ec, lang)]
+	c.mu.RUnlock()
+	if ok {
+		return em, true
+	}
+	em, ok = eci18n[i18nKey(ec, lang)]
+	return em, ok
+}
+
+// Reply 创建属于该命名空间的 Reply
+func (c *Catalog) Reply(httpCode, ec int, em string) Reply {
+	return &reply{
+		httpCode: httpCode,
+		ec:       ec,
+		em:       em,
+		lang:     LANG_DEFAULT,
+		ns:       c.ns,
+	}
+}
+
+// RegisterMessage 注册全局的 ec 在 lang 下的描述信息，应在 init 中调用
+func RegisterMessage(ec int, lang, em string) {
+	eci18n[i18nKey(ec, lang)] = em
+}
+
+// ReplyNamespace 返回 Reply 所属的命名空间，全局的 Reply 返回空字符串
+func ReplyNamespace(r Reply) string {
+	switch rr := r.(type) {
+	case *reply:
+		return rr.ns
+	case *errReply:
+		return ReplyNamespace(rr.Reply)
+	}
+	return ""
+}
+
+func i18nKey(ec int, lang string) string {
+	return strconv.Itoa(ec) + "." + lang
+}
+
+// lookupEM 返回 Reply 的 ec 在 lang 下的描述信息，依次查找 Reply 所属的命名空间及全局
+func lookupEM(r Reply, lang string) (string, bool) {
+	if ns := ReplyNamespace(r); ns != "" {
+		if c := lookupCatalog(ns); c != nil {
+			return c.Lookup(r.EC(), lang)
+		}
+	}
+	em, ok := eci18n[i18nKey(r.EC(), lang)]
+	return em, ok
+}
@@ -0,0 +1,55 @@
+package uecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	RegisterMessage(4001, LANG_EN_US, "global 4001")
+	payments := Namespace("test-payments").
+		Register(4001, LANG_EN_US, "Insufficient balance").
+		Register(4001, LANG_ZH_CN, "余额不足")
+	orders := Namespace("test-orders").Register(4001, LANG_EN_US, "Order closed")
+	if Namespace("test-payments") != payments {
+		t.Fatal("Namespace returned a different catalog")
+	}
+
+	errBalance := payments.Reply(http.StatusPaymentRequired, 4001, "insufficient balance")
+	errClosed := orders.Reply(http.StatusConflict, 4001, "order closed")
+	errGlobal := NewReply(http.StatusBadRequest, 4001, "global")
+	errFallback := orders.Reply(http.StatusBadRequest, 400, "fail")
+
+	for _, tc := range []struct {
+		r    Reply
+		lang string
+		want string
+	}{
+		{errBalance, LANG_EN_US, "Insufficient balance"},
+		{errBalance, LANG_ZH_CN, "余额不足"},
+		{errClosed.WithData(1), LANG_EN_US, "Order closed"},
+		{errGlobal, LANG_EN_US, "global 4001"},
+		// 命名空间中没有时使用全局的描述
+		{errFallback, LANG_EN_US, ErrIllegalparams.WithEC(400).I18n(LANG_EN_US)},
+	} {
+		if got := tc.r.I18n(tc.lang); got != tc.want {
+			t.Errorf("%s %d %s: got %q, want %q", ReplyNamespace(tc.r), tc.r.EC(), tc.lang, got, tc.want)
+		}
+	}
+
+	ue := New(nil)
+	ue.LocalizeEM = true
+	ue.LangResolvers = []LangResolver{LangFromHeader()}
+	ue.GET("/pay", HandlerFunc(func(c *Context) error {
+		return c.Abort(errBalance)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/pay", nil)
+	req.Header.Set(HeaderAcceptLanguage, "en-US")
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPaymentRequired || !strings.Contains(rec.Body.String(), `"em":"Insufficient balance"`) {
+		t.Errorf("got %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,125 @@
+// Package client 调用 uecho 风格服务（{ec, em, data} 信封）的 http 客户端
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hunyxv/uecho"
+	"github.com/labstack/echo/v4"
+)
+
+// envelope 响应信封
+type envelope struct {
+	EC   int             `json:"ec"`
+	EM   string          `json:"em"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+// Client 解析信封并将 data 解码到目标值的 http 客户端
+// ec 不是 SuccessEC 时返回 uecho.ErrReply，网关 handler 可直接 return 该错误，
+// 或通过 uecho.ReplyOf 取出 Reply 后 c.Abort 重新抛出
+type Client struct {
+	// BaseURL 服务地址，例如 http://order-service:8080
+	BaseURL string
+
+	// HTTPClient 发送请求的 http client
+	// Optional. Default value uses uecho.PropagatingTransport with a 10s timeout.
+	HTTPClient *http.Client
+
+	// SuccessEC 表示成功的业务码
+	// Optional. Default value 200.
+	SuccessEC int
+}
+
+// New 创建 Client，默认透传 c.OutgoingContext() 中的请求头
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{
+			Transport: &uecho.PropagatingTransport{},
+			Timeout:   10 * time.Second,
+		},
+		SuccessEC: uecho.OK.EC(),
+	}
+}
+
+// Get 发送 GET 请求，data 解码到 out
+func (c *Client) Get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.Do(ctx, http.MethodGet, path, nil, out)
+}
+
+// Post 发送 JSON 请求体的 POST 请求，data 解码到 out
+func (c *Client) Post(ctx context.Context, path string, body, out interface{}) error {
+	return c.Do(ctx, http.MethodPost, path, body, out)
+}
+
+// Put 发送 JSON 请求体的 PUT 请求，data 解码到 out
+func (c *Client) Put(ctx context.Context, path string, body, out interface{}) error {
+	return c.Do(ctx, http.MethodPut, path, body, out)
+}
+
+// Delete 发送 DELETE 请求，data 解码到 out
+func (c *Client) Delete(ctx context.Context, path string, out interface{}) error {
+	return c.Do(ctx, http.MethodDelete, path, nil, out)
+}
+
+// Do 发送请求，body 不为 nil 时编码为 JSON；out 为 nil 时忽略 data
+func (c *Client) Do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(echo.HeaderAccept, echo.MIMEApplicationJSON)
+	if body != nil {
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+	}
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return uecho.NewErrReply(uecho.ErrBadGateway.HTTPCode(), uecho.ErrBadGateway.EC(),
+			uecho.ErrBadGateway.EM(), err)
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return uecho.NewErrReply(uecho.ErrBadGateway.HTTPCode(), uecho.ErrBadGateway.EC(),
+			uecho.ErrBadGateway.EM(), fmt.Errorf("client: decode %s %s (status %d): %w", method, path, resp.StatusCode, err))
+	}
+
+	success := c.SuccessEC
+	if success == 0 {
+		success = uecho.OK.EC()
+	}
+	if env.EC != success {
+		return uecho.NewErrReply(resp.StatusCode, env.EC, env.EM, nil)
+	}
+	if out == nil || len(env.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(env.Data, out)
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hunyxv/uecho"
+)
+
+func TestClient(t *testing.T) {
+	type order struct {
+		ID    int    `json:"id"`
+		State string `json:"state"`
+	}
+
+	ue := uecho.New(nil)
+	ue.GET("/orders/1", uecho.HandlerFunc(func(c *uecho.Context) error {
+		return c.SetPayload(uecho.OK.WithData(&order{ID: 1, State: "paid"}))
+	}))
+	ue.GET("/orders/2", uecho.HandlerFunc(func(c *uecho.Context) error {
+		return c.Abort(uecho.ErrNotFound)
+	}))
+	srv := httptest.NewServer(ue)
+	defer srv.Close()
+
+	cli := New(srv.URL)
+	var o order
+	if err := cli.Get(context.Background(), "/orders/1", nil, &o); err != nil {
+		t.Fatal(err)
+	}
+	if o.ID != 1 || o.State != "paid" {
+		t.Fatalf("order = %+v", o)
+	}
+
+	err := cli.Get(context.Background(), "/orders/2", nil, &o)
+	reply, ok := uecho.ReplyOf(err)
+	if !ok || reply.EC() != 404 || reply.HTTPCode() != http.StatusNotFound {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package uecho
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultShutdownGrace 默认的 UEcho.ShutdownGrace
+const DefaultShutdownGrace = 5 * time.Second
+
+// connRegistry 登记 SSE、WebSocket 等长连接，Shutdown 时通知其结束
+type connRegistry struct {
+	mu       sync.Mutex
+	next     int64
+	conns    map[int64]func()
+	closing  bool
+	inflight sync.WaitGroup
+}
+
+// TrackConn 登记长连接，Shutdown 开始时调用 onShutdown（不应阻塞）通知其结束，
+// 并最多等待 ShutdownGrace 直到返回的 done 被调用；长连接结束时必须调用 done
+// 在 Shutdown 开始之后登记的连接，onShutdown 会被立即调用
+// SSE、WebSocket 可直接使用 c.SSE、c.WebSocket
+func (c *Context) TrackConn(onShutdown func()) (done func()) {
+	r := &c.ue.conns
+	r.mu.Lock()
+	if r.closing {
+		r.mu.Unlock()
+		onShutdown()
+		return func() {}
+	}
+	if r.conns == nil {
+		r.conns = make(map[int64]func())
+	}
+	r.next++
+	id := r.next
+	r.conns[id] = onShutdown
+	r.inflight.Add(1)
+	r.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			r.mu.Lock()
+			delete(r.conns, id)
+			r.mu.Unlock()
+			r.inflight.Done()
+		})
+	}
+}
+
+// Conns 当前登记的长连接数
+func (e *UEcho) Conns() int {
+	e.conns.mu.Lock()
+	defer e.conns.mu.Unlock()
+	return len(e.conns.conns)
+}
+
+// shutdownConns 通知全部长连接结束，等待其结束、ShutdownGrace 或 ctx 结束
+func (e *UEcho) shutdownConns(ctx context.Context) {
+	r := &e.conns
+	r.mu.Lock()
+	r.closing = true
+	notify := make([]func(), 0, len(r.conns))
+	for _, fn := range r.conns {
+		notify = append(notify, fn)
+	}
+	r.mu.Unlock()
+	if len(notify) == 0 {
+		return
+	}
+	for _, fn := range notify {
+		fn()
+	}
+
+	grace := e.ShutdownGrace
+	if grace <= 0 {
+		grace = DefaultShutdownGrace
+	}
+	done := make(chan struct{})
+	go func() {
+		r.inflight.Wait()
+		close(done)
+	}()
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		e.Logger.Warnf("%d long-lived connections still open after shutdown grace period %s", e.Conns(), grace)
+	case <-ctx.Done():
+	}
+}
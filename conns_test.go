@@ -0,0 +1,76 @@
+package uecho
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestShutdownConns(t *testing.T) {
+	ue := New(nil)
+	ue.ShutdownGrace = time.Second
+	ready := make(chan struct{}, 2)
+	ue.GET("/events", HandlerFunc(func(c *Context) error {
+		return c.SSE(func(w *SSEWriter) error {
+			if err := w.Send(SSEEvent{ID: "1", Data: map[string]int{"n": 1}}); err != nil {
+				return err
+			}
+			ready <- struct{}{}
+			<-w.Context().Done()
+			return w.Context().Err()
+		})
+	}))
+	ue.GET("/ws", HandlerFunc(func(c *Context) error {
+		return c.WebSocket(func(ws *websocket.Conn) error {
+			ready <- struct{}{}
+			var msg string
+			return websocket.Message.Receive(ws, &msg)
+		})
+	}))
+	srv := httptest.NewServer(ue)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	<-ready
+	<-ready
+	if n := ue.Conns(); n != 2 {
+		t.Fatalf("got %d conns, want 2", n)
+	}
+
+	start := time.Now()
+	if err := ue.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) >= ue.ShutdownGrace {
+		t.Errorf("shutdown waited for the grace period")
+	}
+	if n := ue.Conns(); n != 0 {
+		t.Errorf("got %d conns after shutdown", n)
+	}
+
+	b, _ := ioutil.ReadAll(resp.Body)
+	want := "id: 1\ndata: {\"n\":1}\n\nevent: shutdown\nretry: 3000\ndata: server shutting down\n\n"
+	if string(b) != want {
+		t.Errorf("got %q, want %q", b, want)
+	}
+	var msg string
+	if err := websocket.Message.Receive(ws, &msg); err != io.EOF {
+		t.Errorf("websocket: got %v, want EOF", err)
+	}
+}
@@ -1,7 +1,9 @@
 package uecho
 
 import (
+	"bytes"
 	"context"
+	"encoding/xml"
 	"net/url"
 
 	"github.com/labstack/echo/v4"
@@ -10,9 +12,12 @@ import (
 
 // HttpApiResponse 响应
 type HttpApiResponse struct {
-	EC   int         `json:"ec"`
-	EM   string      `json:"em"`
-	Data interface{} `json:"data,omitempty"`
+	XMLName xml.Name    `json:"-" xml:"response"`
+	EC      int         `json:"ec" xml:"ec"`
+	EM      string      `json:"em" xml:"em"`
+	Data    interface{} `json:"data,omitempty" xml:"data,omitempty"`
+	// RequestID 异常响应携带的请求 id
+	RequestID string `json:"request_id,omitempty" xml:"request_id,omitempty"`
 }
 
 var _ echo.Context = (*Context)(nil)
@@ -21,7 +26,22 @@ var _ echo.Context = (*Context)(nil)
 // 可进行自定义扩展
 type Context struct {
 	echo.Context
+	ue     *UEcho
 	logger *logrus.Logger
+	lang   string
+	route  *Route
+	fields logrus.Fields
+
+	hostParamNames  []string
+	hostParamValues []string
+
+	// errReplies 本次请求已输出的 errReply，请求结束时归还到池中（Logger 等中间键在输出后仍会读取）
+	errReplies []*errReply
+
+	onFinish []func(status int, err error)
+
+	// poolRecord PoolDebug 记录的本次获取
+	poolRecord *poolRecord
 }
 
 func (c *Context) init(ec echo.Context) {
@@ -30,7 +50,35 @@ func (c *Context) init(ec echo.Context) {
 
 func (c *Context) reset() {
 	c.Context = nil
-	c.logger = nil
+	c.lang = ""
+	c.route = nil
+	c.fields = nil
+	c.hostParamNames = c.hostParamNames[:0]
+	c.hostParamValues = c.hostParamValues[:0]
+	for i, er := range c.errReplies {
+		releaseErrReply(er)
+		c.errReplies[i] = nil
+	}
+	c.errReplies = c.errReplies[:0]
+	for i := range c.onFinish {
+		c.onFinish[i] = nil
+	}
+	c.onFinish = c.onFinish[:0]
+}
+
+// releaseOnFinish 请求结束时归还 er
+func (c *Context) releaseOnFinish(er *errReply) {
+	for _, r := range c.errReplies {
+		if r == er {
+			return
+		}
+	}
+	c.errReplies = append(c.errReplies, er)
+}
+
+// Route 当前请求匹配到的路由，未匹配时返回 nil
+func (c *Context) Route() *Route {
+	return c.route
 }
 
 // RequestContext Request 的 ctx
@@ -66,6 +114,26 @@ func (c *Context) SetRespHeader(key, value string) {
 	c.Response().Header().Set(key, value)
 }
 
+// RequestID 请求 id，优先取响应头中（RequestID 中间件写入）的值
+func (c *Context) RequestID() string {
+	if id := c.Response().Header().Get(echo.HeaderXRequestID); id != "" {
+		return id
+	}
+	return c.GetHeader(echo.HeaderXRequestID)
+}
+
+// TrustedIP 可信的客户端 ip：设置了 e.IPExtractor 时为其结果，否则为 RemoteAddr，不信任客户端可以伪造的
+// X-Forwarded-For、X-Real-IP 请求头（c.RealIP() 在未设置 IPExtractor 时会使用）
+// 位于反向代理之后时应设置可信的 IPExtractor，例如 echo.ExtractIPFromXFFHeader(echo.TrustIPRange(...))
+func (c *Context) TrustedIP() string {
+	if c.ue.IPExtractor != nil {
+		return c.ue.IPExtractor(c.Request())
+	}
+	return echo.ExtractIPDirect()(c.Request())
+}
+
+// Logrus 返回 New 传入的 logger，为 nil 时返回 logrus.StandardLogger()；
+// 不需要日志的服务可以传入 NopLogger（New 传入 nil 时 Logger 中间键同样不输出访问日志）
 func (c *Context) Logrus() *logrus.Logger {
 	if c.logger != nil {
 		return c.logger
@@ -77,24 +145,56 @@ func (c *Context) setLogrus(logger *logrus.Logger) {
 	c.logger = logger
 }
 
+// AddLogField 添加访问日志字段，Logger 中间键输出日志时会带上这些字段
+func (c *Context) AddLogField(key string, value interface{}) {
+	if c.fields == nil {
+		c.fields = make(logrus.Fields, 1)
+	}
+	c.fields[key] = value
+}
+
+// LogFields 通过 AddLogField 添加的访问日志字段
+func (c *Context) LogFields() logrus.Fields {
+	return c.fields
+}
+
+// Error 调用 HTTPErrorHandler 处理异常，传入的是 *Context 而不是内部的 echo.Context
+func (c *Context) Error(err error) {
+	c.ue.HTTPErrorHandler(err, c)
+}
+
+// Render 渲染模板，与 echo 不同的是传给 Renderer 的是 *Context，便于注入请求相关的数据
+func (c *Context) Render(code int, name string, data interface{}) error {
+	r := c.Echo().Renderer
+	if r == nil {
+		return echo.ErrRendererNotRegistered
+	}
+	buf := new(bytes.Buffer)
+	if err := r.Render(buf, name, data, c); err != nil {
+		return err
+	}
+	return c.HTMLBlob(code, buf.Bytes())
+}
+
 // SetPayload 写入响应,http 状态码大于 400 就当作异常处理
+// 开启 JSONP（UEcho.JSONPParam）且请求携带回调参数时以 JSONP 形式响应
 func (c *Context) SetPayload(payload Reply) error {
 	p := payload.(*reply)
 	if p.httpCode >= 400 {
 		return &errReply{Reply: p}
 	}
 
-	return c.JSON(p.httpCode, &HttpApiResponse{
-		EC:   p.ec,
-		EM:   p.em,
-		Data: p.data,
-	})
+	c.countReply(p.ec)
+	if c.rawMode() {
+		return rawPayload(c, p)
+	}
+	if callback := c.jsonpCallback(); callback != "" {
+		return c.SetPayloadJSONP(callback, p)
+	}
+	return c.JSON(p.httpCode, newHttpApiResponse(c, p))
 }
 
 // Abort 终止处理，返回携带状态码的异常
 func (c *Context) Abort(reply Reply) ErrReply {
-	er := errReplyPool.Get().(*errReply)
-	er.reset()
-	er.Reply = reply
-	return er
+	return acquireErrReply(reply)
 }
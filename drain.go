@@ -0,0 +1,54 @@
+package uecho
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Drain 进入 drain 状态：关闭 keep-alive，健康检查（Draining）随之变为未就绪，
+// 开启 DrainOnShutdown 时新请求直接以 503 响应。Shutdown 会自动调用
+func (e *UEcho) Drain() {
+	if atomic.CompareAndSwapInt32(&e.draining, 0, 1) {
+		atomic.StoreInt64(&e.drainStart, time.Now().UnixNano())
+		e.Server.SetKeepAlivesEnabled(false)
+		e.TLSServer.SetKeepAlivesEnabled(false)
+	}
+}
+
+// Draining 是否处于 drain 状态（正在停止）
+func (e *UEcho) Draining() bool {
+	return atomic.LoadInt32(&e.draining) == 1
+}
+
+// waitDrainDelay 等待 DrainDelay 或 ctx 结束
+func (e *UEcho) waitDrainDelay(ctx context.Context) error {
+	if e.DrainDelay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(e.DrainDelay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// drainHandler drain 状态下新请求的处理：503 + Retry-After（DrainDelay 剩余的秒数，向上取整，至少 1 秒），
+// 并要求客户端关闭连接
+func drainHandler(c echo.Context) error {
+	uc := c.(*Context)
+	start := time.Unix(0, atomic.LoadInt64(&uc.ue.drainStart))
+	retryAfter := resetSeconds(start.Add(uc.ue.DrainDelay))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	uc.SetRespHeader(HeaderRetryAfter, strconv.Itoa(retryAfter))
+	uc.SetRespHeader("Connection", "close")
+	return uc.Abort(ErrServiceUnavailable)
+}
@@ -0,0 +1,180 @@
+package uecho
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestDrain(t *testing.T) {
+	ue := New(nil)
+	ue.DrainOnShutdown = true
+	ue.DrainDelay = 1500 * time.Millisecond
+	var status int
+	ue.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			status = 0
+			if r, ok := err.(Reply); ok {
+				status = r.HTTPCode()
+			}
+			return err
+		}
+	})
+	ue.GET("/", HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}))
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec
+	}
+
+	if ue.Draining() || get().Code != http.StatusNoContent {
+		t.Fatal("not draining yet")
+	}
+	ue.Drain()
+	if !ue.Draining() {
+		t.Fatal("Draining() = false after Drain")
+	}
+	rec := get()
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Connection") != "close" {
+		t.Fatalf("status = %d, Connection = %q", rec.Code, rec.Header().Get("Connection"))
+	}
+	// drain 响应同样经过 UEcho#Use 的中间键（访问日志、指标）
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("middleware saw status %d", status)
+	}
+	// 剩余时间向上取整
+	if ra := rec.Header().Get(HeaderRetryAfter); ra != "2" {
+		t.Errorf("Retry-After = %q, want 2", ra)
+	}
+	for _, delay := range []time.Duration{300 * time.Millisecond, 0} {
+		ue.DrainDelay = delay
+		if ra := get().Header().Get(HeaderRetryAfter); ra != "1" {
+			t.Errorf("DrainDelay %s: Retry-After = %q, want 1", delay, ra)
+		}
+	}
+}
+
+func TestShutdownOrder(t *testing.T) {
+	ue := New(nil)
+	ue.DrainOnShutdown = true
+	ue.DrainDelay = 50 * time.Millisecond
+	ue.Jobs = NewJobs(JobsConfig{Workers: 1})
+	ue.GET("/", HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}))
+
+	var jobDone int32
+	if _, err := ue.Jobs.Submit(func(ctx context.Context) (interface{}, error) {
+		time.Sleep(20 * time.Millisecond)
+		atomic.StoreInt32(&jobDone, 1)
+		return nil, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	done := make(chan error)
+	go func() { done <- ue.Shutdown(context.Background()) }()
+
+	// 等待 DrainDelay 期间已进入 drain 状态，新请求以 503 响应
+	for !ue.Draining() {
+		time.Sleep(time.Millisecond)
+	}
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("request during drain delay: status = %d", rec.Code)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < ue.DrainDelay {
+		t.Errorf("Shutdown returned after %s, before DrainDelay", elapsed)
+	}
+	// 异步任务在服务关闭之后结束
+	if atomic.LoadInt32(&jobDone) != 1 {
+		t.Error("job was not finished by Shutdown")
+	}
+	if _, err := ue.Jobs.Submit(func(ctx context.Context) (interface{}, error) { return nil, nil }); err != ErrJobsClosed {
+		t.Errorf("submit after shutdown: err = %v", err)
+	}
+}
+
+func TestShutdownDrainDelayCanceled(t *testing.T) {
+	ue := New(nil)
+	ue.DrainDelay = time.Minute
+	ue.Jobs = NewJobs(JobsConfig{Workers: 1})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error)
+	go func() { served <- ue.Server.Serve(ln) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := ue.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("err = %v", err)
+	}
+	// DrainDelay 未结束同样关闭监听及异步任务
+	if err := <-served; err != http.ErrServerClosed {
+		t.Errorf("Serve: err = %v", err)
+	}
+	if conn, err := net.Dial("tcp", ln.Addr().String()); err == nil {
+		conn.Close()
+		t.Error("listener still open after Shutdown")
+	}
+	if _, err := ue.Jobs.Submit(func(ctx context.Context) (interface{}, error) { return nil, nil }); err != ErrJobsClosed {
+		t.Errorf("submit after shutdown: err = %v", err)
+	}
+}
+
+func TestShutdownWhileAsync(t *testing.T) {
+	ue := New(nil)
+	ue.POST("/reports", HandlerFunc(func(c *Context) error {
+		return c.Async(func(ctx context.Context) (interface{}, error) { return nil, nil })
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/reports", nil))
+		if rec.Code != http.StatusAccepted && rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("status = %d", rec.Code)
+		}
+	}()
+	if err := ue.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+}
+
+func TestShutdownWithoutAsync(t *testing.T) {
+	ue := New(nil)
+	ue.POST("/reports", HandlerFunc(func(c *Context) error {
+		return c.Async(func(ctx context.Context) (interface{}, error) { return nil, nil })
+	}))
+	if err := ue.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// 没有使用过 c.Async 时 Shutdown 不创建任务池
+	if ue.Jobs != nil {
+		t.Fatal("Shutdown created the job pool")
+	}
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/reports", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("async after shutdown: status = %d", rec.Code)
+	}
+}
@@ -27,14 +27,76 @@ func init() {
 	eci18n["400."+LANG_ZH_CN] = "请求失败"
 	eci18n["400."+LANG_ZH_TW] = "请求失败"
 	eci18n["400."+LANG_EN_US] = "Fail"
+
+	eci18n["10301."+LANG_ZH_CN] = "签名校验失败"
+	eci18n["10301."+LANG_ZH_TW] = "簽名校驗失敗"
+	eci18n["10301."+LANG_EN_US] = "Signature verification failed"
+
+	eci18n["10302."+LANG_ZH_CN] = "消息解密失败"
+	eci18n["10302."+LANG_ZH_TW] = "消息解密失敗"
+	eci18n["10302."+LANG_EN_US] = "Message decryption failed"
+
+	eci18n["10303."+LANG_ZH_CN] = "请求已过期或重复提交"
+	eci18n["10303."+LANG_ZH_TW] = "請求已過期或重複提交"
+	eci18n["10303."+LANG_EN_US] = "Request expired or replayed"
+
+	eci18n["10403."+LANG_ZH_CN] = "您所在的地区无法访问该服务"
+	eci18n["10403."+LANG_ZH_TW] = "您所在的地區無法訪問該服務"
+	eci18n["10403."+LANG_EN_US] = "This service is not available in your region"
+
+	eci18n["10429."+LANG_ZH_CN] = "调用次数已超过配额"
+	eci18n["10429."+LANG_ZH_TW] = "調用次數已超過配額"
+	eci18n["10429."+LANG_EN_US] = "Quota exceeded"
+
+	// 参数校验提示信息，见 RegisterValidationMessage
+	RegisterValidationMessage("required", LANG_ZH_CN, "{field} 不能为空")
+	RegisterValidationMessage("required", LANG_ZH_TW, "{field} 不能為空")
+	RegisterValidationMessage("required", LANG_EN_US, "{field} is required")
+
+	RegisterValidationMessage("min", LANG_ZH_CN, "{field} 不能小于 {param}")
+	RegisterValidationMessage("min", LANG_ZH_TW, "{field} 不能小於 {param}")
+	RegisterValidationMessage("min", LANG_EN_US, "{field} must be at least {param}")
+
+	RegisterValidationMessage("max", LANG_ZH_CN, "{field} 不能大于 {param}")
+	RegisterValidationMessage("max", LANG_ZH_TW, "{field} 不能大於 {param}")
+	RegisterValidationMessage("max", LANG_EN_US, "{field} must be at most {param}")
+
+	RegisterValidationMessage("len", LANG_ZH_CN, "{field} 的长度必须为 {param}")
+	RegisterValidationMessage("len", LANG_ZH_TW, "{field} 的長度必須為 {param}")
+	RegisterValidationMessage("len", LANG_EN_US, "{field} must have length {param}")
+
+	RegisterValidationMessage("oneof", LANG_ZH_CN, "{field} 必须是 [{param}] 之一")
+	RegisterValidationMessage("oneof", LANG_ZH_TW, "{field} 必須是 [{param}] 之一")
+	RegisterValidationMessage("oneof", LANG_EN_US, "{field} must be one of [{param}]")
 }
 
 var errReplyPool = sync.Pool{
 	New: func() interface{} {
+		errReplyPoolNews.Inc()
 		return &errReply{}
 	},
 }
 
+// errReply 池的获取、归还、新建次数
+var (
+	errReplyPoolGets = DefaultMetrics.Counter("err_reply_pool_gets")
+	errReplyPoolPuts = DefaultMetrics.Counter("err_reply_pool_puts")
+	errReplyPoolNews = DefaultMetrics.Counter("err_reply_pool_news")
+)
+
+func acquireErrReply(reply Reply) *errReply {
+	errReplyPoolGets.Inc()
+	er := errReplyPool.Get().(*errReply)
+	er.reset()
+	er.Reply = reply
+	return er
+}
+
+func releaseErrReply(er *errReply) {
+	errReplyPoolPuts.Inc()
+	errReplyPool.Put(er)
+}
+
 var _ Reply = (*reply)(nil)
 
 // Reply 响应
@@ -84,6 +146,7 @@ type reply struct {
 	em       string
 	lang     string
 	data     interface{}
+	ns       string // 所属的命名空间（见 Catalog）
 }
 
 func (r *reply) WithHTTPCode(c int) Reply {
@@ -122,16 +185,30 @@ func (r *reply) WithLang(lang string) Reply {
 	return &clone
 }
 
+// I18n 返回 lang 对应的描述信息，不修改 reply（Reply 通常是共享的包级变量）
 func (r *reply) I18n(lang string) string {
-	r.lang = lang
-	if em, ok := eci18n[fmt.Sprintf("%d.%s", r.ec, r.lang)]; ok {
+	if lang == "" {
+		lang = r.lang
+	}
+	if lang == "" {
+		lang = LANG_DEFAULT
+	}
+	if em, ok := lookupEM(r, lang); ok {
 		return em
 	}
 
-	log.Printf("I18n: invalid code/lang [%d.%s]", r.ec, r.lang)
+	log.Printf("I18n: invalid code/lang [%d.%s]", r.ec, lang)
 	return ""
 }
 
+// localize 返回使用 lang 对应描述信息的 Reply，没有对应的描述时原样返回
+func localize(r Reply, lang string) Reply {
+	if em, ok := lookupEM(r, lang); ok {
+		return r.WithEM(em)
+	}
+	return r
+}
+
 func (r *reply) WithData(d interface{}) Reply {
 	clone := *r
 	clone.data = d
@@ -140,6 +217,14 @@ func (r *reply) WithData(d interface{}) Reply {
 
 func (r *reply) reply() {}
 
+// replyData 返回 reply 携带的响应数据
+func replyData(r Reply) interface{} {
+	if rr, ok := r.(*reply); ok {
+		return rr.data
+	}
+	return nil
+}
+
 type errReply struct {
 	Reply
 	err    error
@@ -176,6 +261,15 @@ func (er *errReply) WithErr(err error) ErrReply {
 	return er
 }
 
+// ReplyOf 返回 err（ErrReply）携带的 Reply，便于将下游返回的异常通过 c.Abort 重新抛出
+func ReplyOf(err error) (Reply, bool) {
+	var er *errReply
+	if errors.As(err, &er) && er.Reply != nil {
+		return er.Reply, true
+	}
+	return nil, false
+}
+
 func (r *errReply) Error() string {
 	if r.err != nil {
 		return fmt.Sprintf("%+v", errors.WithMessage(r.err, r.EM()))
@@ -191,6 +285,13 @@ var OK Reply = &reply{
 	ec:       200,
 }
 
+// Accepted 请求已受理，异步处理（见 Context.Async）
+var Accepted Reply = &reply{
+	httpCode: http.StatusAccepted,
+	ec:       202,
+	em:       http.StatusText(http.StatusAccepted),
+}
+
 // ErrIllegalParams bad request 参数错误、服务端无法理解请求
 var ErrIllegalparams Reply = &reply{
 	httpCode: http.StatusBadRequest,
@@ -205,6 +306,13 @@ var ErrUnauthorized Reply = &reply{
 	em:       http.StatusText(http.StatusUnauthorized),
 }
 
+// ErrForbidden forbidden 拒绝访问
+var ErrForbidden Reply = &reply{
+	httpCode: http.StatusForbidden,
+	ec:       403,
+	em:       http.StatusText(http.StatusForbidden),
+}
+
 // ErrNotFound 404 not found
 var ErrNotFound Reply = &reply{
 	httpCode: http.StatusNotFound,
@@ -219,9 +327,93 @@ var ErrMethodNotAllowed Reply = &reply{
 	em: http.StatusText(http.StatusMethodNotAllowed),
 }
 
+// ErrNotAcceptable not acceptable 无法以客户端可接受的格式响应
+var ErrNotAcceptable Reply = &reply{
+	httpCode: http.StatusNotAcceptable,
+	ec:       406,
+	em:       http.StatusText(http.StatusNotAcceptable),
+}
+
+// ErrRequestEntityTooLarge request entity too large 请求体（上传文件）超过限制
+var ErrRequestEntityTooLarge Reply = &reply{
+	httpCode: http.StatusRequestEntityTooLarge,
+	ec:       413,
+	em:       http.StatusText(http.StatusRequestEntityTooLarge),
+}
+
+// ErrUnsupportedMediaType unsupported media type 不支持的请求（上传文件）类型
+var ErrUnsupportedMediaType Reply = &reply{
+	httpCode: http.StatusUnsupportedMediaType,
+	ec:       415,
+	em:       http.StatusText(http.StatusUnsupportedMediaType),
+}
+
+// ErrTooManyRequests too many requests 超过限流/配额
+var ErrTooManyRequests Reply = &reply{
+	httpCode: http.StatusTooManyRequests,
+	ec:       429,
+	em:       http.StatusText(http.StatusTooManyRequests),
+}
+
 // ErrInternal internal error 服务器内部错误
 var ErrInternal Reply = &reply{
 	httpCode: http.StatusInternalServerError,
 	ec:       500,
 	em:       http.StatusText(http.StatusInternalServerError),
 }
+
+// ErrBadGateway bad gateway 上游服务异常
+var ErrBadGateway Reply = &reply{
+	httpCode: http.StatusBadGateway,
+	ec:       502,
+	em:       http.StatusText(http.StatusBadGateway),
+}
+
+// ErrServiceUnavailable service unavailable 服务过载或正在停止
+var ErrServiceUnavailable Reply = &reply{
+	httpCode: http.StatusServiceUnavailable,
+	ec:       503,
+	em:       http.StatusText(http.StatusServiceUnavailable),
+}
+
+// ErrGatewayTimeout gateway timeout 处理超时
+var ErrGatewayTimeout Reply = &reply{
+	httpCode: http.StatusGatewayTimeout,
+	ec:       504,
+	em:       http.StatusText(http.StatusGatewayTimeout),
+}
+
+// ErrSignatureMismatch 回调（微信、webhook）签名校验失败
+var ErrSignatureMismatch Reply = &reply{
+	httpCode: http.StatusForbidden,
+	ec:       10301,
+	em:       "signature mismatch",
+}
+
+// ErrDecryptFailed 回调消息（微信安全模式）解密失败
+var ErrDecryptFailed Reply = &reply{
+	httpCode: http.StatusBadRequest,
+	ec:       10302,
+	em:       "decrypt message failed",
+}
+
+// ErrReplayed 回调（微信、webhook）时间戳超出有效期或 nonce 重复，疑似重放
+var ErrReplayed Reply = &reply{
+	httpCode: http.StatusForbidden,
+	ec:       10303,
+	em:       "request replayed",
+}
+
+// ErrRegionBlocked 按地区（GeoIP）拒绝访问
+var ErrRegionBlocked Reply = &reply{
+	httpCode: http.StatusForbidden,
+	ec:       10403,
+	em:       "region blocked",
+}
+
+// ErrQuotaExceeded 调用次数超过配额（见 Quota）
+var ErrQuotaExceeded Reply = &reply{
+	httpCode: http.StatusTooManyRequests,
+	ec:       10429,
+	em:       "quota exceeded",
+}
@@ -0,0 +1,101 @@
+package uecho
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ErrorRenderer 以某种格式写出异常响应，reply 的 em 在 Debug 模式下为完整的错误信息
+type ErrorRenderer func(c *Context, reply Reply) error
+
+// ErrorRenderers DefaultHTTPErrorHandler 根据 Accept 选择的异常响应格式
+// 没有客户端可接受的格式时使用 Default，异常响应不会再返回 406
+type ErrorRenderers struct {
+	// Default Accept 为空、*/* 或没有匹配格式时使用的格式
+	Default string
+
+	types     []string
+	renderers map[string]ErrorRenderer
+}
+
+// NewErrorRenderers 创建 ErrorRenderers，默认注册 JSON 信封、纯文本及 problem+json 三种格式
+// HTML 错误页只在配置了 UEcho.ErrorPage（或通过 Register 注册 text/html）时使用，浏览器的请求默认仍返回 JSON 信封
+func NewErrorRenderers() *ErrorRenderers {
+	r := &ErrorRenderers{
+		Default:   echo.MIMEApplicationJSON,
+		renderers: make(map[string]ErrorRenderer),
+	}
+	r.Register(echo.MIMEApplicationJSON, jsonErrorRenderer)
+	r.Register(echo.MIMETextPlain, textErrorRenderer)
+	r.Register(MIMEApplicationProblemJSON, problemJSONRenderer)
+	return r
+}
+
+// Register 注册（或覆盖）一种异常响应格式，先注册的格式在 q 值相同时优先
+func (r *ErrorRenderers) Register(mediaType string, fn ErrorRenderer) {
+	if _, ok := r.renderers[mediaType]; !ok {
+		r.types = append(r.types, mediaType)
+	}
+	r.renderers[mediaType] = fn
+}
+
+// Negotiate 返回 accept 对应的异常响应格式
+func (r *ErrorRenderers) Negotiate(accept string) (string, ErrorRenderer) {
+	if t, ok := negotiate(accept, r.Default, r.types, true); ok {
+		if fn, ok := r.renderers[t]; ok {
+			return t, fn
+		}
+	}
+	return r.Default, r.renderers[r.Default]
+}
+
+// negotiateHTML 同 Negotiate，html 为 true 且未注册 text/html 时加入 HTML 错误页
+func (r *ErrorRenderers) negotiateHTML(accept string, html bool) (string, ErrorRenderer) {
+	if !html || r.renderers[echo.MIMETextHTML] != nil {
+		return r.Negotiate(accept)
+	}
+	types := append(r.types[:len(r.types):len(r.types)], echo.MIMETextHTML)
+	if t, ok := negotiate(accept, r.Default, types, true); ok && t == echo.MIMETextHTML {
+		return t, htmlErrorRenderer
+	}
+	return r.Negotiate(accept)
+}
+
+// problemJSON 返回 problem+json 格式（可通过 Register 覆盖）
+func (r *ErrorRenderers) problemJSON() ErrorRenderer {
+	if fn, ok := r.renderers[MIMEApplicationProblemJSON]; ok {
+		return fn
+	}
+	return problemJSONRenderer
+}
+
+// RegisterErrorRenderer 注册（或覆盖）一种异常响应格式
+func (e *UEcho) RegisterErrorRenderer(mediaType string, fn ErrorRenderer) {
+	e.ErrorRenderers.Register(mediaType, fn)
+}
+
+func jsonErrorRenderer(c *Context, r Reply) error {
+	return c.JSON(r.HTTPCode(), &HttpApiResponse{
+		EC:        r.EC(),
+		EM:        r.EM(),
+		Data:      replyData(r),
+		RequestID: c.RequestID(),
+	})
+}
+
+// htmlErrorRenderer 渲染 ErrorPage 的错误页模板，没有可用的模板时输出简单的 HTML 页面
+func htmlErrorRenderer(c *Context, r Reply) error {
+	if c.ue.renderErrorPage(c, r.HTTPCode(), r.EC(), r.EM()) {
+		return nil
+	}
+	return c.HTML(r.HTTPCode(), fmt.Sprintf(
+		"<!DOCTYPE html>\n<html><head><title>%d %s</title></head><body><h1>%d %s</h1><p>%s</p></body></html>\n",
+		r.HTTPCode(), http.StatusText(r.HTTPCode()), r.HTTPCode(), http.StatusText(r.HTTPCode()), html.EscapeString(r.EM())))
+}
+
+func textErrorRenderer(c *Context, r Reply) error {
+	return c.String(r.HTTPCode(), fmt.Sprintf("%d %s\n", r.EC(), r.EM()))
+}
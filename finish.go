@@ -0,0 +1,25 @@
+package uecho
+
+// OnFinish 注册请求结束时执行的函数：响应（包括 HTTPErrorHandler 输出的异常响应）写出之后、Context 归还之前，
+// 按注册的相反顺序执行。status 为响应状态码，err 为处理链返回的错误（没有时为 nil）
+// 可用于请求级别的清理、指标的最终统计、写后缓存等，fn 不应持有 c 或 err
+func (c *Context) OnFinish(fn func(status int, err error)) {
+	c.onFinish = append(c.onFinish, fn)
+}
+
+// finish 执行 OnFinish 注册的函数，单个函数 panic 不影响其他函数及 Context 的归还
+func (c *Context) finish(err error) {
+	status := c.Response().Status
+	for i := len(c.onFinish) - 1; i >= 0; i-- {
+		c.runOnFinish(c.onFinish[i], status, err)
+	}
+}
+
+func (c *Context) runOnFinish(fn func(int, error), status int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.ue.Logger.Errorf("uecho: OnFinish panic: %v", r)
+		}
+	}()
+	fn(status, err)
+}
@@ -0,0 +1,47 @@
+package uecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestOnFinish(t *testing.T) {
+	ue := New(nil)
+	var calls []string
+	var gotStatus int
+	var gotErr error
+	ue.GET("/ok", HandlerFunc(func(c *Context) error {
+		c.OnFinish(func(status int, err error) {
+			calls = append(calls, "first")
+			gotStatus, gotErr = status, err
+		})
+		c.OnFinish(func(int, error) { panic("boom") })
+		c.OnFinish(func(int, error) { calls = append(calls, "last") })
+		return c.NoContent(http.StatusNoContent)
+	}))
+	ue.GET("/fail", HandlerFunc(func(c *Context) error {
+		c.OnFinish(func(status int, err error) {
+			gotStatus, gotErr = status, err
+		})
+		return c.Abort(ErrForbidden)
+	}))
+
+	ue.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if !reflect.DeepEqual(calls, []string{"last", "first"}) || gotStatus != http.StatusNoContent || gotErr != nil {
+		t.Errorf("calls %v, status %d, err %v", calls, gotStatus, gotErr)
+	}
+
+	ue.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	if gotStatus != http.StatusForbidden || gotErr == nil {
+		t.Errorf("status %d, err %v", gotStatus, gotErr)
+	}
+
+	// Context 复用时不保留上个请求注册的函数
+	calls = nil
+	ue.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+	if len(calls) != 0 {
+		t.Errorf("stale hooks ran: %v", calls)
+	}
+}
@@ -12,6 +12,20 @@ type Group struct {
 	prefix     string
 	middleware []echo.MiddlewareFunc
 	echo       *UEcho
+	meta       map[string]interface{}
+	routes     []*Route
+}
+
+// Meta 设置组内路由的元数据，对已注册及之后注册的路由（含子分组）生效
+func (g *Group) Meta(key string, value interface{}) *Group {
+	if g.meta == nil {
+		g.meta = make(map[string]interface{})
+	}
+	g.meta[key] = value
+	for _, r := range g.routes {
+		r.Meta(key, value)
+	}
+	return g
 }
 
 // Use implements `Echo#Use()` for sub-routes within the Group.
@@ -22,58 +36,59 @@ func (g *Group) Use(middleware ...echo.MiddlewareFunc) {
 	}
 	// Allow all requests to reach the group as they might get dropped if router
 	// doesn't find a match, making none of the group middleware process.
-	g.Any("", WrapUHandler(echo.NotFoundHandler))
-	g.Any("/*", WrapUHandler(echo.NotFoundHandler))
+	for _, r := range append(g.Any("", WrapUHandler(echo.NotFoundHandler)), g.Any("/*", WrapUHandler(echo.NotFoundHandler))...) {
+		r.catchAll = true
+	}
 }
 
 // CONNECT implements `Echo#CONNECT()` for sub-routes within the Group.
-func (g *Group) CONNECT(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (g *Group) CONNECT(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return g.Add(http.MethodConnect, path, h, m...)
 }
 
 // DELETE implements `Echo#DELETE()` for sub-routes within the Group.
-func (g *Group) DELETE(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (g *Group) DELETE(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return g.Add(http.MethodDelete, path, h, m...)
 }
 
 // GET implements `Echo#GET()` for sub-routes within the Group.
-func (g *Group) GET(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (g *Group) GET(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return g.Add(http.MethodGet, path, h, m...)
 }
 
 // HEAD implements `Echo#HEAD()` for sub-routes within the Group.
-func (g *Group) HEAD(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (g *Group) HEAD(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return g.Add(http.MethodHead, path, h, m...)
 }
 
 // OPTIONS implements `Echo#OPTIONS()` for sub-routes within the Group.
-func (g *Group) OPTIONS(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (g *Group) OPTIONS(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return g.Add(http.MethodOptions, path, h, m...)
 }
 
 // PATCH implements `Echo#PATCH()` for sub-routes within the Group.
-func (g *Group) PATCH(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (g *Group) PATCH(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return g.Add(http.MethodPatch, path, h, m...)
 }
 
 // POST implements `Echo#POST()` for sub-routes within the Group.
-func (g *Group) POST(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (g *Group) POST(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return g.Add(http.MethodPost, path, h, m...)
 }
 
 // PUT implements `Echo#PUT()` for sub-routes within the Group.
-func (g *Group) PUT(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (g *Group) PUT(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return g.Add(http.MethodPut, path, h, m...)
 }
 
 // TRACE implements `Echo#TRACE()` for sub-routes within the Group.
-func (g *Group) TRACE(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (g *Group) TRACE(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return g.Add(http.MethodTrace, path, h, m...)
 }
 
 // Any implements `Echo#Any()` for sub-routes within the Group.
-func (g *Group) Any(path string, handler Handler, middleware ...echo.MiddlewareFunc) []*echo.Route {
-	routes := make([]*echo.Route, len(methods))
+func (g *Group) Any(path string, handler Handler, middleware ...echo.MiddlewareFunc) []*Route {
+	routes := make([]*Route, len(methods))
 	for i, m := range methods {
 		routes[i] = g.Add(m, path, handler, middleware...)
 	}
@@ -81,8 +96,8 @@ func (g *Group) Any(path string, handler Handler, middleware ...echo.MiddlewareF
 }
 
 // Match implements `Echo#Match()` for sub-routes within the Group.
-func (g *Group) Match(methods []string, path string, handler HandlerFunc, middleware ...echo.MiddlewareFunc) []*echo.Route {
-	routes := make([]*echo.Route, len(methods))
+func (g *Group) Match(methods []string, path string, handler HandlerFunc, middleware ...echo.MiddlewareFunc) []*Route {
+	routes := make([]*Route, len(methods))
 	for i, m := range methods {
 		routes[i] = g.Add(m, path, handler, middleware...)
 	}
@@ -94,28 +109,38 @@ func (g *Group) Group(prefix string, middleware ...echo.MiddlewareFunc) (sg *Gro
 	m := make([]echo.MiddlewareFunc, 0, len(g.middleware)+len(middleware))
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
-	sg = g.echo.Group(g.prefix+prefix, m...)
+	sg = g.echo.Group(g.prefix + prefix)
 	sg.host = g.host
+	for k, v := range g.meta {
+		sg.Meta(k, v)
+	}
+	sg.Use(m...)
 	return
 }
 
 // Static implements `Echo#Static()` for sub-routes within the Group.
 func (g *Group) Static(prefix, root string) {
-	g.static(prefix, root, g.GET)
+	g.static(prefix, root, g.Add)
 }
 
 // File implements `Echo#File()` for sub-routes within the Group.
 func (g *Group) File(path, file string) {
-	g.file(path, file, g.GET)
+	g.file(path, file, g.Add)
 }
 
 // Add implements `Echo#Add()` for sub-routes within the Group.
-func (g *Group) Add(method, path string, handler Handler, middleware ...echo.MiddlewareFunc) *echo.Route {
+func (g *Group) Add(method, path string, handler Handler, middleware ...echo.MiddlewareFunc) *Route {
 	// Combine into a new slice to avoid accidentally passing the same slice for
 	// multiple routes, which would lead to later add() calls overwriting the
 	// middleware from earlier calls.
 	m := make([]echo.MiddlewareFunc, 0, len(g.middleware)+len(middleware))
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
-	return g.echo.add(g.host, method, g.prefix+path, handler, m...)
+	r := g.echo.add(g.host, method, g.prefix+path, handler, m...)
+	r.Group = g.prefix
+	for k, v := range g.meta {
+		r.Meta(k, v)
+	}
+	g.routes = append(g.routes, r)
+	return r
 }
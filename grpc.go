@@ -0,0 +1,346 @@
+package uecho
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MetaGRPCMethod 路由对应的 gRPC 方法（/package.Service/Method）的元数据 key
+const MetaGRPCMethod = "grpc_method"
+
+// GRPCMethod 以 HTTP 暴露的 gRPC 方法
+type GRPCMethod struct {
+	// HTTPMethod 路由的 http method
+	// Optional. Default value POST.
+	HTTPMethod string
+
+	// Path 路由路径，可包含参数（会合并到请求消息中）
+	// Optional. Default value FullMethod.
+	Path string
+
+	// FullMethod gRPC 方法全名，例如 /order.v1.OrderService/GetOrder
+	FullMethod string
+
+	// Invoke 调用 gRPC 方法，dec 将请求解码到请求消息（与生成代码中 methodHandler 的 dec 相同）
+	Invoke func(ctx context.Context, dec func(interface{}) error) (interface{}, error)
+
+	// MaxBodySize 请求体的最大长度，超过时返回 413
+	// Optional. Default value 1MB.
+	MaxBodySize int64
+}
+
+// GRPCCodec JSON 与请求/响应消息之间的转换，protobuf 消息建议使用 protojson 实现
+type GRPCCodec interface {
+	Unmarshal(data []byte, msg interface{}) error
+	Marshal(msg interface{}) ([]byte, error)
+}
+
+// JSONCodec 基于 encoding/json 的 GRPCCodec
+type JSONCodec struct{}
+
+func (JSONCodec) Unmarshal(data []byte, msg interface{}) error { return json.Unmarshal(data, msg) }
+func (JSONCodec) Marshal(msg interface{}) ([]byte, error)      { return json.Marshal(msg) }
+
+// GRPCMethodsFromServiceDesc 根据生成代码中的 *grpc.ServiceDesc 及服务实现构造全部 unary 方法，
+// 通过反射调用 MethodDesc.Handler，因此本包不需要依赖 grpc
+// srv 为 nil 或没有实现 desc.HandlerType 时返回错误
+func GRPCMethodsFromServiceDesc(desc interface{}, srv interface{}) ([]GRPCMethod, error) {
+	v := reflect.Indirect(reflect.ValueOf(desc))
+	if v.Kind() != reflect.Struct {
+		return nil, errors.New("grpc: desc must be a *grpc.ServiceDesc")
+	}
+	serviceName, methods := v.FieldByName("ServiceName"), v.FieldByName("Methods")
+	if serviceName.Kind() != reflect.String || methods.Kind() != reflect.Slice {
+		return nil, errors.New("grpc: desc must be a *grpc.ServiceDesc")
+	}
+	if sv := reflect.ValueOf(srv); !sv.IsValid() || sv.Kind() == reflect.Ptr && sv.IsNil() {
+		return nil, fmt.Errorf("grpc: nil service implementation for %s", serviceName.String())
+	}
+	// HandlerType 为 (*XxxServer)(nil)
+	if ht := v.FieldByName("HandlerType"); ht.IsValid() && ht.Kind() == reflect.Interface && !ht.IsNil() {
+		if t := ht.Elem().Type(); t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Interface && !reflect.TypeOf(srv).Implements(t.Elem()) {
+			return nil, fmt.Errorf("grpc: %T does not implement %v", srv, t.Elem())
+		}
+	}
+
+	result := make([]GRPCMethod, 0, methods.Len())
+	for i := 0; i < methods.Len(); i++ {
+		m := methods.Index(i)
+		name, handler := m.FieldByName("MethodName"), m.FieldByName("Handler")
+		if name.Kind() != reflect.String || handler.Kind() != reflect.Func || handler.Type().NumIn() != 4 {
+			return nil, fmt.Errorf("grpc: unexpected method desc %v", m.Type())
+		}
+
+		interceptor := reflect.Zero(handler.Type().In(3))
+		result = append(result, GRPCMethod{
+			FullMethod: "/" + serviceName.String() + "/" + name.String(),
+			Invoke: func(ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+				out := handler.Call([]reflect.Value{
+					reflect.ValueOf(srv), reflect.ValueOf(ctx), reflect.ValueOf(dec), interceptor,
+				})
+				err, _ := out[1].Interface().(error)
+				return out[0].Interface(), err
+			},
+		})
+	}
+	return result, nil
+}
+
+// GRPCCodeReplies gRPC 状态码到 Reply 的映射，可按需修改
+var GRPCCodeReplies = map[uint32]Reply{
+	1:  NewReply(499, 499, "Client Closed Request"),                                            // Canceled
+	2:  ErrInternal,                                                                            // Unknown
+	3:  ErrIllegalparams,                                                                       // InvalidArgument
+	4:  ErrGatewayTimeout,                                                                      // DeadlineExceeded
+	5:  ErrNotFound,                                                                            // NotFound
+	6:  NewReply(http.StatusConflict, 409, http.StatusText(http.StatusConflict)),               // AlreadyExists
+	7:  ErrForbidden,                                                                           // PermissionDenied
+	8:  NewReply(http.StatusTooManyRequests, 429, http.StatusText(http.StatusTooManyRequests)), // ResourceExhausted
+	9:  ErrIllegalparams,                                                                       // FailedPrecondition
+	10: NewReply(http.StatusConflict, 409, http.StatusText(http.StatusConflict)),               // Aborted
+	11: ErrIllegalparams,                                                                       // OutOfRange
+	12: NewReply(http.StatusNotImplemented, 501, http.StatusText(http.StatusNotImplemented)),   // Unimplemented
+	13: ErrInternal,                                                                            // Internal
+	14: ErrServiceUnavailable,                                                                  // Unavailable
+	15: ErrInternal,                                                                            // DataLoss
+	16: ErrUnauthorized,                                                                        // Unauthenticated
+}
+
+// grpcStatus 通过反射读取 err 的 GRPCStatus()（*status.Status）的 code 与 message
+func grpcStatus(err error) (uint32, string, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		m := reflect.ValueOf(err).MethodByName("GRPCStatus")
+		if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+			continue
+		}
+		st := m.Call(nil)[0]
+		if st.Kind() == reflect.Ptr && st.IsNil() {
+			return 0, "", false
+		}
+		code, msg := st.MethodByName("Code"), st.MethodByName("Message")
+		if !code.IsValid() || !msg.IsValid() {
+			return 0, "", false
+		}
+		return uint32(code.Call(nil)[0].Uint()), msg.Call(nil)[0].String(), true
+	}
+	return 0, "", false
+}
+
+// ReplyFromGRPC 将 gRPC 调用返回的错误转换为 Reply，不是 gRPC status 错误时返回 ErrInternal
+func ReplyFromGRPC(err error) Reply {
+	code, _, ok := grpcStatus(err)
+	if !ok {
+		return ErrInternal
+	}
+	if r, ok := GRPCCodeReplies[code]; ok {
+		return r
+	}
+	return ErrInternal
+}
+
+// MapGRPCError ErrorMapper，将 gRPC status 错误按 GRPCCodeReplies 转换
+//
+//	e.RegisterErrorMapper(uecho.MapGRPCError)
+func MapGRPCError(err error) (Reply, bool) {
+	code, _, ok := grpcStatus(err)
+	if !ok {
+		return nil, false
+	}
+	r, ok := GRPCCodeReplies[code]
+	return r, ok
+}
+
+// GRPCHandler 将 gRPC 方法转换为 Handler：JSON 请求（GET 为 query 参数）及路由参数解码为请求消息，
+// 响应消息编码后放入信封的 data，错误按 GRPCCodeReplies 转换
+// query 参数、路由参数按请求消息对应字段的类型转换为 bool、数字，请求消息解码失败时返回 ErrIllegalparams
+func GRPCHandler(m GRPCMethod, codec GRPCCodec) Handler {
+	if m.Invoke == nil {
+		panic("uecho: GRPCHandler requires GRPCMethod.Invoke")
+	}
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	if m.MaxBodySize <= 0 {
+		m.MaxBodySize = 1 << 20
+	}
+	return HandlerFunc(func(c *Context) error {
+		body, fields, err := grpcRequest(c, m.MaxBodySize)
+		if err != nil {
+			return err
+		}
+
+		var decErr error
+		dec := func(msg interface{}) error {
+			data := body
+			if data == nil {
+				if data, decErr = json.Marshal(convertGRPCFields(fields, msg)); decErr != nil {
+					return decErr
+				}
+			}
+			decErr = codec.Unmarshal(data, msg)
+			return decErr
+		}
+		resp, err := m.Invoke(c.RequestContext(), dec)
+		if decErr != nil {
+			return c.Abort(ErrIllegalparams).WithErr(decErr)
+		}
+		if err != nil {
+			_, msg, _ := grpcStatus(err)
+			return c.Abort(ReplyFromGRPC(err)).WithErr(err).WithField("grpc_message", msg)
+		}
+
+		out, err := codec.Marshal(resp)
+		if err != nil {
+			return c.Abort(ErrInternal).WithErr(err)
+		}
+		return c.SetPayload(OK.WithData(json.RawMessage(out)))
+	})
+}
+
+// grpcRequest 读取请求消息：没有路由参数的 JSON 请求体原样返回，
+// 否则返回请求体（GET/DELETE 为 query 参数）合并路由参数后的字段，解码时再按请求消息的字段类型转换
+// 请求体超过 limit 时返回 413
+func grpcRequest(c *Context, limit int64) ([]byte, map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	switch c.Method() {
+	case http.MethodGet, http.MethodDelete:
+		for key, values := range c.QueryParams() {
+			if len(values) == 1 {
+				fields[key] = values[0]
+			} else {
+				fields[key] = values
+			}
+		}
+	default:
+		body, err := ioutil.ReadAll(io.LimitReader(c.Request().Body, limit+1))
+		if err != nil {
+			return nil, nil, c.Abort(ErrIllegalparams).WithErr(err)
+		}
+		if int64(len(body)) > limit {
+			return nil, nil, c.Abort(ErrRequestEntityTooLarge).WithField("max_body_size", limit)
+		}
+		if len(c.ParamNames()) == 0 && len(body) > 0 {
+			return body, nil, nil
+		}
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &fields); err != nil {
+				return nil, nil, c.Abort(ErrIllegalparams).WithErr(err)
+			}
+		}
+	}
+
+	for _, name := range c.ParamNames() {
+		fields[name] = c.Param(name)
+	}
+	return nil, fields, nil
+}
+
+// convertGRPCFields 按 msg 字段（json tag、protobuf tag 的 name、json 及字段名）的类型
+// 将字符串（及字符串切片）转换为 bool、数字，无法转换的值（例如枚举名）保持不变
+func convertGRPCFields(fields map[string]interface{}, msg interface{}) map[string]interface{} {
+	t := reflect.TypeOf(msg)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return fields
+	}
+
+	types := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		types[sf.Name] = sf.Type
+		if name := strings.Split(sf.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			types[name] = sf.Type
+		}
+		for _, opt := range strings.Split(sf.Tag.Get("protobuf"), ",") {
+			if strings.HasPrefix(opt, "name=") || strings.HasPrefix(opt, "json=") {
+				types[opt[5:]] = sf.Type
+			}
+		}
+	}
+
+	for key, value := range fields {
+		ft, ok := types[key]
+		if !ok {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			fields[key] = convertGRPCValue(v, ft)
+		case []string:
+			if ft.Kind() != reflect.Slice {
+				continue
+			}
+			values := make([]interface{}, len(v))
+			for i, s := range v {
+				values[i] = convertGRPCValue(s, ft.Elem())
+			}
+			fields[key] = values
+		}
+	}
+	return fields
+}
+
+func convertGRPCValue(s string, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return s
+}
+
+// GRPC 将 gRPC 方法注册为路由
+func (e *UEcho) GRPC(methods []GRPCMethod, codec GRPCCodec, m ...echo.MiddlewareFunc) []*Route {
+	return registerGRPC(e.Add, methods, codec, m...)
+}
+
+// GRPC 将 gRPC 方法注册为路由
+func (g *Group) GRPC(methods []GRPCMethod, codec GRPCCodec, m ...echo.MiddlewareFunc) []*Route {
+	return registerGRPC(g.Add, methods, codec, m...)
+}
+
+func registerGRPC(add addFunc, methods []GRPCMethod, codec GRPCCodec, m ...echo.MiddlewareFunc) []*Route {
+	routes := make([]*Route, len(methods))
+	for i, method := range methods {
+		httpMethod, path := method.HTTPMethod, method.Path
+		if httpMethod == "" {
+			httpMethod = http.MethodPost
+		}
+		if path == "" {
+			path = method.FullMethod
+		}
+		routes[i] = add(httpMethod, path, GRPCHandler(method, codec), m...).Meta(MetaGRPCMethod, method.FullMethod)
+	}
+	return routes
+}
@@ -0,0 +1,189 @@
+package uecho
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// 模拟生成代码中的 grpc.ServiceDesc / MethodDesc
+type testInterceptor func()
+
+type testMethodDesc struct {
+	MethodName string
+	Handler    func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor testInterceptor) (interface{}, error)
+}
+
+type testServiceDesc struct {
+	ServiceName string
+	Methods     []testMethodDesc
+}
+
+type testStatus struct {
+	code uint32
+	msg  string
+}
+
+func (s *testStatus) Code() uint32    { return s.code }
+func (s *testStatus) Message() string { return s.msg }
+
+type testStatusError struct{ s *testStatus }
+
+func (e testStatusError) Error() string           { return e.s.msg }
+func (e testStatusError) GRPCStatus() *testStatus { return e.s }
+
+type getOrderRequest struct {
+	ID string `json:"id"`
+}
+
+type orderServer struct{}
+
+func (orderServer) GetOrder(_ context.Context, req *getOrderRequest) (interface{}, error) {
+	if req.ID == "404" {
+		return nil, testStatusError{&testStatus{code: 5, msg: "order not found"}}
+	}
+	return map[string]string{"id": req.ID}, nil
+}
+
+var testOrderServiceDesc = testServiceDesc{
+	ServiceName: "order.v1.OrderService",
+	Methods: []testMethodDesc{{
+		MethodName: "GetOrder",
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ testInterceptor) (interface{}, error) {
+			in := new(getOrderRequest)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			return srv.(orderServer).GetOrder(ctx, in)
+		},
+	}},
+}
+
+func TestGRPC(t *testing.T) {
+	methods, err := GRPCMethodsFromServiceDesc(&testOrderServiceDesc, orderServer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(methods) != 1 || methods[0].FullMethod != "/order.v1.OrderService/GetOrder" {
+		t.Fatalf("methods = %+v", methods)
+	}
+
+	ue := New(nil)
+	routes := ue.GRPC(methods, nil)
+	if v, _ := routes[0].GetMeta(MetaGRPCMethod); v != "/order.v1.OrderService/GetOrder" {
+		t.Fatalf("meta = %v", v)
+	}
+	methods[0].HTTPMethod, methods[0].Path = http.MethodGet, "/v1/orders/:id"
+	ue.GRPC(methods, nil)
+
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/order.v1.OrderService/GetOrder", strings.NewReader(`{"id":"7"}`)))
+	var resp struct {
+		EC   int               `json:"ec"`
+		Data map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Data["id"] != "7" {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders/8", nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Data["id"] != "8" {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders/404", nil))
+	if rec.Code != ErrNotFound.HTTPCode() {
+		t.Fatalf("not found: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/order.v1.OrderService/GetOrder", strings.NewReader(`{"id":"`+strings.Repeat("7", 1<<20)+`"}`)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("large body: status = %d", rec.Code)
+	}
+}
+
+type listOrdersRequest struct {
+	PageSize int32   `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	Paid     bool    `protobuf:"varint,2,opt,name=paid,proto3" json:"paid,omitempty"`
+	IDs      []int64 `json:"ids,omitempty"`
+}
+
+func TestGRPCQueryTypes(t *testing.T) {
+	desc := testServiceDesc{
+		ServiceName: "order.v1.OrderService",
+		Methods: []testMethodDesc{{
+			MethodName: "ListOrders",
+			Handler: func(_ interface{}, _ context.Context, dec func(interface{}) error, _ testInterceptor) (interface{}, error) {
+				in := new(listOrdersRequest)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return in, nil
+			},
+		}},
+	}
+	if _, err := GRPCMethodsFromServiceDesc(&desc, nil); err == nil {
+		t.Fatal("nil srv: expected error")
+	}
+	methods, err := GRPCMethodsFromServiceDesc(&desc, orderServer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	methods[0].HTTPMethod, methods[0].Path = http.MethodGet, "/v1/orders"
+	ue := New(nil)
+	ue.GRPC(methods, nil)
+
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders?page_size=20&paid=true&ids=1&ids=2", nil))
+	var resp struct {
+		Data listOrdersRequest `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Data.PageSize != 20 || !resp.Data.Paid || len(resp.Data.IDs) != 2 {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/orders?paid=maybe", nil))
+	if rec.Code != ErrIllegalparams.HTTPCode() {
+		t.Fatalf("bad bool: status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestErrorMapper(t *testing.T) {
+	ue := New(nil)
+	ue.RegisterErrorMapper(MapGRPCError, func(err error) (Reply, bool) {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return ErrGatewayTimeout, true
+		}
+		return nil, false
+	})
+	ue.GET("/grpc", HandlerFunc(func(c *Context) error {
+		return fmt.Errorf("call: %w", testStatusError{&testStatus{code: 7, msg: "denied"}})
+	}))
+	ue.GET("/deadline", HandlerFunc(func(c *Context) error {
+		return fmt.Errorf("query: %w", context.DeadlineExceeded)
+	}))
+	ue.GET("/other", HandlerFunc(func(c *Context) error {
+		return errors.New("other")
+	}))
+
+	for path, status := range map[string]int{
+		"/grpc":     http.StatusForbidden,
+		"/deadline": http.StatusGatewayTimeout,
+		"/other":    http.StatusInternalServerError,
+	} {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != status {
+			t.Fatalf("%s: status = %d, want %d", path, rec.Code, status)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package uecho
+
+import (
+	"net"
+	"strings"
+)
+
+// hostPattern 带参数的 host，例如 {tenant}.api.example.com
+type hostPattern struct {
+	labels []string
+	router *Router
+}
+
+// isHostPattern host 是否包含 {name} 形式的参数
+func isHostPattern(host string) bool {
+	return strings.Contains(host, "{")
+}
+
+func newHostPattern(host string, router *Router) hostPattern {
+	return hostPattern{labels: strings.Split(strings.ToLower(host), "."), router: router}
+}
+
+// match host 与 pattern 逐级（以 . 分隔）匹配，{name} 匹配任意一级并记录参数
+func (p hostPattern) match(host string, names, values []string) ([]string, []string, bool) {
+	labels := strings.Split(host, ".")
+	if len(labels) != len(p.labels) {
+		return names, values, false
+	}
+	for i, label := range p.labels {
+		if strings.HasPrefix(label, "{") && strings.HasSuffix(label, "}") {
+			if labels[i] == "" {
+				return names, values, false
+			}
+			names = append(names, label[1:len(label)-1])
+			values = append(values, labels[i])
+			continue
+		}
+		if !strings.EqualFold(label, labels[i]) {
+			return names, values, false
+		}
+	}
+	return names, values, true
+}
+
+// matchRouter 返回请求 host 对应的 Router：先完整匹配，再依次匹配带参数的 host，host 参数记录到 Context
+func (e *UEcho) matchRouter(c *Context, host string) *Router {
+	if r, ok := e.routers[host]; ok {
+		return r
+	}
+	if len(e.hostPatterns) == 0 {
+		return e.router
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	for _, p := range e.hostPatterns {
+		names, values, ok := p.match(host, c.hostParamNames[:0], c.hostParamValues[:0])
+		if ok {
+			c.hostParamNames, c.hostParamValues = names, values
+			return p.router
+		}
+	}
+	c.hostParamNames, c.hostParamValues = c.hostParamNames[:0], c.hostParamValues[:0]
+	return e.router
+}
+
+// Param 返回路径参数，路径中没有该参数时返回 host 参数（e.Host("{tenant}.api.example.com")）
+func (c *Context) Param(name string) string {
+	for _, n := range c.ParamNames() {
+		if n == name {
+			return c.Context.Param(name)
+		}
+	}
+	return c.HostParam(name)
+}
+
+// HostParam 返回 host 参数
+func (c *Context) HostParam(name string) string {
+	for i, n := range c.hostParamNames {
+		if n == name {
+			return c.hostParamValues[i]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,194 @@
+package uecho
+
+import (
+	"sync"
+
+	"golang.org/x/net/websocket"
+)
+
+// DefaultHubBufferSize 默认的 Hub.BufferSize
+const DefaultHubBufferSize = 64
+
+var hubEvictions = DefaultMetrics.Counter("hub_evictions")
+
+// HubMessage Hub 发布的消息
+type HubMessage struct {
+	Topic string      `json:"topic"`
+	ID    string      `json:"id,omitempty"`
+	Event string      `json:"event,omitempty"`
+	Data  interface{} `json:"data"`
+}
+
+// Hub 进程内的 pub/sub，用于 SSE、WebSocket 的实时推送，小规模部署无需外部消息中间键
+// 每个订阅者有独立的发送缓冲，缓冲写满的订阅者（消费过慢）会被移除，其 C 随之关闭
+type Hub struct {
+	// BufferSize 每个订阅者的发送缓冲大小
+	// Optional. Default value 64.
+	BufferSize int
+
+	mu     sync.RWMutex
+	topics map[string]map[*Subscription]struct{}
+}
+
+// NewHub 创建 Hub
+func NewHub() *Hub {
+	return &Hub{BufferSize: DefaultHubBufferSize, topics: make(map[string]map[*Subscription]struct{})}
+}
+
+// Hub 返回 UEcho 的 Hub（第一次调用时创建）
+func (e *UEcho) Hub() *Hub {
+	e.hubOnce.Do(func() {
+		e.hub = NewHub()
+	})
+	return e.hub
+}
+
+// Subscription 订阅
+type Subscription struct {
+	// C 订阅的消息，订阅被取消或因消费过慢被移除时关闭
+	C <-chan HubMessage
+
+	hub     *Hub
+	c       chan HubMessage
+	topics  []string
+	closed  bool
+	evicted bool
+}
+
+// Subscribe 订阅 topics，不再需要时应调用 Close
+func (h *Hub) Subscribe(topics ...string) *Subscription {
+	size := h.BufferSize
+	if size <= 0 {
+		size = DefaultHubBufferSize
+	}
+	ch := make(chan HubMessage, size)
+	sub := &Subscription{C: ch, hub: h, c: ch, topics: topics}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, topic := range topics {
+		subs, ok := h.topics[topic]
+		if !ok {
+			subs = make(map[*Subscription]struct{})
+			h.topics[topic] = subs
+		}
+		subs[sub] = struct{}{}
+	}
+	return sub
+}
+
+// Publish 向 topic 的全部订阅者发送消息（不阻塞），返回送达的订阅者数量
+func (h *Hub) Publish(topic string, msg HubMessage) int {
+	msg.Topic = topic
+	var delivered int
+	var slow []*Subscription
+
+	h.mu.RLock()
+	for sub := range h.topics[topic] {
+		select {
+		case sub.c <- msg:
+			delivered++
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, sub := range slow {
+		if sub.remove(true) {
+			hubEvictions.Inc()
+		}
+	}
+	return delivered
+}
+
+// Subscribers 返回 topic 的订阅者数量
+func (h *Hub) Subscribers(topic string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.topics[topic])
+}
+
+// Close 取消订阅并关闭 C
+func (s *Subscription) Close() {
+	s.remove(false)
+}
+
+// Evicted 订阅是否因消费过慢被移除
+func (s *Subscription) Evicted() bool {
+	s.hub.mu.RLock()
+	defer s.hub.mu.RUnlock()
+	return s.evicted
+}
+
+// remove 从 Hub 中移除订阅并关闭 C，已移除时返回 false
+func (s *Subscription) remove(evicted bool) bool {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.closed, s.evicted = true, evicted
+	for _, topic := range s.topics {
+		if subs, ok := h.topics[topic]; ok {
+			delete(subs, s)
+			if len(subs) == 0 {
+				delete(h.topics, topic)
+			}
+		}
+	}
+	close(s.c)
+	return true
+}
+
+// SubscribeSSE 以 SSE 推送 topics 的消息（见 c.SSE），客户端断开、服务 Shutdown 或订阅因消费过慢被移除时返回
+// 消息的 Event 为空时使用 topic 作为事件名
+func (c *Context) SubscribeSSE(topics ...string) error {
+	sub := c.ue.Hub().Subscribe(topics...)
+	defer sub.Close()
+
+	return c.SSE(func(w *SSEWriter) error {
+		for {
+			select {
+			case msg, ok := <-sub.C:
+				if !ok {
+					return nil
+				}
+				event := msg.Event
+				if event == "" {
+					event = msg.Topic
+				}
+				if err := w.Send(SSEEvent{ID: msg.ID, Event: event, Data: msg.Data}); err != nil {
+					return err
+				}
+			case <-w.Context().Done():
+				return w.Context().Err()
+			}
+		}
+	})
+}
+
+// SubscribeWebSocket 以 WebSocket 推送 topics 的消息（JSON 编码的 HubMessage，见 c.WebSocket），
+// 客户端断开、服务 Shutdown 或订阅因消费过慢被移除时返回；客户端发送的消息被忽略
+func (c *Context) SubscribeWebSocket(topics ...string) error {
+	sub := c.ue.Hub().Subscribe(topics...)
+	defer sub.Close()
+
+	return c.WebSocket(func(ws *websocket.Conn) error {
+		// 客户端断开时取消订阅
+		go func() {
+			var discard []byte
+			for websocket.Message.Receive(ws, &discard) == nil {
+			}
+			sub.Close()
+		}()
+
+		for msg := range sub.C {
+			if err := websocket.JSON.Send(ws, msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
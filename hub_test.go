@@ -0,0 +1,106 @@
+package uecho
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestHubEviction(t *testing.T) {
+	h := NewHub()
+	h.BufferSize = 2
+	fast, slow := h.Subscribe("orders"), h.Subscribe("orders", "users")
+
+	for i := 0; i < 2; i++ {
+		if n := h.Publish("orders", HubMessage{Data: i}); n != 2 {
+			t.Fatalf("delivered to %d, want 2", n)
+		}
+		<-fast.C
+	}
+	// slow 的缓冲已满，被移除
+	if n := h.Publish("orders", HubMessage{Data: 2}); n != 1 {
+		t.Fatalf("delivered to %d, want 1", n)
+	}
+	if !slow.Evicted() || fast.Evicted() || h.Subscribers("orders") != 1 || h.Subscribers("users") != 0 {
+		t.Fatalf("unexpected state: slow evicted %v, subscribers %d", slow.Evicted(), h.Subscribers("orders"))
+	}
+	var got int
+	for range slow.C {
+		got++
+	}
+	if got != 2 {
+		t.Errorf("slow subscriber drained %d messages, want 2", got)
+	}
+
+	fast.Close()
+	fast.Close()
+	if _, ok := <-fast.C; !ok || h.Subscribers("orders") != 0 {
+		t.Error("expected the buffered message before close")
+	}
+}
+
+func TestHubSubscribe(t *testing.T) {
+	ue := New(nil)
+	ue.GET("/events", HandlerFunc(func(c *Context) error {
+		return c.SubscribeSSE("orders")
+	}))
+	ue.GET("/ws", HandlerFunc(func(c *Context) error {
+		return c.SubscribeWebSocket("orders")
+	}))
+	srv := httptest.NewServer(ue)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", "", srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	for deadline := time.Now().Add(time.Second); ue.Hub().Subscribers("orders") < 2; {
+		if time.Now().After(deadline) {
+			t.Fatal("subscribers not registered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	ue.Hub().Publish("orders", HubMessage{ID: "7", Data: map[string]string{"status": "paid"}})
+
+	r := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	if got := strings.Join(lines, ""); got != "id: 7\nevent: orders\ndata: {\"status\":\"paid\"}\n" {
+		t.Errorf("sse got %q", got)
+	}
+
+	var msg HubMessage
+	if err := websocket.JSON.Receive(ws, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Topic != "orders" || msg.ID != "7" {
+		t.Errorf("ws got %+v", msg)
+	}
+
+	// 客户端断开后取消订阅
+	ws.Close()
+	for deadline := time.Now().Add(time.Second); ue.Hub().Subscribers("orders") != 1; {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d subscribers, want 1", ue.Hub().Subscribers("orders"))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
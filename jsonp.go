@@ -0,0 +1,34 @@
+package uecho
+
+import (
+	"net/http"
+	"regexp"
+)
+
+// jsonpCallbackPattern 合法的 JavaScript 回调函数名，允许 a.b.c 形式
+var jsonpCallbackPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+
+// maxJSONPCallbackLen 回调函数名的最大长度
+const maxJSONPCallbackLen = 128
+
+// validJSONPCallback 校验回调函数名，防止通过回调名注入脚本
+func validJSONPCallback(callback string) bool {
+	return len(callback) <= maxJSONPCallbackLen && jsonpCallbackPattern.MatchString(callback)
+}
+
+// jsonpCallback 返回请求中 JSONP 回调参数的值，未开启 JSONP 或未携带参数时返回空
+func (c *Context) jsonpCallback() string {
+	if c == nil || c.ue == nil || c.ue.JSONPParam == "" {
+		return ""
+	}
+	return c.QueryParam(c.ue.JSONPParam)
+}
+
+// SetPayloadJSONP 以 JSONP 形式写入响应，callback 不合法时返回参数错误
+// <script> 无法读取 http 状态码，JSONP 响应的状态码固定为 200，结果以信封中的 ec 为准
+func (c *Context) SetPayloadJSONP(callback string, payload Reply) error {
+	if !validJSONPCallback(callback) {
+		return c.Abort(ErrIllegalparams).WithField("callback", callback)
+	}
+	return c.JSONP(http.StatusOK, callback, newHttpApiResponse(c, payload))
+}
@@ -0,0 +1,64 @@
+package uecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestValidJSONPCallback(t *testing.T) {
+	for _, cb := range []string{"cb", "jQuery123_456", "$", "_cb", "window.app.onData"} {
+		if !validJSONPCallback(cb) {
+			t.Errorf("%q should be valid", cb)
+		}
+	}
+	for _, cb := range []string{"", "alert(1)//", "a-b", "1abc", "a..b", "a.", "<script>", "cb;x", strings.Repeat("a", maxJSONPCallbackLen+1)} {
+		if validJSONPCallback(cb) {
+			t.Errorf("%q should be invalid", cb)
+		}
+	}
+}
+
+func TestJSONP(t *testing.T) {
+	ue := New(nil)
+	ue.JSONPParam = "callback"
+	ue.GET("/ok", HandlerFunc(func(c *Context) error {
+		return c.SetPayload(OK.WithData("hi"))
+	}))
+	ue.GET("/fail", HandlerFunc(func(c *Context) error {
+		return c.Abort(ErrUnauthorized)
+	}))
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := get("/ok?callback=app.onData")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "app.onData(") ||
+		!strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), echo.MIMEApplicationJavaScript) {
+		t.Errorf("payload: status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	// 不合法的回调名
+	rec = get("/ok?callback=alert(1)//")
+	if rec.Code != ErrIllegalparams.HTTPCode() || strings.Contains(rec.Body.String(), "alert(") {
+		t.Errorf("invalid callback: status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	// 异常响应同样以 JSONP 信封输出，状态码固定为 200
+	rec = get("/fail?callback=cb")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Body.String(), "cb(") ||
+		!strings.Contains(rec.Body.String(), `"ec":401`) || !strings.Contains(rec.Body.String(), `"request_id"`) {
+		t.Errorf("error: status = %d, body = %s", rec.Code, rec.Body)
+	}
+
+	// 异常响应中不合法的回调名退回普通 JSON 信封
+	rec = get("/fail?callback=alert(1)//")
+	if rec.Code != http.StatusUnauthorized || strings.Contains(rec.Body.String(), "alert(") {
+		t.Errorf("error with invalid callback: status = %d, body = %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,106 @@
+package uecho
+
+import "strings"
+
+// SupportedLangs LangResolver 可解析出的语言，其他语言会被忽略
+var SupportedLangs = []string{LANG_ZH_CN, LANG_ZH_TW, LANG_EN_US}
+
+// LangResolver 从请求中解析语言，无法解析时返回空字符串
+type LangResolver func(c *Context) string
+
+// LangFromQuery 从 query 参数（例如 ?lang=en-US）解析语言
+func LangFromQuery(name string) LangResolver {
+	return func(c *Context) string {
+		return matchLang(c.QueryParam(name))
+	}
+}
+
+// LangFromCookie 从 cookie 解析语言
+func LangFromCookie(name string) LangResolver {
+	return func(c *Context) string {
+		cookie, err := c.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return matchLang(cookie.Value)
+	}
+}
+
+// LangFromHeader 从 Accept-Language 解析语言，按 q 值依次匹配 SupportedLangs
+func LangFromHeader() LangResolver {
+	return func(c *Context) string {
+		for _, spec := range parseAccept(c.GetHeader(HeaderAcceptLanguage)) {
+			if spec.q <= 0 {
+				continue
+			}
+			if lang := matchLang(spec.mediaType); lang != "" {
+				return lang
+			}
+		}
+		return ""
+	}
+}
+
+// LangFromProfile 从用户资料解析语言，profile 通常读取认证中间键写入 Context 的用户信息
+func LangFromProfile(profile func(c *Context) string) LangResolver {
+	return func(c *Context) string {
+		return matchLang(profile(c))
+	}
+}
+
+// matchLang 返回 SupportedLangs 中与 lang 匹配的语言：先完整匹配（忽略大小写），再按主语言（en、zh）匹配
+func matchLang(lang string) string {
+	lang = strings.TrimSpace(strings.Replace(lang, "_", "-", -1))
+	if lang == "" {
+		return ""
+	}
+	for _, supported := range SupportedLangs {
+		if strings.EqualFold(supported, lang) {
+			return supported
+		}
+	}
+	primary := strings.SplitN(lang, "-", 2)[0]
+	for _, supported := range SupportedLangs {
+		if strings.EqualFold(strings.SplitN(supported, "-", 2)[0], primary) {
+			return supported
+		}
+	}
+	return ""
+}
+
+// Lang 当前请求的语言：通过 SetLang 设置的语言，其次按 UEcho.LangResolvers 的顺序解析，
+// 都没有时返回 LANG_DEFAULT；解析结果保存在 Context 中
+func (c *Context) Lang() string {
+	if c.lang != "" {
+		return c.lang
+	}
+	if c.ue != nil {
+		for _, resolve := range c.ue.LangResolvers {
+			if lang := resolve(c); lang != "" {
+				c.lang = lang
+				return lang
+			}
+		}
+	}
+	c.lang = LANG_DEFAULT
+	return c.lang
+}
+
+// SetLang 设置当前请求的语言
+func (c *Context) SetLang(lang string) {
+	c.lang = lang
+}
+
+// I18n 返回 reply 在当前请求语言下的描述信息
+func (c *Context) I18n(reply Reply) string {
+	return reply.I18n(c.Lang())
+}
+
+// em 返回写出响应时使用的描述信息：开启 UEcho.LocalizeEM 时使用 ec 在当前请求语言下的描述，
+// 没有对应的描述时使用 reply 自身的 em
+func (c *Context) em(r Reply) string {
+	if c.ue == nil || !c.ue.LocalizeEM {
+		return r.EM()
+	}
+	return localize(r, c.Lang()).EM()
+}
@@ -0,0 +1,87 @@
+package uecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLangResolvers(t *testing.T) {
+	ue := New(nil)
+	ue.LangResolvers = []LangResolver{LangFromQuery("lang"), LangFromCookie("lang"), LangFromHeader()}
+	ue.GET("/lang", HandlerFunc(func(c *Context) error {
+		return c.String(http.StatusOK, c.Lang()+" "+c.I18n(ErrNotFound))
+	}))
+
+	cases := []struct {
+		query, cookie, header string
+		want                  string
+	}{
+		{"", "", "", LANG_DEFAULT + " " + ErrNotFound.I18n(LANG_DEFAULT)},
+		{"", "", "fr;q=1, en;q=0.8", LANG_EN_US + " " + ErrNotFound.I18n(LANG_EN_US)},
+		{"", "zh_tw", "en", LANG_ZH_TW + " " + ErrNotFound.I18n(LANG_ZH_TW)},
+		{"en-us", "zh-TW", "zh", LANG_EN_US + " " + ErrNotFound.I18n(LANG_EN_US)},
+		{"xx", "", "", LANG_DEFAULT + " " + ErrNotFound.I18n(LANG_DEFAULT)},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/lang?lang="+tc.query, nil)
+		if tc.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "lang", Value: tc.cookie})
+		}
+		req.Header.Set(HeaderAcceptLanguage, tc.header)
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, req)
+		if rec.Body.String() != tc.want {
+			t.Errorf("query %q cookie %q header %q: got %q, want %q", tc.query, tc.cookie, tc.header, rec.Body.String(), tc.want)
+		}
+	}
+
+	// I18n 不修改共享的 Reply
+	ErrNotFound.I18n(LANG_EN_US)
+	if ErrNotFound.I18n("") != ErrNotFound.I18n(LANG_DEFAULT) {
+		t.Fatal("I18n mutated the shared reply")
+	}
+}
+
+func TestI18nDefaultLang(t *testing.T) {
+	// 声明时未指定 lang 的 Reply，I18n("") 使用 LANG_DEFAULT
+	if ErrSignatureMismatch.I18n("") != ErrSignatureMismatch.I18n(LANG_DEFAULT) {
+		t.Errorf("I18n(\"\") = %q, want %q", ErrSignatureMismatch.I18n(""), ErrSignatureMismatch.I18n(LANG_DEFAULT))
+	}
+	if ErrSignatureMismatch.I18n(LANG_EN_US) == "" {
+		t.Error("missing en-US message")
+	}
+}
+
+func TestLocalizeEM(t *testing.T) {
+	ue := New(nil)
+	ue.LocalizeEM = true
+	ue.LangResolvers = []LangResolver{LangFromHeader()}
+	ue.GET("/ok", HandlerFunc(func(c *Context) error {
+		return c.SetPayload(OK)
+	}))
+	ue.GET("/fail", HandlerFunc(func(c *Context) error {
+		return c.Abort(ErrSignatureMismatch)
+	}))
+	ue.GET("/custom", HandlerFunc(func(c *Context) error {
+		return c.SetPayload(NewReply(http.StatusOK, 99999, "custom"))
+	}))
+
+	cases := []struct {
+		path, want string
+	}{
+		{"/ok", `"em":"` + OK.I18n(LANG_EN_US) + `"`},
+		{"/fail", `"em":"` + ErrSignatureMismatch.I18n(LANG_EN_US) + `"`},
+		{"/custom", `"em":"custom"`},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set(HeaderAcceptLanguage, "en-US")
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, req)
+		if !strings.Contains(rec.Body.String(), tc.want) {
+			t.Errorf("%s: body = %s, want %s", tc.path, rec.Body.String(), tc.want)
+		}
+	}
+}
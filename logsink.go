@@ -0,0 +1,315 @@
+package uecho
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 访问日志输出（sink）：任意 io.Writer 都可以作为 sink，logrus 每条日志调用一次 Write，
+// 既可以设置为 LoggerConfig.Sink，也可以通过 logger.SetOutput 用于 Hosts、Groups 中的 logger
+//
+//	file := &uecho.RotatingFile{Filename: "/var/log/app/access.log", MaxSize: 100 << 20, MaxBackups: 7}
+//	ue.Use(uecho.LoggerWithConfig(uecho.LoggerConfig{Sink: uecho.NewAsyncSink(file, uecho.AsyncSinkConfig{})}))
+
+// newSinkLogger 返回输出到 sink 的 logger
+func newSinkLogger(sink io.Writer, formatter logrus.Formatter) *logrus.Logger {
+	if formatter == nil {
+		formatter = &logrus.JSONFormatter{}
+	}
+	return &logrus.Logger{
+		Out:       sink,
+		Formatter: formatter,
+		Hooks:     make(logrus.LevelHooks),
+		Level:     logrus.InfoLevel,
+		ExitFunc:  os.Exit,
+	}
+}
+
+// RotatingFile 按大小及时间切割的日志文件，切割后的文件名为 Filename.20060102-150405
+type RotatingFile struct {
+	// Filename 日志文件路径
+	// Required.
+	Filename string
+
+	// MaxSize 文件超过该大小（字节）时切割，0 表示不按大小切割
+	MaxSize int64
+
+	// Interval 按时间切割的周期（例如 24h），按本地时间对齐到周期的整点，0 表示不按时间切割
+	Interval time.Duration
+
+	// MaxBackups 保留的切割文件数，0 表示全部保留
+	MaxBackups int
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	rotateAt time.Time
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	now := time.Now()
+	if (f.MaxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.MaxSize) ||
+		(f.Interval > 0 && !now.Before(f.rotateAt)) {
+		if err := f.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate 立即切割（例如收到 SIGHUP 时）
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return f.open()
+	}
+	return f.rotate(time.Now())
+}
+
+// Close 关闭日志文件
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.Filename), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	if f.Interval > 0 {
+		f.rotateAt = nextRotation(time.Now(), f.Interval)
+	}
+	return nil
+}
+
+// nextRotation 返回 now 之后按 interval 对齐（本地时间）的下一个切割时间
+func nextRotation(now time.Time, interval time.Duration) time.Time {
+	_, offset := now.Zone()
+	shift := time.Duration(offset) * time.Second
+	return now.Add(shift).Truncate(interval).Add(interval).Add(-shift)
+}
+
+func (f *RotatingFile) rotate(now time.Time) error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	backup := f.Filename + "." + now.Format("20060102-150405")
+	if _, err := os.Stat(backup); err == nil {
+		backup += "." + strconv.Itoa(now.Nanosecond())
+	}
+	if err := os.Rename(f.Filename, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	f.removeBackups()
+	return f.open()
+}
+
+// removeBackups 删除超过 MaxBackups 的旧切割文件
+func (f *RotatingFile) removeBackups() {
+	if f.MaxBackups <= 0 {
+		return
+	}
+	matches, err := filepath.Glob(f.Filename + ".*")
+	if err != nil {
+		return
+	}
+	prefix := f.Filename + "."
+	backups := matches[:0]
+	for _, m := range matches {
+		if strings.HasPrefix(m, prefix) && len(m) > len(prefix) && m[len(prefix)] >= '0' && m[len(prefix)] <= '9' {
+			backups = append(backups, m)
+		}
+	}
+	sort.Strings(backups)
+	for len(backups) > f.MaxBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+}
+
+// DropPolicy 异步 sink 缓冲写满时的处理方式
+type DropPolicy int
+
+const (
+	// DropNewest 丢弃新写入的日志
+	DropNewest DropPolicy = iota
+	// DropOldest 丢弃缓冲中最早的日志
+	DropOldest
+	// Block 阻塞等待（请求 goroutine 会被慢速输出拖慢）
+	Block
+)
+
+var accessLogDropped = DefaultMetrics.Counter("access_log_dropped")
+
+type AsyncSinkConfig struct {
+	// BufferSize 缓冲的日志条数
+	// Optional. Default value 4096.
+	BufferSize int
+
+	// Policy 缓冲写满时的处理方式
+	// Optional. Default value DropNewest.
+	Policy DropPolicy
+}
+
+// ErrSinkClosed sink 已关闭
+var ErrSinkClosed = errors.New("uecho: log sink closed")
+
+// AsyncSink 异步 sink：Write 只将日志放入缓冲，由后台 goroutine 写入下游，
+// 避免慢速输出（网络、磁盘）阻塞请求；丢弃的日志数见 Dropped 及 access_log_dropped 指标
+type AsyncSink struct {
+	w      io.Writer
+	policy DropPolicy
+	ch     chan []byte
+	done   chan struct{}
+
+	mu      sync.RWMutex
+	closed  bool
+	dropped Counter
+}
+
+// NewAsyncSink 创建异步 sink，不再使用时应调用 Close 写出缓冲中的日志
+func NewAsyncSink(w io.Writer, conf AsyncSinkConfig) *AsyncSink {
+	if conf.BufferSize <= 0 {
+		conf.BufferSize = 4096
+	}
+	s := &AsyncSink{
+		w:      w,
+		policy: conf.Policy,
+		ch:     make(chan []byte, conf.BufferSize),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *AsyncSink) run() {
+	defer close(s.done)
+	for p := range s.ch {
+		s.w.Write(p)
+	}
+}
+
+func (s *AsyncSink) Write(p []byte) (int, error) {
+	b := make([]byte, len(p))
+	copy(b, p)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return 0, ErrSinkClosed
+	}
+	switch s.policy {
+	case Block:
+		s.ch <- b
+		return len(p), nil
+	case DropOldest:
+		for {
+			select {
+			case s.ch <- b:
+				return len(p), nil
+			default:
+			}
+			select {
+			case <-s.ch:
+				s.drop()
+			default:
+			}
+		}
+	default:
+		select {
+		case s.ch <- b:
+		default:
+			s.drop()
+		}
+		return len(p), nil
+	}
+}
+
+func (s *AsyncSink) drop() {
+	s.dropped.Inc()
+	accessLogDropped.Inc()
+}
+
+// Dropped 丢弃的日志条数
+func (s *AsyncSink) Dropped() int64 {
+	return s.dropped.Value()
+}
+
+// Close 停止接收日志，等待缓冲中的日志写入下游后关闭下游（实现了 io.Closer 时）
+func (s *AsyncSink) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	close(s.ch)
+	s.mu.Unlock()
+
+	<-s.done
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// KafkaProducer Kafka 生产者，由使用方适配具体的客户端（sarama、kafka-go 等）
+type KafkaProducer interface {
+	Produce(topic string, key, value []byte) error
+}
+
+// KafkaSink 将每条日志作为一条消息发送到 topic，通常与 AsyncSink 组合使用
+func KafkaSink(producer KafkaProducer, topic string) io.Writer {
+	return kafkaSink{producer: producer, topic: topic}
+}
+
+type kafkaSink struct {
+	producer KafkaProducer
+	topic    string
+}
+
+func (s kafkaSink) Write(p []byte) (int, error) {
+	msg := make([]byte, len(p))
+	copy(msg, p)
+	if err := s.producer.Produce(s.topic, nil, msg); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
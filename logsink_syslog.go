@@ -0,0 +1,16 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package uecho
+
+import (
+	"io"
+	"log/syslog"
+)
+
+// SyslogSink 输出到 syslog 的 sink，network、raddr 为空时连接本机 syslog
+//
+//	sink, err := uecho.SyslogSink("udp", "syslog.internal:514", syslog.LOG_INFO|syslog.LOG_LOCAL0, "api")
+func SyslogSink(network, raddr string, priority syslog.Priority, tag string) (io.WriteCloser, error) {
+	return syslog.Dial(network, raddr, priority, tag)
+}
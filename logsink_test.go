@@ -0,0 +1,110 @@
+package uecho
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "logs", "access.log")
+	f := &RotatingFile{Filename: name, MaxSize: 10, MaxBackups: 2}
+	defer f.Close()
+	for i := 0; i < 5; i++ {
+		if _, err := f.Write([]byte("0123456789")); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	backups, _ := filepath.Glob(name + ".*")
+	if len(backups) != 2 {
+		t.Errorf("got %d backups, want 2: %v", len(backups), backups)
+	}
+	if b, _ := ioutil.ReadFile(name); string(b) != "0123456789" {
+		t.Errorf("current file %q", b)
+	}
+}
+
+func TestNextRotation(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, loc)
+	if got := nextRotation(now, 24*time.Hour); !got.Equal(time.Date(2024, 1, 3, 0, 0, 0, 0, loc)) {
+		t.Errorf("daily: %v", got)
+	}
+	if got := nextRotation(now, time.Hour); !got.Equal(time.Date(2024, 1, 2, 16, 0, 0, 0, loc)) {
+		t.Errorf("hourly: %v", got)
+	}
+}
+
+// blockingWriter 在 release 关闭前阻塞写入
+type blockingWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	buf     bytes.Buffer
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestAsyncSink(t *testing.T) {
+	for _, tc := range []struct {
+		policy DropPolicy
+		want   string
+	}{
+		{DropNewest, "abc"},
+		{DropOldest, "ade"},
+	} {
+		w := &blockingWriter{release: make(chan struct{})}
+		s := NewAsyncSink(w, AsyncSinkConfig{BufferSize: 2, Policy: tc.policy})
+		s.Write([]byte("a"))
+		// 等待后台 goroutine 取出第一条并阻塞在下游
+		for len(s.ch) != 0 {
+			time.Sleep(time.Millisecond)
+		}
+		for _, p := range []string{"b", "c", "d", "e"} {
+			s.Write([]byte(p))
+		}
+		close(w.release)
+		s.Close()
+		if got := w.buf.String(); got != tc.want || s.Dropped() != 2 {
+			t.Errorf("policy %d: got %q, dropped %d", tc.policy, got, s.Dropped())
+		}
+		if _, err := s.Write([]byte("f")); err != ErrSinkClosed {
+			t.Errorf("write after close: %v", err)
+		}
+	}
+}
+
+type fakeProducer struct {
+	topic string
+	msgs  []string
+}
+
+func (p *fakeProducer) Produce(topic string, key, value []byte) error {
+	p.topic = topic
+	p.msgs = append(p.msgs, string(value))
+	return nil
+}
+
+func TestLoggerSink(t *testing.T) {
+	producer := new(fakeProducer)
+	ue := New(nil)
+	ue.Use(LoggerWithConfig(LoggerConfig{Sink: KafkaSink(producer, "access-log")}))
+	ue.GET("/", HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}))
+	ue.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if producer.topic != "access-log" || len(producer.msgs) != 1 || !strings.Contains(producer.msgs[0], `"status":204`) {
+		t.Errorf("got %q %q", producer.topic, producer.msgs)
+	}
+}
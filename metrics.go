@@ -0,0 +1,259 @@
+package uecho
+
+import (
+	"expvar"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultMetrics 默认的指标注册表，通过 expvar 以 "uecho" 为名导出（/debug/vars）
+var DefaultMetrics = NewMetrics()
+
+func init() {
+	expvar.Publish("uecho", expvar.Func(func() interface{} {
+		return DefaultMetrics.Snapshot()
+	}))
+}
+
+// DefaultBuckets 直方图默认的桶上界（秒），与 prometheus 的默认值相同
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Counter 计数器
+type Counter struct {
+	v int64
+}
+
+// Inc 加 1
+func (c *Counter) Inc() {
+	atomic.AddInt64(&c.v, 1)
+}
+
+// Add 加 n
+func (c *Counter) Add(n int64) {
+	atomic.AddInt64(&c.v, n)
+}
+
+// Value 当前值
+func (c *Counter) Value() int64 {
+	return atomic.LoadInt64(&c.v)
+}
+
+// Histogram 直方图，记录观测值的分布
+type Histogram struct {
+	buckets []float64
+	counts  []uint64 // counts[i] 为落入 buckets[i] 的次数，最后一个为 +Inf
+	count   uint64
+	sum     uint64 // float64 bits
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	return &Histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)+1),
+	}
+}
+
+// Observe 记录一个观测值
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+	atomic.AddUint64(&h.counts[i], 1)
+	atomic.AddUint64(&h.count, 1)
+	for {
+		old := atomic.LoadUint64(&h.sum)
+		if atomic.CompareAndSwapUint64(&h.sum, old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+// Count 观测次数
+func (h *Histogram) Count() uint64 {
+	return atomic.LoadUint64(&h.count)
+}
+
+// Sum 观测值之和
+func (h *Histogram) Sum() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&h.sum))
+}
+
+// snapshot 返回 count、sum 及累计的各桶计数（le => count）
+func (h *Histogram) snapshot() map[string]interface{} {
+	buckets := make(map[string]uint64, len(h.counts))
+	var cumulative uint64
+	for i, upper := range h.buckets {
+		cumulative += atomic.LoadUint64(&h.counts[i])
+		buckets[strconv.FormatFloat(upper, 'g', -1, 64)] = cumulative
+	}
+	cumulative += atomic.LoadUint64(&h.counts[len(h.buckets)])
+	buckets["+Inf"] = cumulative
+	return map[string]interface{}{
+		"count":   h.Count(),
+		"sum":     h.Sum(),
+		"buckets": buckets,
+	}
+}
+
+// Metrics 指标注册表
+// 带标签的指标以 name{k1="v1",k2="v2"} 为名单独计数，标签按 key 排序
+type Metrics struct {
+	mu         sync.RWMutex
+	counters   map[string]*Counter
+	histograms map[string]*Histogram
+
+	// Buckets 新建直方图使用的桶上界，需升序
+	// Optional. Default value DefaultBuckets.
+	Buckets []float64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		counters:   make(map[string]*Counter),
+		histograms: make(map[string]*Histogram),
+	}
+}
+
+// Counter 返回名为 name 的计数器，不存在时创建
+// labels 为 key、value 交替的标签，例如 m.Counter("payment_declines", "route", "/pay")
+func (m *Metrics) Counter(name string, labels ...string) *Counter {
+	key := seriesName(name, labels)
+	m.mu.RLock()
+	c, ok := m.counters[key]
+	m.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok = m.counters[key]; !ok {
+		c = new(Counter)
+		m.counters[key] = c
+	}
+	return c
+}
+
+// Histogram 返回名为 name 的直方图，不存在时创建，labels 同 Counter
+func (m *Metrics) Histogram(name string, labels ...string) *Histogram {
+	key := seriesName(name, labels)
+	m.mu.RLock()
+	h, ok := m.histograms[key]
+	m.mu.RUnlock()
+	if ok {
+		return h
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if h, ok = m.histograms[key]; !ok {
+		buckets := m.Buckets
+		if len(buckets) == 0 {
+			buckets = DefaultBuckets
+		}
+		h = newHistogram(buckets)
+		m.histograms[key] = h
+	}
+	return h
+}
+
+// Snapshot 返回全部指标当前值
+func (m *Metrics) Snapshot() map[string]interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	snapshot := make(map[string]interface{}, len(m.counters)+len(m.histograms))
+	for name, c := range m.counters {
+		snapshot[name] = c.Value()
+	}
+	for name, h := range m.histograms {
+		snapshot[name] = h.snapshot()
+	}
+	return snapshot
+}
+
+// seriesName 返回带标签指标的名称：name{k1="v1",k2="v2"}
+func seriesName(name string, labels []string) string {
+	if len(labels) < 2 {
+		return name
+	}
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, labels[i]+"="+`"`+labels[i+1]+`"`)
+	}
+	sort.Strings(pairs)
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Metric 带有当前请求 route、tenant 标签的指标
+type Metric struct {
+	metrics *Metrics
+	name    string
+	labels  []string
+}
+
+// Metric 返回名为 name 的指标，自动带上 route（注册的路由路径，未匹配到路由时为 RouteUnmatched）
+// 及 tenant（c.SetTenant 设置的租户，未设置时省略）标签；
+// 不使用客户端携带的 X-Tenant-ID，避免客户端制造无限多的序列
+//
+//	c.Metric("cache_hits").Inc()
+//	c.Metric("payment_amount").With("channel", "alipay").Observe(amount)
+func (c *Context) Metric(name string) *Metric {
+	m := c.ue.Metrics
+	if m == nil {
+		m = DefaultMetrics
+	}
+	labels := []string{"route", c.routeLabel()}
+	if tenant, _ := c.Get(tenantKey).(string); tenant != "" {
+		labels = append(labels, "tenant", tenant)
+	}
+	return &Metric{metrics: m, name: name, labels: labels}
+}
+
+// With 追加标签
+func (m *Metric) With(key, value string) *Metric {
+	labels := make([]string, len(m.labels), len(m.labels)+2)
+	copy(labels, m.labels)
+	return &Metric{metrics: m.metrics, name: m.name, labels: append(labels, key, value)}
+}
+
+// Inc 计数器加 1
+func (m *Metric) Inc() {
+	m.metrics.Counter(m.name, m.labels...).Inc()
+}
+
+// Add 计数器加 n
+func (m *Metric) Add(n int64) {
+	m.metrics.Counter(m.name, m.labels...).Add(n)
+}
+
+// Observe 直方图记录一个观测值
+func (m *Metric) Observe(v float64) {
+	m.metrics.Histogram(m.name, m.labels...).Observe(v)
+}
+
+// MetricResponses 按 ec 及路由统计的响应数，SetPayload 及 DefaultHTTPErrorHandler 输出响应时计数：
+// responses{ec="10302",route="/wechat"}
+const MetricResponses = "responses"
+
+// RouteUnmatched 未匹配到路由的请求的 route 标签
+const RouteUnmatched = "unmatched"
+
+// routeLabel 指标的 route 标签：注册的路由路径，未匹配到路由时为 RouteUnmatched
+// 不使用 c.Path()：未匹配到路由时为请求的原始路径，扫描请求会产生无限多的序列
+func (c *Context) routeLabel() string {
+	if route := c.Route(); route != nil {
+		return route.Path
+	}
+	return RouteUnmatched
+}
+
+// countReply 按 ec 及路由（见 routeLabel）统计响应数
+func (c *Context) countReply(ec int) {
+	m := c.ue.Metrics
+	if m == nil {
+		m = DefaultMetrics
+	}
+	m.Counter(MetricResponses, "ec", strconv.Itoa(ec), "route", c.routeLabel()).Inc()
+}
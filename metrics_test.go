@@ -0,0 +1,95 @@
+package uecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextMetric(t *testing.T) {
+	ue := New(nil)
+	ue.Metrics = NewMetrics()
+	ue.GET("/orders/:id", HandlerFunc(func(c *Context) error {
+		if c.QueryParam("auth") != "" {
+			c.SetTenant("acme")
+		}
+		c.Metric("cache_hits").Inc()
+		c.Metric("latency").With("backend", "db").Observe(0.02)
+		return c.NoContent(http.StatusNoContent)
+	}))
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/orders/1?auth=1", nil)
+		ue.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	// 客户端携带的 X-Tenant-ID 不作为标签
+	req := httptest.NewRequest(http.MethodGet, "/orders/2", nil)
+	req.Header.Set(HeaderXTenantID, "spoofed")
+	ue.ServeHTTP(httptest.NewRecorder(), req)
+	if v := ue.Metrics.Counter("cache_hits", "route", "/orders/:id").Value(); v != 1 {
+		t.Fatalf("cache_hits without tenant = %d", v)
+	}
+
+	if v := ue.Metrics.Counter("cache_hits", "tenant", "acme", "route", "/orders/:id").Value(); v != 2 {
+		t.Fatalf("cache_hits = %d", v)
+	}
+	h := ue.Metrics.Histogram("latency", "route", "/orders/:id", "tenant", "acme", "backend", "db")
+	if h.Count() != 2 || h.Sum() != 0.04 {
+		t.Fatalf("latency count = %d, sum = %v", h.Count(), h.Sum())
+	}
+	snapshot := ue.Metrics.Snapshot()[`latency{backend="db",route="/orders/:id",tenant="acme"}`].(map[string]interface{})
+	if buckets := snapshot["buckets"].(map[string]uint64); buckets["0.01"] != 0 || buckets["0.025"] != 2 || buckets["+Inf"] != 2 {
+		t.Fatalf("buckets = %v", buckets)
+	}
+}
+
+func TestResponseMetrics(t *testing.T) {
+	ue := New(nil)
+	ue.Metrics = NewMetrics()
+	ue.POST("/wechat", HandlerFunc(func(c *Context) error {
+		if c.QueryParam("ok") != "" {
+			return c.SetPayload(OK)
+		}
+		return c.Abort(ErrDecryptFailed)
+	}))
+
+	for _, q := range []string{"", "", "?ok=1"} {
+		ue.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/wechat"+q, nil))
+	}
+	if v := ue.Metrics.Counter(MetricResponses, "ec", "10302", "route", "/wechat").Value(); v != 2 {
+		t.Errorf("ec 10302 = %d", v)
+	}
+	if v := ue.Metrics.Snapshot()[`responses{ec="200",route="/wechat"}`]; v != int64(1) {
+		t.Errorf("ec 200 = %v", v)
+	}
+
+	// 未匹配到路由的请求共用一个序列
+	for _, path := range []string{"/scan1", "/scan2/x", "/wp-admin.php"} {
+		ue.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if v := ue.Metrics.Counter(MetricResponses, "ec", "404", "route", RouteUnmatched).Value(); v != 3 {
+		t.Errorf("unmatched = %d, snapshot = %v", v, ue.Metrics.Snapshot())
+	}
+}
+
+func TestPoolMetrics(t *testing.T) {
+	ue := New(nil)
+	ue.GET("/fail", HandlerFunc(func(c *Context) error {
+		return c.Abort(ErrIllegalparams)
+	}))
+
+	gets, puts := contextPoolGets.Value(), contextPoolPuts.Value()
+	erGets, erPuts := errReplyPoolGets.Value(), errReplyPoolPuts.Value()
+	finds := routerFinds.Value()
+	ue.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	if contextPoolGets.Value()-gets != 1 || contextPoolPuts.Value()-puts != 1 {
+		t.Fatalf("context pool gets = %d, puts = %d", contextPoolGets.Value()-gets, contextPoolPuts.Value()-puts)
+	}
+	if errReplyPoolGets.Value()-erGets != 1 || errReplyPoolPuts.Value()-erPuts != 1 {
+		t.Fatalf("errReply pool gets = %d, puts = %d", errReplyPoolGets.Value()-erGets, errReplyPoolPuts.Value()-erPuts)
+	}
+	if routerFinds.Value()-finds != 1 {
+		t.Fatalf("router finds = %d", routerFinds.Value()-finds)
+	}
+}
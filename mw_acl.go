@@ -0,0 +1,428 @@
+package uecho
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ACLAllow = "allow"
+	ACLDeny  = "deny"
+)
+
+// ACLRule 访问控制规则，为空的条件匹配全部请求，全部条件都匹配时规则生效
+type ACLRule struct {
+	// Name 规则名称，拒绝时记录在审计日志及异常字段中
+	Name string `json:"name,omitempty"`
+
+	// Action allow 或 deny
+	Action string `json:"action"`
+
+	// Paths 请求路径，`/admin/*` 匹配 /admin 及其下的全部路径，其余按 path.Match 匹配
+	Paths []string `json:"paths,omitempty"`
+
+	// Methods 请求方法
+	Methods []string `json:"methods,omitempty"`
+
+	// Roles 主体拥有其中任一角色时匹配
+	Roles []string `json:"roles,omitempty"`
+
+	// IPs 客户端 ip 或网段（CIDR）
+	IPs []string `json:"ips,omitempty"`
+}
+
+// ACLRules 访问控制规则集，规则按顺序匹配，第一条匹配的规则生效，没有匹配的规则时执行 Default
+//
+//	{"default": "allow", "rules": [{"name": "lock-admin", "action": "deny", "paths": ["/admin/*"]}]}
+type ACLRules struct {
+	// Default 没有匹配的规则时的动作
+	// Optional. Default value allow.
+	Default string    `json:"default,omitempty"`
+	Rules   []ACLRule `json:"rules"`
+}
+
+// ACLSource 规则来源
+type ACLSource interface {
+	Load(ctx context.Context) ([]byte, error)
+}
+
+// ACLSourceFunc ACLSource 的函数形式
+type ACLSourceFunc func(ctx context.Context) ([]byte, error)
+
+func (f ACLSourceFunc) Load(ctx context.Context) ([]byte, error) {
+	return f(ctx)
+}
+
+// ACLFile 从 JSON 文件加载规则
+func ACLFile(name string) ACLSource {
+	return ACLSourceFunc(func(context.Context) ([]byte, error) {
+		return ioutil.ReadFile(name)
+	})
+}
+
+// aclClient ACLURL 使用的 http.Client，ACLConfig.Timeout 之外再限制单次请求的时间
+var aclClient = &http.Client{Timeout: 30 * time.Second}
+
+// ACLURL 从远程地址（GET，响应 200）加载规则
+func ACLURL(url string) ACLSource {
+	return ACLSourceFunc(func(ctx context.Context) ([]byte, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := aclClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("uecho: load acl from %s: %s", url, resp.Status)
+		}
+		return ioutil.ReadAll(resp.Body)
+	})
+}
+
+type aclRule struct {
+	ACLRule
+	allow   bool
+	methods map[string]struct{}
+	nets    []*net.IPNet
+}
+
+type aclRuleSet struct {
+	rules []aclRule
+	allow bool
+	raw   []byte
+}
+
+// compileACL 解析并校验规则
+func compileACL(b []byte) (*aclRuleSet, error) {
+	var rules ACLRules
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, err
+	}
+	allow, err := aclAction(rules.Default, true)
+	if err != nil {
+		return nil, err
+	}
+	set := &aclRuleSet{allow: allow, raw: b}
+	for i, r := range rules.Rules {
+		cr := aclRule{ACLRule: r}
+		if cr.allow, err = aclAction(r.Action, false); err != nil {
+			return nil, fmt.Errorf("uecho: acl rule %d: %v", i, err)
+		}
+		if len(r.Methods) > 0 {
+			cr.methods = make(map[string]struct{}, len(r.Methods))
+			for _, m := range r.Methods {
+				cr.methods[strings.ToUpper(m)] = struct{}{}
+			}
+		}
+		for _, p := range r.Paths {
+			if _, err := path.Match(p, "/"); err != nil {
+				return nil, fmt.Errorf("uecho: acl rule %d: path %q: %v", i, p, err)
+			}
+		}
+		for _, s := range r.IPs {
+			if !strings.Contains(s, "/") {
+				if ip := net.ParseIP(s); ip != nil && ip.To4() != nil {
+					s += "/32"
+				} else {
+					s += "/128"
+				}
+			}
+			_, ipnet, err := net.ParseCIDR(s)
+			if err != nil {
+				return nil, fmt.Errorf("uecho: acl rule %d: %v", i, err)
+			}
+			cr.nets = append(cr.nets, ipnet)
+		}
+		set.rules = append(set.rules, cr)
+	}
+	return set, nil
+}
+
+func aclAction(action string, emptyAllow bool) (bool, error) {
+	switch strings.ToLower(action) {
+	case ACLAllow:
+		return true, nil
+	case ACLDeny:
+		return false, nil
+	case "":
+		if emptyAllow {
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("invalid action %q", action)
+}
+
+func (r *aclRule) match(method, reqPath string, ip net.IP, roles []string) bool {
+	if r.methods != nil {
+		if _, ok := r.methods[method]; !ok {
+			return false
+		}
+	}
+	if len(r.Paths) > 0 && !aclPathMatch(r.Paths, reqPath) {
+		return false
+	}
+	if len(r.nets) > 0 {
+		matched := false
+		for _, n := range r.nets {
+			if ip != nil && n.Contains(ip) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(r.Roles) > 0 {
+		for _, want := range r.Roles {
+			for _, role := range roles {
+				if role == want {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func aclPathMatch(patterns []string, reqPath string) bool {
+	for _, p := range patterns {
+		if strings.HasSuffix(p, "/*") {
+			prefix := p[:len(p)-1]
+			if reqPath == prefix[:len(prefix)-1] || strings.HasPrefix(reqPath, prefix) {
+				return true
+			}
+			continue
+		}
+		if ok, _ := path.Match(p, reqPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+type ACLConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// Source 规则来源（ACLFile、ACLURL）
+	// Required.
+	Source ACLSource
+
+	// Interval 检查规则是否变化的间隔，小于 0 时不检查
+	// Optional. Default value 30s.
+	Interval time.Duration
+
+	// ReloadOnSIGHUP 为 true 时收到 SIGHUP 重新加载规则（会监听进程的 SIGHUP 信号）
+	ReloadOnSIGHUP bool
+
+	// Timeout 单次加载规则的时限，避免规则来源无响应时阻塞重新加载
+	// Optional. Default value 10s.
+	Timeout time.Duration
+
+	// Logger 输出重新加载结果的 logger
+	// Optional. Default value logrus.StandardLogger().
+	Logger *logrus.Logger
+
+	// Roles 返回当前请求主体的角色
+	// Optional. Default value reads []string from c.Get("roles").
+	Roles func(c *Context) []string
+
+	// IPExtractor 提取客户端 ip，位于反向代理之后时应设置 e.IPExtractor 或在此指定可信的提取方式，
+	// 不应直接信任客户端可以伪造的 X-Forwarded-For、X-Real-IP
+	// Optional. Default value c.TrustedIP().
+	IPExtractor func(c *Context) string
+}
+
+// ACL 可热更新的访问控制规则：定时检查规则来源（及开启 ReloadOnSIGHUP 时收到 SIGHUP）时重新加载，
+// 新规则解析失败时继续使用原有规则
+type ACL struct {
+	conf  ACLConfig
+	rules atomic.Value // *aclRuleSet
+
+	mu      sync.Mutex
+	closeCh chan struct{}
+	done    chan struct{}
+	closed  bool
+}
+
+// NewACL 加载规则并开始监听变化，规则加载失败时返回错误；不再使用时应调用 Close
+func NewACL(conf ACLConfig) (*ACL, error) {
+	if conf.Source == nil {
+		panic("uecho: acl requires a source")
+	}
+	if conf.Interval == 0 {
+		conf.Interval = 30 * time.Second
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = 10 * time.Second
+	}
+	if conf.Logger == nil {
+		conf.Logger = logrus.StandardLogger()
+	}
+	if conf.Roles == nil {
+		conf.Roles = func(c *Context) []string {
+			roles, _ := c.Get("roles").([]string)
+			return roles
+		}
+	}
+	if conf.IPExtractor == nil {
+		conf.IPExtractor = func(c *Context) string { return c.TrustedIP() }
+	}
+
+	a := &ACL{conf: conf, closeCh: make(chan struct{}), done: make(chan struct{})}
+	if _, err := a.Reload(); err != nil {
+		return nil, err
+	}
+	go a.watch()
+	return a, nil
+}
+
+// Reload 重新加载规则，规则发生变化时返回 true；失败时继续使用原有规则
+func (a *ACL) Reload() (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.conf.Timeout)
+	defer cancel()
+	b, err := a.conf.Source.Load(ctx)
+	if err != nil {
+		return false, err
+	}
+	if old, ok := a.rules.Load().(*aclRuleSet); ok && bytes.Equal(old.raw, b) {
+		return false, nil
+	}
+	set, err := compileACL(b)
+	if err != nil {
+		return false, err
+	}
+	a.rules.Store(set)
+	return true, nil
+}
+
+// Rules 返回当前生效的规则
+func (a *ACL) Rules() ACLRules {
+	set := a.rules.Load().(*aclRuleSet)
+	rules := ACLRules{Default: ACLDeny, Rules: make([]ACLRule, 0, len(set.rules))}
+	if set.allow {
+		rules.Default = ACLAllow
+	}
+	for _, r := range set.rules {
+		rules.Rules = append(rules.Rules, r.ACLRule)
+	}
+	return rules
+}
+
+// Close 停止监听规则变化，等待进行中的重新加载结束
+func (a *ACL) Close() {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.closeCh)
+	}
+	a.mu.Unlock()
+	<-a.done
+}
+
+func (a *ACL) watch() {
+	defer close(a.done)
+	var tick <-chan time.Time
+	if a.conf.Interval > 0 {
+		ticker := time.NewTicker(a.conf.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	var hup chan os.Signal
+	if a.conf.ReloadOnSIGHUP {
+		hup = make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+	}
+
+	for {
+		select {
+		case <-tick:
+		case <-hup:
+		case <-a.closeCh:
+			return
+		}
+		changed, err := a.Reload()
+		if err != nil {
+			a.conf.Logger.WithError(err).Error("acl reload failed, keeping previous rules")
+		} else if changed {
+			a.conf.Logger.WithField("rules", len(a.rules.Load().(*aclRuleSet).rules)).Info("acl rules reloaded")
+		}
+	}
+}
+
+// Middleware 按规则放行或拒绝请求，拒绝时返回 403 并记录审计日志
+// 以 e.Use 注册时在分组、路由的中间键之前执行（应注册在写入角色的认证中间键之后）；
+// 规则不依赖角色时也可以 e.Pre 注册，在路由匹配之前拒绝
+func (a *ACL) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) error {
+			if a.conf.Skipper != nil && a.conf.Skipper(c) {
+				return next(c)
+			}
+
+			set := a.rules.Load().(*aclRuleSet)
+			method, reqPath := c.Request().Method, c.Request().URL.Path
+			ip := net.ParseIP(a.conf.IPExtractor(c))
+			var roles []string
+			if len(set.rules) > 0 {
+				roles = a.conf.Roles(c)
+			}
+
+			allow, name := set.allow, ""
+			for i := range set.rules {
+				r := &set.rules[i]
+				if r.match(method, reqPath, ip, roles) {
+					allow, name = r.allow, r.Name
+					break
+				}
+			}
+			if !allow {
+				// 审计日志
+				c.Logrus().WithFields(logrus.Fields{
+					"audit":      "acl",
+					"rule":       name,
+					"method":     method,
+					"path":       reqPath,
+					"ip":         ip.String(),
+					"roles":      roles,
+					"request_id": c.RequestID(),
+				}).Warn("access denied by acl")
+				return c.Abort(ErrForbidden).WithField("acl", name)
+			}
+			return next(c)
+		}
+		return WrapHandler(HandlerFunc(f))
+	}
+}
+
+// ACLMiddleware 以 conf 创建 ACL 并返回其中间键，规则加载失败时 panic
+func ACLMiddleware(conf ACLConfig) echo.MiddlewareFunc {
+	a, err := NewACL(conf)
+	if err != nil {
+		panic(err)
+	}
+	return a.Middleware()
+}
@@ -0,0 +1,170 @@
+package uecho
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+func TestACL(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "acl.json")
+	write := func(s string) {
+		if err := ioutil.WriteFile(name, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"rules": [
+		{"name": "office", "action": "allow", "paths": ["/admin/*"], "ips": ["10.0.0.0/8"]},
+		{"name": "ops", "action": "allow", "paths": ["/admin/*"], "roles": ["ops"]},
+		{"name": "lock-admin", "action": "deny", "paths": ["/admin/*"]},
+		{"name": "readonly", "action": "deny", "methods": ["delete"]}
+	]}`)
+
+	acl, err := NewACL(ACLConfig{Source: ACLFile(name), Interval: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acl.Close()
+
+	ue := New(nil)
+	ue.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return WrapHandler(HandlerFunc(func(c *Context) error {
+			if role := c.GetHeader("X-Role"); role != "" {
+				c.Set("roles", []string{role})
+			}
+			return next(c)
+		}))
+	}, acl.Middleware())
+	ok := HandlerFunc(func(c *Context) error { return c.NoContent(http.StatusNoContent) })
+	ue.GET("/admin", ok)
+	ue.GET("/admin/users", ok)
+	ue.DELETE("/orders", ok)
+	ue.GET("/orders", ok)
+
+	cases := []struct {
+		method, path, ip, role string
+		want                   int
+	}{
+		{http.MethodGet, "/admin", "", "", http.StatusForbidden},
+		{http.MethodGet, "/admin/users", "10.1.2.3", "", http.StatusNoContent},
+		{http.MethodGet, "/admin/users", "", "ops", http.StatusNoContent},
+		{http.MethodDelete, "/orders", "", "", http.StatusForbidden},
+		{http.MethodGet, "/orders", "", "", http.StatusNoContent},
+	}
+	serve := func(method, path, ip, role string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if ip != "" {
+			req.RemoteAddr = ip + ":1234"
+		}
+		if role != "" {
+			req.Header.Set("X-Role", role)
+		}
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	for _, tc := range cases {
+		if got := serve(tc.method, tc.path, tc.ip, tc.role); got != tc.want {
+			t.Errorf("%s %s ip=%q role=%q: got %d, want %d", tc.method, tc.path, tc.ip, tc.role, got, tc.want)
+		}
+	}
+
+	// 默认不信任客户端携带的 X-Forwarded-For、X-Real-IP
+	req := httptest.NewRequest(http.MethodGet, "/admin/users", nil)
+	req.Header.Set(echo.HeaderXForwardedFor, "10.1.2.3")
+	req.Header.Set(echo.HeaderXRealIP, "10.1.2.3")
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("spoofed X-Forwarded-For: got %d", rec.Code)
+	}
+
+	// 新规则解析失败时继续使用原有规则
+	write(`{"rules": [{"action": "maybe"}]}`)
+	if _, err := acl.Reload(); err == nil {
+		t.Error("expected error for invalid rules")
+	}
+	if got := serve(http.MethodDelete, "/orders", "", ""); got != http.StatusForbidden {
+		t.Errorf("after failed reload: got %d", got)
+	}
+
+	write(`{"default": "deny", "rules": [{"action": "allow", "methods": ["GET"]}]}`)
+	if changed, err := acl.Reload(); err != nil || !changed {
+		t.Fatalf("reload: %v %v", changed, err)
+	}
+	if got := serve(http.MethodDelete, "/orders", "", ""); got != http.StatusForbidden {
+		t.Errorf("after reload: got %d", got)
+	}
+	if got := serve(http.MethodGet, "/admin", "", ""); got != http.StatusNoContent {
+		t.Errorf("after reload: got %d", got)
+	}
+}
+
+func TestACLWatch(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "acl.json")
+	ioutil.WriteFile(name, []byte(`{"rules": []}`), 0644)
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	acl, err := NewACL(ACLConfig{Source: ACLFile(name), Interval: 10 * time.Millisecond, Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acl.Close()
+
+	ioutil.WriteFile(name, []byte(`{"default": "deny", "rules": []}`), 0644)
+	deadline := time.Now().Add(time.Second)
+	for acl.Rules().Default != ACLDeny {
+		if time.Now().After(deadline) {
+			t.Fatal("rules were not reloaded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	os.Remove(name)
+	time.Sleep(30 * time.Millisecond)
+	if acl.Rules().Default != ACLDeny {
+		t.Error("rules changed after the source became unavailable")
+	}
+}
+
+func TestACLReloadTimeout(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	var calls int32
+	source := ACLSourceFunc(func(ctx context.Context) ([]byte, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			return []byte(`{"rules": []}`), nil
+		}
+		<-ctx.Done() // 规则来源无响应
+		return nil, ctx.Err()
+	})
+	acl, err := NewACL(ACLConfig{Source: source, Interval: 10 * time.Millisecond, Timeout: 20 * time.Millisecond, Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer acl.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&calls) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatal("watch blocked on a hung source")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	acl.Close()
+	if !strings.Contains(buf.String(), "acl reload failed") {
+		t.Errorf("log = %q", buf.String())
+	}
+}
@@ -0,0 +1,165 @@
+package uecho
+
+import (
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+// MetaPerm 路由所需权限的元数据 key，例如 e.POST(...).Meta(MetaPerm, "orders:write")
+const MetaPerm = "perm"
+
+// Policy 授权策略
+type Policy interface {
+	// Allow 判断拥有 roles 的主体是否具有 perm 权限
+	Allow(c *Context, roles []string, perm string) (bool, error)
+}
+
+var _ Policy = StaticPolicy(nil)
+
+// StaticPolicy 静态配置的策略：角色 => 权限列表
+// 权限支持通配：`orders:*` 匹配 orders 下的全部权限，`*` 匹配全部权限
+type StaticPolicy map[string][]string
+
+func (p StaticPolicy) Allow(_ *Context, roles []string, perm string) (bool, error) {
+	for _, role := range roles {
+		for _, granted := range p[role] {
+			if permMatch(granted, perm) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func permMatch(granted, perm string) bool {
+	if granted == "*" || granted == perm {
+		return true
+	}
+	return strings.HasSuffix(granted, ":*") && strings.HasPrefix(perm, granted[:len(granted)-1])
+}
+
+// Enforcer casbin 的 *casbin.Enforcer 满足该接口
+type Enforcer interface {
+	Enforce(rvals ...interface{}) (bool, error)
+}
+
+// EnforcerPolicy 将 casbin Enforcer 适配为 Policy
+// perm 按最后一个 ":" 拆分为 obj 与 act，依次以 (role, obj, act) 调用 Enforce，任一角色通过即通过
+func EnforcerPolicy(e Enforcer) Policy {
+	return enforcerPolicy{e}
+}
+
+type enforcerPolicy struct {
+	e Enforcer
+}
+
+func (p enforcerPolicy) Allow(_ *Context, roles []string, perm string) (bool, error) {
+	obj, act := perm, ""
+	if i := strings.LastIndex(perm, ":"); i >= 0 {
+		obj, act = perm[:i], perm[i+1:]
+	}
+	for _, role := range roles {
+		ok, err := p.e.Enforce(role, obj, act)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type AuthorizeConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// Policy 授权策略
+	// Required.
+	Policy Policy
+
+	// Roles 返回当前请求主体的角色，通常由认证中间键写入 Context
+	// Optional. Default value reads []string from c.Get("roles").
+	Roles func(c *Context) []string
+
+	// MetaKey 路由元数据中权限的 key
+	// Optional. Default value MetaPerm.
+	MetaKey string
+}
+
+// Authorize 按路由元数据中声明的权限进行授权，未声明权限的路由直接放行，
+// 没有匹配到路由（例如通过 Pre 注册）的请求返回 403，权限不是非空字符串时返回 500
+func Authorize(policy Policy) echo.MiddlewareFunc {
+	return AuthorizeWithConfig(AuthorizeConfig{Policy: policy})
+}
+
+// AuthorizeWithConfig 授权中间键，拒绝时返回 403 并记录审计日志
+func AuthorizeWithConfig(conf AuthorizeConfig) echo.MiddlewareFunc {
+	if conf.Policy == nil {
+		panic("uecho: authorize middleware requires a policy")
+	}
+	if conf.Roles == nil {
+		conf.Roles = func(c *Context) []string {
+			roles, _ := c.Get("roles").([]string)
+			return roles
+		}
+	}
+	if conf.MetaKey == "" {
+		conf.MetaKey = MetaPerm
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) error {
+			if conf.Skipper != nil && conf.Skipper(c) {
+				return next(c)
+			}
+
+			route := c.Route()
+			if route == nil {
+				auditDenied(c, "", nil, "", "route not matched")
+				return c.Abort(ErrForbidden).WithField("authorize", "route not matched")
+			}
+			v, ok := route.GetMeta(conf.MetaKey)
+			if !ok {
+				return next(c)
+			}
+			perm, _ := v.(string)
+			if perm == "" {
+				return c.Abort(ErrInternal).WithFields(map[string]interface{}{
+					"authorize": "invalid permission meta",
+					"route":     route.Name,
+					"perm":      v,
+				})
+			}
+
+			roles := conf.Roles(c)
+			allowed, err := conf.Policy.Allow(c, roles, perm)
+			if err != nil {
+				return c.Abort(ErrInternal).WithErr(err)
+			}
+			if !allowed {
+				auditDenied(c, route.Name, roles, perm, "permission denied")
+				return c.Abort(ErrForbidden).WithField("perm", perm)
+			}
+			return next(c)
+		}
+
+		return WrapHandler(HandlerFunc(f))
+	}
+}
+
+// auditDenied 记录拒绝授权的审计日志
+func auditDenied(c *Context, route string, roles []string, perm, msg string) {
+	c.Logrus().WithFields(logrus.Fields{
+		"audit":      "authorize",
+		"route":      route,
+		"method":     c.Method(),
+		"path":       c.Request().URL.Path,
+		"roles":      roles,
+		"perm":       perm,
+		"request_id": c.RequestID(),
+	}).Warn(msg)
+}
@@ -0,0 +1,89 @@
+package uecho
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+func newAuthorizeApp(pre bool) (*UEcho, *bytes.Buffer) {
+	buf := new(bytes.Buffer)
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	ue := New(logger)
+	roles := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if r := c.Request().Header.Get("X-Roles"); r != "" {
+				c.Set("roles", strings.Split(r, ","))
+			}
+			return next(c)
+		}
+	}
+	authorize := Authorize(StaticPolicy{
+		"admin":  {"*"},
+		"viewer": {"orders:read"},
+	})
+	if pre {
+		ue.Pre(roles, authorize)
+	} else {
+		ue.Use(roles, authorize)
+	}
+
+	ok := HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	ue.GET("/orders", ok).Meta(MetaPerm, "orders:read")
+	ue.POST("/orders", ok).Meta(MetaPerm, "orders:write").Name = "createOrder"
+	ue.GET("/public", ok)
+	ue.GET("/broken", ok).Meta(MetaPerm, 1)
+	return ue, buf
+}
+
+func TestAuthorize(t *testing.T) {
+	ue, buf := newAuthorizeApp(false)
+	for _, tc := range []struct {
+		method, path, roles string
+		code                int
+	}{
+		{http.MethodGet, "/orders", "viewer", http.StatusNoContent},
+		{http.MethodPost, "/orders", "admin", http.StatusNoContent},
+		{http.MethodPost, "/orders", "viewer", http.StatusForbidden},
+		{http.MethodGet, "/orders", "", http.StatusForbidden},
+		{http.MethodGet, "/public", "", http.StatusNoContent},
+		{http.MethodGet, "/broken", "admin", http.StatusInternalServerError},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		req.Header.Set("X-Roles", tc.roles)
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, req)
+		if rec.Code != tc.code {
+			t.Errorf("%s %s (%s): status = %d, want %d", tc.method, tc.path, tc.roles, rec.Code, tc.code)
+		}
+	}
+
+	// 审计日志
+	log := buf.String()
+	if !strings.Contains(log, "audit=authorize") || !strings.Contains(log, "perm=\"orders:write\"") ||
+		!strings.Contains(log, "route=createOrder") || !strings.Contains(log, "roles=\"[viewer]\"") {
+		t.Errorf("audit log = %q", log)
+	}
+}
+
+func TestAuthorizeWithoutRoute(t *testing.T) {
+	ue, buf := newAuthorizeApp(true)
+	req := httptest.NewRequest(http.MethodGet, "/public", nil)
+	req.Header.Set("X-Roles", "admin")
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if !strings.Contains(buf.String(), "route not matched") {
+		t.Errorf("audit log = %q", buf.String())
+	}
+}
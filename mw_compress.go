@@ -0,0 +1,321 @@
+package uecho
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+)
+
+// DefaultCompressTypes 默认压缩的响应类型
+var DefaultCompressTypes = []string{
+	"text/*",
+	echo.MIMEApplicationJSON,
+	echo.MIMEApplicationJavaScript,
+	echo.MIMEApplicationXML,
+	MIMEApplicationProblemJSON,
+	"application/x-ndjson",
+	"image/svg+xml",
+}
+
+type CompressConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// Level 压缩级别，取值同 compress/flate（-2 ~ 9），0 视为默认级别
+	// Optional. Default value gzip.DefaultCompression.
+	Level int
+
+	// MinLength 响应体达到该长度才压缩，较小的响应压缩后反而更大；调用 Flush 的流式响应（SSE 等）不受限制
+	// Optional. Default value 1024.
+	MinLength int
+
+	// Types 需要压缩的响应类型，支持 type/*
+	// Optional. Default value DefaultCompressTypes.
+	Types []string
+
+	// TypeLevels 按响应类型设置压缩级别（覆盖 Level），gzip.NoCompression 表示该类型不压缩
+	// 例如对体积大、实时性要求高的 application/json 使用 gzip.BestSpeed
+	TypeLevels map[string]int
+}
+
+// DefaultCompressConfig is the default compress middleware config.
+var DefaultCompressConfig = CompressConfig{
+	Level:     gzip.DefaultCompression,
+	MinLength: 1024,
+	Types:     DefaultCompressTypes,
+}
+
+// Compress 响应压缩中间键，按 Accept-Encoding 使用 gzip 或 deflate
+// 标准库没有 brotli 实现，为避免引入额外依赖暂不支持 br，客户端只接受 br 时不压缩
+func Compress() echo.MiddlewareFunc {
+	return CompressWithConfig(DefaultCompressConfig)
+}
+
+// CompressWithConfig 响应压缩中间键，压缩器按编码及级别池化复用；
+// 流式响应每次 Flush 都会将已压缩的数据立即发送给客户端，不会被缓冲到响应结束
+func CompressWithConfig(conf CompressConfig) echo.MiddlewareFunc {
+	if conf.Level == gzip.NoCompression {
+		conf.Level = DefaultCompressConfig.Level
+	}
+	if conf.MinLength <= 0 {
+		conf.MinLength = DefaultCompressConfig.MinLength
+	}
+	if conf.Types == nil {
+		conf.Types = DefaultCompressConfig.Types
+	}
+	checkCompressLevel(conf.Level)
+	for _, level := range conf.TypeLevels {
+		checkCompressLevel(level)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) error {
+			if conf.Skipper != nil && conf.Skipper(c) {
+				return next(c)
+			}
+
+			res := c.Response()
+			res.Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+			encoding := acceptEncoding(c.Request().Header.Get(echo.HeaderAcceptEncoding))
+			if encoding == "" || c.Request().Method == http.MethodHead {
+				return next(c)
+			}
+
+			cw := &compressWriter{ResponseWriter: res.Writer, conf: &conf, encoding: encoding}
+			res.Writer = cw
+			defer func() {
+				cw.finish()
+				res.Writer = cw.ResponseWriter
+			}()
+			return next(c)
+		}
+		return WrapHandler(HandlerFunc(f))
+	}
+}
+
+func checkCompressLevel(level int) {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		panic("uecho: invalid compression level")
+	}
+}
+
+// acceptEncoding 返回客户端可接受的编码，优先 gzip；不接受压缩时返回空
+func acceptEncoding(header string) string {
+	if header == "" {
+		return ""
+	}
+	specs := parseAccept(header)
+	refused := make(map[string]bool)
+	for _, spec := range specs {
+		if spec.q <= 0 {
+			refused[spec.mediaType] = true
+		}
+	}
+	for _, spec := range specs {
+		if spec.q <= 0 {
+			continue
+		}
+		switch spec.mediaType {
+		case EncodingGzip, EncodingDeflate:
+			return spec.mediaType
+		case "*":
+			for _, enc := range []string{EncodingGzip, EncodingDeflate} {
+				if !refused[enc] {
+					return enc
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// compressor gzip.Writer 与 flate.Writer 的公共方法
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// 按级别（-2 ~ 9）池化的压缩器
+var (
+	gzipPools  [flate.BestCompression - flate.HuffmanOnly + 1]sync.Pool
+	flatePools [flate.BestCompression - flate.HuffmanOnly + 1]sync.Pool
+)
+
+func acquireCompressor(encoding string, level int, w io.Writer) compressor {
+	i := level - flate.HuffmanOnly
+	if encoding == EncodingGzip {
+		if cw, ok := gzipPools[i].Get().(compressor); ok {
+			cw.Reset(w)
+			return cw
+		}
+		cw, _ := gzip.NewWriterLevel(w, level)
+		return cw
+	}
+	if cw, ok := flatePools[i].Get().(compressor); ok {
+		cw.Reset(w)
+		return cw
+	}
+	cw, _ := flate.NewWriter(w, level)
+	return cw
+}
+
+func releaseCompressor(encoding string, level int, cw compressor) {
+	cw.Reset(ioutil.Discard)
+	if encoding == EncodingGzip {
+		gzipPools[level-flate.HuffmanOnly].Put(cw)
+	} else {
+		flatePools[level-flate.HuffmanOnly].Put(cw)
+	}
+}
+
+// compressWriter 在写入响应头前缓冲响应体，达到 MinLength 或 Flush 时决定是否压缩
+type compressWriter struct {
+	http.ResponseWriter
+	conf     *CompressConfig
+	encoding string
+
+	code    int
+	buf     []byte
+	decided bool
+	cw      compressor
+	level   int
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.decided || w.code != 0 {
+		return
+	}
+	w.code = code
+	if !bodyAllowed(code) {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		if w.code == 0 {
+			w.code = http.StatusOK
+		}
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < w.conf.MinLength {
+			return len(p), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.cw != nil {
+		return w.cw.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush 流式响应：将缓冲及压缩器中的数据立即发送给客户端
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if w.code == 0 {
+			w.code = http.StatusOK
+		}
+		w.decide(true)
+	}
+	if w.cw != nil {
+		w.cw.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("uecho: response writer does not support hijacking")
+}
+
+// decide 写出响应头及已缓冲的数据，compress 为 false 或响应类型不需要压缩时原样输出；
+// 范围响应（Content-Range）的偏移对应原始内容，压缩后客户端无法拼接，同样原样输出
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	if compress && bodyAllowed(w.code) && header.Get(echo.HeaderContentEncoding) == "" && header.Get("Content-Range") == "" {
+		ctype := header.Get(echo.HeaderContentType)
+		if ctype == "" {
+			ctype = http.DetectContentType(w.buf)
+			header.Set(echo.HeaderContentType, ctype)
+		}
+		if level, ok := w.conf.levelOf(ctype); ok {
+			header.Del(echo.HeaderContentLength)
+			header.Set(echo.HeaderContentEncoding, w.encoding)
+			w.level = level
+			w.cw = acquireCompressor(w.encoding, level, w.ResponseWriter)
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(w.code)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.cw != nil {
+		_, err = w.cw.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// finish 处理结束：未达到 MinLength 的响应原样输出，关闭压缩器并放回池中
+func (w *compressWriter) finish() {
+	if !w.decided {
+		if w.code == 0 && len(w.buf) == 0 {
+			return // 没有写入响应，留给 HTTPErrorHandler
+		}
+		w.decide(false)
+	}
+	if w.cw != nil {
+		w.cw.Close()
+		releaseCompressor(w.encoding, w.level, w.cw)
+		w.cw = nil
+	}
+}
+
+// levelOf 返回响应类型的压缩级别，不需要压缩时返回 false
+func (conf *CompressConfig) levelOf(contentType string) (int, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return 0, false
+	}
+	if level, ok := conf.TypeLevels[mediaType]; ok {
+		return level, level != gzip.NoCompression
+	}
+	for _, t := range conf.Types {
+		if matchMediaType(t, mediaType) {
+			return conf.Level, conf.Level != gzip.NoCompression
+		}
+	}
+	return 0, false
+}
+
+func bodyAllowed(code int) bool {
+	return code >= 200 && code != http.StatusNoContent && code != http.StatusNotModified
+}
@@ -0,0 +1,167 @@
+package uecho
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestAcceptEncoding(t *testing.T) {
+	cases := map[string]string{
+		"":                        "",
+		"gzip, deflate, br":       EncodingGzip,
+		"deflate;q=1, gzip;q=0.5": EncodingDeflate,
+		"gzip;q=0, *":             EncodingDeflate,
+		"br":                      "",
+		"identity":                "",
+	}
+	for header, want := range cases {
+		if got := acceptEncoding(header); got != want {
+			t.Errorf("%q: got %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat(`{"name":"uecho"}`, 200)
+	ue := New(nil)
+	ue.Use(CompressWithConfig(CompressConfig{
+		TypeLevels: map[string]int{"text/csv": gzip.NoCompression},
+	}))
+	ue.GET("/large", HandlerFunc(func(c *Context) error {
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, []byte(large))
+	}))
+	ue.GET("/small", HandlerFunc(func(c *Context) error {
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, []byte(`{}`))
+	}))
+	ue.GET("/csv", HandlerFunc(func(c *Context) error {
+		return c.Blob(http.StatusOK, "text/csv", []byte(large))
+	}))
+	ue.GET("/png", HandlerFunc(func(c *Context) error {
+		return c.Blob(http.StatusOK, "image/png", []byte(large))
+	}))
+	ue.GET("/range", HandlerFunc(func(c *Context) error {
+		c.Response().Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(large)-1, len(large)*2))
+		return c.Blob(http.StatusPartialContent, echo.MIMEApplicationJSON, []byte(large))
+	}))
+	ue.GET("/error", HandlerFunc(func(c *Context) error {
+		return c.Abort(ErrIllegalparams)
+	}))
+
+	do := func(path, encoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(echo.HeaderAcceptEncoding, encoding)
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("/large", "gzip")
+	if rec.Header().Get(echo.HeaderContentEncoding) != EncodingGzip || !strings.Contains(rec.Header().Get(echo.HeaderVary), echo.HeaderAcceptEncoding) {
+		t.Fatalf("unexpected headers %v", rec.Header())
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadAll(zr); string(b) != large {
+		t.Errorf("gzip body mismatch")
+	}
+
+	rec = do("/large", "deflate")
+	if rec.Header().Get(echo.HeaderContentEncoding) != EncodingDeflate {
+		t.Fatalf("unexpected headers %v", rec.Header())
+	}
+	if b, _ := ioutil.ReadAll(flate.NewReader(rec.Body)); string(b) != large {
+		t.Errorf("deflate body mismatch")
+	}
+
+	for _, path := range []string{"/small", "/csv", "/png", "/range", "/error"} {
+		rec = do(path, "gzip")
+		if rec.Header().Get(echo.HeaderContentEncoding) != "" {
+			t.Errorf("%s should not be compressed", path)
+		}
+	}
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"ec":400`) {
+		t.Errorf("error response: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// flushRecorder 记录每次 Flush 时已写出的数据
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushed []int
+}
+
+func (r *flushRecorder) Flush() {
+	r.flushed = append(r.flushed, r.Body.Len())
+	r.ResponseRecorder.Flush()
+}
+
+func TestCompressStream(t *testing.T) {
+	ue := New(nil)
+	ue.Use(Compress())
+	ue.GET("/events", HandlerFunc(func(c *Context) error {
+		return c.StreamFunc(http.StatusOK, "text/event-stream", func(w StreamWriter) error {
+			for i := 0; i < 3; i++ {
+				io.WriteString(w, "data: tick\n\n")
+				if err := w.Flush(); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	rec := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	ue.ServeHTTP(rec, req)
+
+	if rec.Header().Get(echo.HeaderContentEncoding) != EncodingGzip {
+		t.Fatalf("unexpected headers %v", rec.Header())
+	}
+	// 每个事件 Flush 后都有新的压缩数据发出
+	if len(rec.flushed) != 4 || rec.flushed[1] >= rec.flushed[2] || rec.flushed[2] >= rec.flushed[3] {
+		t.Errorf("flushed sizes %v", rec.flushed)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(rec.Body.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadAll(zr); string(b) != strings.Repeat("data: tick\n\n", 3) {
+		t.Errorf("body %q", b)
+	}
+}
+
+func BenchmarkCompress(b *testing.B) {
+	body := []byte(strings.Repeat(`{"name":"uecho","tags":["a","b"]}`, 256))
+	ue := New(nil)
+	ue.Use(Compress())
+	ue.GET("/", HandlerFunc(func(c *Context) error {
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, body)
+	}))
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(body)))
+	b.RunParallel(func(pb *testing.PB) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+		for pb.Next() {
+			rec := httptest.NewRecorder()
+			ue.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				b.Fatal(rec.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package uecho
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// HeaderRetryAfter 503/429 响应中提示客户端重试间隔的响应头
+const HeaderRetryAfter = "Retry-After"
+
+type ConcurrencyLimitConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// Max 同时处理的请求数上限
+	// Required.
+	Max int
+
+	// Queue 超过上限后允许排队等待的请求数，0 表示不排队直接拒绝
+	Queue int
+
+	// QueueTimeout 排队等待的最长时间，超时后返回 503
+	// Optional. Default value 1s.
+	QueueTimeout time.Duration
+
+	// PerRoute 为 true 时每个注册的路由单独计数（未匹配到路由的请求共享一个上限），否则所有经过该中间键的请求共享一个上限
+	PerRoute bool
+
+	// RetryAfter 拒绝时 Retry-After 响应头的值
+	// Optional. Default value 1s.
+	RetryAfter time.Duration
+}
+
+// ConcurrencyLimit 并发限制中间键，最多同时处理 max 个请求，超出的请求最多 queue 个排队等待 queueTimeout
+// 作为路由中间键使用时只限制该路由，作为全局中间键使用时限制所有请求
+func ConcurrencyLimit(max, queue int, queueTimeout time.Duration) echo.MiddlewareFunc {
+	return ConcurrencyLimitWithConfig(ConcurrencyLimitConfig{
+		Max:          max,
+		Queue:        queue,
+		QueueTimeout: queueTimeout,
+	})
+}
+
+// ConcurrencyLimitWithConfig 并发限制中间键，拒绝时返回携带 Retry-After 的 503
+func ConcurrencyLimitWithConfig(conf ConcurrencyLimitConfig) echo.MiddlewareFunc {
+	if conf.Max <= 0 {
+		panic("uecho: concurrency limit requires max > 0")
+	}
+	if conf.QueueTimeout <= 0 {
+		conf.QueueTimeout = time.Second
+	}
+	if conf.RetryAfter <= 0 {
+		conf.RetryAfter = time.Second
+	}
+	retryAfter := strconv.Itoa(int((conf.RetryAfter + time.Second - 1) / time.Second))
+
+	global := newConcurrencyLimiter(conf.Max)
+	unmatched := newConcurrencyLimiter(conf.Max)
+	var routes sync.Map // *Route => *concurrencyLimiter
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) error {
+			if conf.Skipper != nil && conf.Skipper(c) {
+				return next(c)
+			}
+
+			l := global
+			if conf.PerRoute {
+				// 以注册的路由为 key：未匹配到路由时 c.Path() 为请求的原始路径，不能作为 key
+				l = unmatched
+				if route := c.Route(); route != nil {
+					v, ok := routes.Load(route)
+					if !ok {
+						v, _ = routes.LoadOrStore(route, newConcurrencyLimiter(conf.Max))
+					}
+					l = v.(*concurrencyLimiter)
+				}
+			}
+
+			if !l.acquire(c, conf.Queue, conf.QueueTimeout) {
+				c.SetRespHeader(HeaderRetryAfter, retryAfter)
+				return c.Abort(ErrServiceUnavailable).WithField("concurrency_limit", conf.Max)
+			}
+			defer l.release()
+			return next(c)
+		}
+
+		return WrapHandler(HandlerFunc(f))
+	}
+}
+
+type concurrencyLimiter struct {
+	sem     chan struct{}
+	waiting int32
+}
+
+func newConcurrencyLimiter(max int) *concurrencyLimiter {
+	return &concurrencyLimiter{sem: make(chan struct{}, max)}
+}
+
+// acquire 获取执行许可，没有空闲许可时最多排队等待 timeout
+func (l *concurrencyLimiter) acquire(c *Context, queue int, timeout time.Duration) bool {
+	select {
+	case l.sem <- struct{}{}:
+		return true
+	default:
+	}
+
+	if atomic.AddInt32(&l.waiting, 1) > int32(queue) {
+		atomic.AddInt32(&l.waiting, -1)
+		return false
+	}
+	defer atomic.AddInt32(&l.waiting, -1)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case l.sem <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-c.RequestContext().Done():
+		return false
+	}
+}
+
+func (l *concurrencyLimiter) release() {
+	<-l.sem
+}
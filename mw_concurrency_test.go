@@ -0,0 +1,80 @@
+package uecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimit(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	ue := New(nil)
+	ue.GET("/busy", HandlerFunc(func(c *Context) error {
+		entered <- struct{}{}
+		<-release
+		return c.NoContent(http.StatusNoContent)
+	}), ConcurrencyLimit(1, 1, 20*time.Millisecond))
+
+	done := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/busy", nil))
+		done <- rec.Code
+	}()
+	<-entered
+
+	// 排队超时
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/busy", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get(HeaderRetryAfter) != "1" {
+		t.Fatalf("status = %d, Retry-After = %q", rec.Code, rec.Header().Get(HeaderRetryAfter))
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusNoContent {
+		t.Fatalf("first request: status = %d", code)
+	}
+}
+
+func TestConcurrencyLimitPerRoute(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	ue := New(nil)
+	ue.Use(ConcurrencyLimitWithConfig(ConcurrencyLimitConfig{Max: 1, QueueTimeout: 20 * time.Millisecond, PerRoute: true}))
+	ue.GET("/orders/:id", HandlerFunc(func(c *Context) error {
+		if c.Param("id") == "1" {
+			entered <- struct{}{}
+			<-release
+		}
+		return c.NoContent(http.StatusNoContent)
+	}))
+	ue.GET("/users", HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}))
+
+	done := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+		done <- rec.Code
+	}()
+	<-entered
+
+	// 同一路由的不同路径共享上限，其他路由及未匹配到路由的请求不受影响
+	for path, want := range map[string]int{
+		"/orders/2": http.StatusServiceUnavailable,
+		"/users":    http.StatusNoContent,
+		"/scan":     http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", path, rec.Code, want)
+		}
+	}
+
+	close(release)
+	if code := <-done; code != http.StatusNoContent {
+		t.Fatalf("first request: status = %d", code)
+	}
+}
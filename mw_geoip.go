@@ -0,0 +1,149 @@
+package uecho
+
+import (
+	"net"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// geoInfoKey GeoIP 信息在 Context 中的 key
+const geoInfoKey = "uecho.geoip"
+
+// GeoInfo GeoIP 查询结果
+type GeoInfo struct {
+	Country      string `json:"country,omitempty"` // ISO 3166-1 alpha-2，例如 CN
+	ASN          uint   `json:"asn,omitempty"`
+	Organization string `json:"organization,omitempty"`
+}
+
+// GeoIPProvider GeoIP 数据源
+type GeoIPProvider interface {
+	Lookup(ip net.IP) (*GeoInfo, error)
+}
+
+// MaxMindReader MaxMind 数据库读取接口，*maxminddb.Reader（oschwald/maxminddb-golang）满足该接口
+type MaxMindReader interface {
+	Lookup(ip net.IP, result interface{}) error
+}
+
+// MaxMindProvider 基于 MaxMind 的 GeoIP 数据源，country 为 GeoLite2-Country/City 库，asn 为 GeoLite2-ASN 库，均可为 nil
+func MaxMindProvider(country, asn MaxMindReader) GeoIPProvider {
+	return &maxMindProvider{country: country, asn: asn}
+}
+
+type maxMindProvider struct {
+	country MaxMindReader
+	asn     MaxMindReader
+}
+
+func (p *maxMindProvider) Lookup(ip net.IP) (*GeoInfo, error) {
+	info := new(GeoInfo)
+	if p.country != nil {
+		var record struct {
+			Country struct {
+				ISOCode string `maxminddb:"iso_code"`
+			} `maxminddb:"country"`
+		}
+		if err := p.country.Lookup(ip, &record); err != nil {
+			return nil, err
+		}
+		info.Country = record.Country.ISOCode
+	}
+	if p.asn != nil {
+		var record struct {
+			ASN          uint   `maxminddb:"autonomous_system_number"`
+			Organization string `maxminddb:"autonomous_system_organization"`
+		}
+		if err := p.asn.Lookup(ip, &record); err != nil {
+			return nil, err
+		}
+		info.ASN, info.Organization = record.ASN, record.Organization
+	}
+	return info, nil
+}
+
+// GeoInfo 返回 GeoIP 中间键查询到的信息，未查询或查询失败时返回 nil
+func (c *Context) GeoInfo() *GeoInfo {
+	info, _ := c.Get(geoInfoKey).(*GeoInfo)
+	return info
+}
+
+type GeoIPConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// Provider GeoIP 数据源
+	// Required.
+	Provider GeoIPProvider
+
+	// AllowCountries 允许访问的国家/地区，不为空时其余国家/地区（包括无法识别的，见 AllowUnknown）一律拒绝
+	AllowCountries []string
+
+	// DenyCountries 拒绝访问的国家/地区
+	DenyCountries []string
+
+	// AllowUnknown 为 true 时放行无法识别国家/地区的请求（仅在设置了 AllowCountries 时有意义，未设置时总是放行）
+	AllowUnknown bool
+
+	// IPExtractor 提取客户端 ip，位于反向代理之后时应设置 e.IPExtractor 或在此指定可信的提取方式
+	// Optional. Default value c.TrustedIP().
+	IPExtractor func(c *Context) string
+}
+
+// GeoIP GeoIP 中间键，将国家/地区及 ASN 写入 Context（c.GeoInfo()）及访问日志字段，
+// 并可按国家/地区放行或拒绝，拒绝时返回按请求语言本地化的 403
+func GeoIP(conf GeoIPConfig) echo.MiddlewareFunc {
+	if conf.Provider == nil {
+		panic("uecho: geoip middleware requires a provider")
+	}
+	if conf.IPExtractor == nil {
+		conf.IPExtractor = func(c *Context) string { return c.TrustedIP() }
+	}
+	allow := countrySet(conf.AllowCountries)
+	deny := countrySet(conf.DenyCountries)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) error {
+			if conf.Skipper != nil && conf.Skipper(c) {
+				return next(c)
+			}
+
+			var country string
+			if ip := net.ParseIP(conf.IPExtractor(c)); ip != nil {
+				if info, err := conf.Provider.Lookup(ip); err == nil && info != nil {
+					c.Set(geoInfoKey, info)
+					c.AddLogField("country", info.Country)
+					if info.ASN != 0 {
+						c.AddLogField("asn", info.ASN)
+					}
+					country = strings.ToUpper(info.Country)
+				}
+			}
+
+			blocked := deny[country] && country != ""
+			if len(allow) > 0 {
+				if country == "" {
+					blocked = blocked || !conf.AllowUnknown
+				} else {
+					blocked = blocked || !allow[country]
+				}
+			}
+			if blocked {
+				return c.Abort(localize(ErrRegionBlocked, c.Lang())).WithField("country", country)
+			}
+			return next(c)
+		}
+
+		return WrapHandler(HandlerFunc(f))
+	}
+}
+
+func countrySet(countries []string) map[string]bool {
+	set := make(map[string]bool, len(countries))
+	for _, country := range countries {
+		set[strings.ToUpper(country)] = true
+	}
+	return set
+}
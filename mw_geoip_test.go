@@ -0,0 +1,77 @@
+package uecho
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+type fakeGeoIP map[string]string
+
+func (p fakeGeoIP) Lookup(ip net.IP) (*GeoInfo, error) {
+	country, ok := p[ip.String()]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &GeoInfo{Country: country, ASN: 4134}, nil
+}
+
+func TestGeoIP(t *testing.T) {
+	provider := fakeGeoIP{"1.1.1.1": "cn", "2.2.2.2": "US", "3.3.3.3": "KP"}
+	newApp := func(conf GeoIPConfig) *UEcho {
+		conf.Provider = provider
+		ue := New(nil)
+		ue.Use(GeoIP(conf))
+		ue.GET("/", HandlerFunc(func(c *Context) error {
+			if info := c.GeoInfo(); info != nil && info.ASN != 4134 {
+				t.Errorf("geo info = %+v", info)
+			}
+			return c.NoContent(http.StatusNoContent)
+		}))
+		return ue
+	}
+	status := func(ue *UEcho, ip string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for _, tc := range []struct {
+		name string
+		conf GeoIPConfig
+		want map[string]int
+	}{
+		{"deny", GeoIPConfig{DenyCountries: []string{"kp"}}, map[string]int{
+			"1.1.1.1": http.StatusNoContent, "3.3.3.3": http.StatusForbidden, "9.9.9.9": http.StatusNoContent,
+		}},
+		{"allow", GeoIPConfig{AllowCountries: []string{"CN"}}, map[string]int{
+			"1.1.1.1": http.StatusNoContent, "2.2.2.2": http.StatusForbidden, "9.9.9.9": http.StatusForbidden,
+		}},
+		{"allow unknown", GeoIPConfig{AllowCountries: []string{"CN"}, AllowUnknown: true}, map[string]int{
+			"2.2.2.2": http.StatusForbidden, "9.9.9.9": http.StatusNoContent,
+		}},
+	} {
+		ue := newApp(tc.conf)
+		for ip, want := range tc.want {
+			if got := status(ue, ip); got != want {
+				t.Errorf("%s: %s got %d, want %d", tc.name, ip, got, want)
+			}
+		}
+	}
+
+	// 默认不信任客户端携带的 X-Real-IP
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "3.3.3.3:1234"
+	req.Header.Set(echo.HeaderXRealIP, "1.1.1.1")
+	rec := httptest.NewRecorder()
+	newApp(GeoIPConfig{DenyCountries: []string{"KP"}}).ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("spoofed X-Real-IP: got %d", rec.Code)
+	}
+}
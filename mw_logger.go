@@ -1,6 +1,8 @@
 package uecho
 
 import (
+	"io"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
@@ -11,6 +13,59 @@ import (
 type LoggerConfig struct {
 	// Skipper defines a function to skip middleware.
 	Skipper middleware.Skipper
+
+	// Hosts 按 host 路由（e.Host 的 name）输出访问日志的 logger，logger 为 nil 或 NopLogger 时不输出
+	// Optional.
+	Hosts map[string]*logrus.Logger
+
+	// Groups 按分组前缀输出访问日志的 logger，匹配路由所在分组的最长前缀，优先于 Hosts
+	// 例如 {"/admin": adminFileLogger, "/api": stdoutJSONLogger}
+	// Optional.
+	Groups map[string]*logrus.Logger
+
+	// Sink 未匹配 Hosts、Groups 的访问日志的输出（见 RotatingFile、AsyncSink、SyslogSink、KafkaSink），
+	// 为 nil 时输出到 New 传入的 logger
+	// Optional.
+	Sink io.Writer
+
+	// Formatter 输出到 Sink 的日志格式
+	// Optional. Default value &logrus.JSONFormatter{}.
+	Formatter logrus.Formatter
+
+	sinkLogger *logrus.Logger
+}
+
+// logger 返回当前请求访问日志的 logger，未配置时为 New 传入的 logger；
+// 返回 nil（New 传入 nil 或 Hosts 中配置为 nil）时不输出访问日志
+func (conf *LoggerConfig) logger(c *Context) *logrus.Logger {
+	if route := c.Route(); route != nil && len(conf.Groups) > 0 {
+		var (
+			matched string
+			logger  *logrus.Logger
+		)
+		for prefix, l := range conf.Groups {
+			if len(prefix) >= len(matched) && groupHasPrefix(route.Group, prefix) {
+				matched, logger = prefix, l
+			}
+		}
+		if logger != nil {
+			return logger
+		}
+	}
+	// host 路由以请求的 Host 为 key（见 findRouter），未匹配到路由的请求同样适用
+	if l, ok := conf.Hosts[c.Request().Host]; ok {
+		return l
+	}
+	if conf.sinkLogger != nil {
+		return conf.sinkLogger
+	}
+	return c.logger
+}
+
+// groupHasPrefix 分组 group 是否为 prefix 或其子分组
+func groupHasPrefix(group, prefix string) bool {
+	return strings.HasPrefix(group, prefix) &&
+		(len(group) == len(prefix) || strings.HasSuffix(prefix, "/") || group[len(prefix)] == '/')
 }
 
 func Logger() echo.MiddlewareFunc {
@@ -19,6 +74,9 @@ func Logger() echo.MiddlewareFunc {
 
 // LoggerWithConfig 日志中间键
 func LoggerWithConfig(conf LoggerConfig) echo.MiddlewareFunc {
+	if conf.Sink != nil {
+		conf.sinkLogger = newSinkLogger(conf.Sink, conf.Formatter)
+	}
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		f := func(c *Context) (err error) {
 			if conf.Skipper != nil && conf.Skipper(c) {
@@ -33,7 +91,20 @@ func LoggerWithConfig(conf LoggerConfig) echo.MiddlewareFunc {
 			}
 			stop := time.Now()
 
-			entry := c.Logrus().WithFields(logrus.Fields{
+			// 日志级别未开启（例如 NopLogger）时不构建日志字段
+			logger := conf.logger(c)
+			level := logrus.InfoLevel
+			if err != nil {
+				level = logrus.WarnLevel
+				if res.Status >= 500 {
+					level = logrus.ErrorLevel
+				}
+			}
+			if logger == nil || !logger.IsLevelEnabled(level) {
+				return
+			}
+
+			entry := logger.WithFields(logrus.Fields{
 				"host":       req.Host,
 				"uri":        req.RequestURI,
 				"method":     req.Method,
@@ -41,7 +112,8 @@ func LoggerWithConfig(conf LoggerConfig) echo.MiddlewareFunc {
 				"user_agent": req.UserAgent(),
 				"status":     res.Status,
 				"latency":    stop.Sub(start).String(),
-			})
+				"request_id": c.RequestID(),
+			}).WithFields(c.LogFields())
 
 			if err != nil { 
 				// 状态码 >= 500 即发生异常
@@ -0,0 +1,184 @@
+package uecho
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestLoggerDestinations(t *testing.T) {
+	newLogger := func() (*logrus.Logger, *bytes.Buffer) {
+		buf := new(bytes.Buffer)
+		l := logrus.New()
+		l.SetOutput(buf)
+		return l, buf
+	}
+	adminLogger, adminBuf := newLogger()
+	apiLogger, apiBuf := newLogger()
+	hostLogger, hostBuf := newLogger()
+
+	ue := New(nil)
+	ue.Use(LoggerWithConfig(LoggerConfig{
+		Groups: map[string]*logrus.Logger{"/admin": adminLogger, "/api": apiLogger},
+		Hosts:  map[string]*logrus.Logger{"static.example.com": hostLogger},
+	}))
+	ok := HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	ue.Group("/admin").Group("/users").GET("", ok)
+	ue.Group("/api").GET("/orders", ok)
+	ue.Group("/apix").GET("/orders", ok)
+	ue.Host("static.example.com").GET("/logo.png", ok)
+
+	for _, target := range []string{"/admin/users", "/api/orders", "/apix/orders", "http://static.example.com/logo.png"} {
+		ue.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	if !strings.Contains(adminBuf.String(), "/admin/users") || strings.Contains(adminBuf.String(), "/api") {
+		t.Errorf("admin log = %q", adminBuf.String())
+	}
+	if !strings.Contains(apiBuf.String(), "uri=/api/orders") || strings.Contains(apiBuf.String(), "/apix") {
+		t.Errorf("api log = %q", apiBuf.String())
+	}
+	if !strings.Contains(hostBuf.String(), "/logo.png") {
+		t.Errorf("host log = %q", hostBuf.String())
+	}
+}
+
+// countHook 记录触发次数的 logrus hook
+type countHook struct{ n int }
+
+func (h *countHook) Levels() []logrus.Level { return logrus.AllLevels }
+func (h *countHook) Fire(*logrus.Entry) error {
+	h.n++
+	return nil
+}
+
+func TestLoggerDisabled(t *testing.T) {
+	newApp := func(logger *logrus.Logger) *UEcho {
+		ue := New(logger)
+		ue.Use(LoggerWithConfig(LoggerConfig{
+			Hosts: map[string]*logrus.Logger{"quiet.example.com": nil},
+		}))
+		ue.GET("/", HandlerFunc(func(c *Context) error {
+			c.AddLogField("user", "u1")
+			return c.NoContent(http.StatusNoContent)
+		}))
+		ue.GET("/fail", HandlerFunc(func(c *Context) error {
+			return c.Abort(ErrInternal)
+		}))
+		return ue
+	}
+
+	buf, hook := new(bytes.Buffer), new(countHook)
+	quiet := logrus.New()
+	quiet.SetOutput(buf)
+	quiet.SetLevel(logrus.PanicLevel)
+	quiet.AddHook(hook)
+	ue := newApp(quiet)
+	for _, target := range []string{"/", "/fail", "http://quiet.example.com/"} {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code == 0 {
+			t.Errorf("%s: no response", target)
+		}
+	}
+	if hook.n != 0 || buf.Len() != 0 {
+		t.Errorf("disabled logger: %d entries, output %q", hook.n, buf.String())
+	}
+
+	// 级别未开启时不构建日志字段：与不使用 Logger 中间键相比只多出少量分配
+	plain := New(NopLogger)
+	plain.GET("/", HandlerFunc(func(c *Context) error {
+		c.AddLogField("user", "u1")
+		return c.NoContent(http.StatusNoContent)
+	}))
+	allocs := func(ue *UEcho) float64 {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		return testing.AllocsPerRun(100, func() {
+			ue.ServeHTTP(httptest.NewRecorder(), req)
+		})
+	}
+	if disabled, base := allocs(newApp(NopLogger)), allocs(plain); disabled-base > 4 {
+		t.Errorf("allocs with NopLogger = %v, without Logger middleware = %v", disabled, base)
+	}
+}
+
+func TestLoggerNil(t *testing.T) {
+	// 复用的 Context 保留 New 传入的 logger
+	logger, hook := logrus.New(), new(countHook)
+	logger.SetOutput(ioutil.Discard)
+	logger.AddHook(hook)
+	ue := New(logger)
+	ue.Use(Logger())
+	var got []*logrus.Logger
+	ue.GET("/", HandlerFunc(func(c *Context) error {
+		got = append(got, c.Logrus())
+		return c.NoContent(http.StatusNoContent)
+	}))
+	for i := 0; i < 3; i++ {
+		ue.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	for i, l := range got {
+		if l != logger {
+			t.Errorf("request %d: c.Logrus() is not the logger passed to New", i)
+		}
+	}
+	if hook.n != 3 {
+		t.Errorf("access log entries = %d", hook.n)
+	}
+
+	// New(nil) 时不输出访问日志，c.Logrus() 仍为 logrus.StandardLogger()
+	std := logrus.StandardLogger()
+	stdHook := new(countHook)
+	defer std.ReplaceHooks(std.ReplaceHooks(logrus.LevelHooks{}))
+	std.AddHook(stdHook)
+
+	ue = New(nil)
+	ue.Use(Logger())
+	ue.GET("/", HandlerFunc(func(c *Context) error {
+		if c.Logrus() != std {
+			t.Error("c.Logrus() is not the standard logger")
+		}
+		return c.NoContent(http.StatusNoContent)
+	}))
+	ue.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if stdHook.n != 0 {
+		t.Errorf("standard logger entries = %d", stdHook.n)
+	}
+}
+
+func benchmarkLogger(b *testing.B, logger *logrus.Logger) {
+	ue := New(logger)
+	ue.Use(Logger())
+	ue.GET("/", HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}))
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for pb.Next() {
+			rec := httptest.NewRecorder()
+			ue.ServeHTTP(rec, req)
+			if rec.Code != http.StatusNoContent {
+				b.Fatal(rec.Code)
+			}
+		}
+	})
+}
+
+func BenchmarkLogger(b *testing.B) {
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	benchmarkLogger(b, logger)
+}
+
+func BenchmarkLoggerNop(b *testing.B) {
+	benchmarkLogger(b, NopLogger)
+}
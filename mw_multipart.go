@@ -0,0 +1,200 @@
+package uecho
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"os"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type MultipartLimitConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// MaxMemory 解析表单时保存在内存中的上限，超出部分写入临时文件（同 http.Request#ParseMultipartForm）
+	// Optional. Default value 32MB.
+	MaxMemory int64
+
+	// MaxBodySize 请求体的大小上限，0 表示不限制
+	MaxBodySize int64
+
+	// MaxParts part（文件及普通字段）数量上限，0 表示不限制
+	MaxParts int
+
+	// MaxFieldSize 单个普通表单字段的大小上限，0 表示不限制
+	MaxFieldSize int64
+
+	// MaxFileSize 单个文件的大小上限，0 表示不限制
+	MaxFileSize int64
+
+	// AllowedTypes 允许上传的文件类型（part 的 Content-Type，支持 type/*），为空时不限制
+	AllowedTypes []string
+}
+
+// DefaultMultipartLimitConfig is the default multipart limit middleware config.
+var DefaultMultipartLimitConfig = MultipartLimitConfig{
+	MaxMemory: 32 << 20,
+}
+
+// MultipartLimit multipart 表单限制中间键，在处理函数之前逐个 part 检查请求体，
+// 超过限制返回 ErrRequestEntityTooLarge，文件类型不允许返回 ErrUnsupportedMediaType；
+// 检查通过后按 MaxMemory 解析表单，处理函数中 c.FormValue、c.FormFile 直接使用解析结果
+// 作为全局中间键使用时统一限制所有表单接口，也可以作为路由（分组）中间键使用不同的限制
+// 使用 c.Upload 的路由自行读取请求体，不应使用该中间键
+func MultipartLimit(maxBodySize int64) echo.MiddlewareFunc {
+	conf := DefaultMultipartLimitConfig
+	conf.MaxBodySize = maxBodySize
+	return MultipartLimitWithConfig(conf)
+}
+
+// MultipartLimitWithConfig multipart 表单限制中间键，见 MultipartLimit
+func MultipartLimitWithConfig(conf MultipartLimitConfig) echo.MiddlewareFunc {
+	if conf.MaxMemory <= 0 {
+		conf.MaxMemory = DefaultMultipartLimitConfig.MaxMemory
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) error {
+			if conf.Skipper != nil && conf.Skipper(c) {
+				return next(c)
+			}
+			req := c.Request()
+			mediaType, params, _ := mime.ParseMediaType(req.Header.Get(echo.HeaderContentType))
+			if mediaType != echo.MIMEMultipartForm || params["boundary"] == "" {
+				return next(c)
+			}
+			if conf.MaxBodySize > 0 && req.ContentLength > conf.MaxBodySize {
+				return c.Abort(ErrRequestEntityTooLarge).WithField("max_body_size", conf.MaxBodySize)
+			}
+
+			spool := &spoolBuffer{max: conf.MaxMemory}
+			defer spool.Close()
+			if err := conf.check(c, params["boundary"], spool); err != nil {
+				return err
+			}
+
+			body, err := spool.Reader()
+			if err != nil {
+				return err
+			}
+			req.Body = ioutil.NopCloser(body)
+			if err := req.ParseMultipartForm(conf.MaxMemory); err != nil {
+				return c.Abort(ErrIllegalparams).WithErr(err)
+			}
+			defer req.MultipartForm.RemoveAll()
+			return next(c)
+		}
+		return WrapHandler(HandlerFunc(f))
+	}
+}
+
+// check 逐个 part 检查请求体，请求体同时复制到 spool 供之后解析
+func (conf *MultipartLimitConfig) check(c *Context, boundary string, spool *spoolBuffer) error {
+	body := &uploadReader{r: io.TeeReader(c.Request().Body, spool), max: conf.MaxBodySize, conf: &UploadConfig{}}
+	mr := multipart.NewReader(body, boundary)
+	tooLarge := func() error {
+		return c.Abort(ErrRequestEntityTooLarge).WithField("max_body_size", conf.MaxBodySize)
+	}
+
+	for parts := 1; ; parts++ {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if body.exceeded {
+			return tooLarge()
+		}
+		if err != nil {
+			return c.Abort(ErrIllegalparams).WithErr(err)
+		}
+		if conf.MaxParts > 0 && parts > conf.MaxParts {
+			return c.Abort(ErrRequestEntityTooLarge).WithField("max_parts", conf.MaxParts)
+		}
+
+		limit := conf.MaxFieldSize
+		if part.FileName() != "" {
+			limit = conf.MaxFileSize
+			if !conf.allowed(part.Header.Get(echo.HeaderContentType)) {
+				return c.Abort(ErrUnsupportedMediaType).WithField("file", part.FileName()).WithField("content_type", part.Header.Get(echo.HeaderContentType))
+			}
+		}
+		var r io.Reader = part
+		if limit > 0 {
+			r = io.LimitReader(part, limit+1)
+		}
+		n, err := io.Copy(ioutil.Discard, r)
+		if body.exceeded {
+			return tooLarge()
+		}
+		if err != nil {
+			return c.Abort(ErrIllegalparams).WithErr(err)
+		}
+		if limit > 0 && n > limit {
+			return c.Abort(ErrRequestEntityTooLarge).WithField("part", part.FormName()).WithField("max_part_size", limit)
+		}
+	}
+}
+
+func (conf *MultipartLimitConfig) allowed(contentType string) bool {
+	if len(conf.AllowedTypes) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range conf.AllowedTypes {
+		if matchMediaType(t, mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+// spoolBuffer 不超过 max 时保存在内存中，超出后写入临时文件
+type spoolBuffer struct {
+	max  int64
+	buf  bytes.Buffer
+	file *os.File
+}
+
+func (s *spoolBuffer) Write(p []byte) (int, error) {
+	if s.file == nil && int64(s.buf.Len()+len(p)) > s.max {
+		f, err := ioutil.TempFile("", "uecho-multipart-*")
+		if err != nil {
+			return 0, err
+		}
+		s.file = f
+		if _, err := s.buf.WriteTo(f); err != nil {
+			return 0, err
+		}
+	}
+	if s.file != nil {
+		return s.file.Write(p)
+	}
+	return s.buf.Write(p)
+}
+
+// Reader 从头读取已写入的数据
+func (s *spoolBuffer) Reader() (io.Reader, error) {
+	if s.file == nil {
+		return &s.buf, nil
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return s.file, nil
+}
+
+func (s *spoolBuffer) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	s.file.Close()
+	return os.Remove(s.file.Name())
+}
@@ -0,0 +1,81 @@
+package uecho
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func TestMultipartLimit(t *testing.T) {
+	ue := New(nil)
+	ue.Use(MultipartLimitWithConfig(MultipartLimitConfig{
+		MaxMemory:    16,
+		MaxBodySize:  2 << 10,
+		MaxParts:     3,
+		MaxFieldSize: 8,
+		MaxFileSize:  1 << 10,
+		AllowedTypes: []string{"image/*"},
+	}))
+	ue.POST("/form", HandlerFunc(func(c *Context) error {
+		fh, err := c.FormFile("avatar")
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, c.FormValue("name")+" "+fh.Filename)
+	}))
+
+	type part struct{ name, filename, ctype, content string }
+	do := func(parts ...part) *httptest.ResponseRecorder {
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		for _, p := range parts {
+			h := textproto.MIMEHeader{}
+			disposition := `form-data; name="` + p.name + `"`
+			if p.filename != "" {
+				disposition += `; filename="` + p.filename + `"`
+				h.Set("Content-Type", p.ctype)
+			}
+			h.Set("Content-Disposition", disposition)
+			w, _ := mw.CreatePart(h)
+			w.Write([]byte(p.content))
+		}
+		mw.Close()
+		req := httptest.NewRequest(http.MethodPost, "/form", body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, req)
+		return rec
+	}
+
+	avatar := part{"avatar", "a.png", "image/png", strings.Repeat("p", 512)}
+	if rec := do(part{"name", "", "", "uecho"}, avatar); rec.Code != http.StatusOK || rec.Body.String() != "uecho a.png" {
+		t.Errorf("got %d %s", rec.Code, rec.Body.String())
+	}
+
+	cases := []struct {
+		parts []part
+		code  int
+	}{
+		{[]part{{"name", "", "", "too long value"}, avatar}, http.StatusRequestEntityTooLarge},
+		{[]part{{"avatar", "a.png", "image/png", strings.Repeat("p", 2<<10)}}, http.StatusRequestEntityTooLarge},
+		{[]part{{"a", "", "", "1"}, {"b", "", "", "2"}, {"c", "", "", "3"}, avatar}, http.StatusRequestEntityTooLarge},
+		{[]part{{"avatar", "a.exe", "application/octet-stream", "MZ"}}, http.StatusUnsupportedMediaType},
+	}
+	for i, tc := range cases {
+		if rec := do(tc.parts...); rec.Code != tc.code {
+			t.Errorf("case %d: got %d, want %d", i, rec.Code, tc.code)
+		}
+	}
+
+	var files []part
+	for i := 0; i < 3; i++ {
+		files = append(files, part{"avatar", "a.png", "image/png", strings.Repeat("p", 1<<10)})
+	}
+	if rec := do(files...); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("body too large: got %d", rec.Code)
+	}
+}
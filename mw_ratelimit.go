@@ -0,0 +1,172 @@
+package uecho
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// 限流响应头，X-RateLimit-* 为常用的事实标准，RateLimit-* 为 IETF draft-ietf-httpapi-ratelimit-headers
+const (
+	HeaderXRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderXRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderXRateLimitReset     = "X-RateLimit-Reset"
+	HeaderRateLimitLimit      = "RateLimit-Limit"
+	HeaderRateLimitRemaining  = "RateLimit-Remaining"
+	HeaderRateLimitReset      = "RateLimit-Reset"
+	HeaderRateLimitPolicy     = "RateLimit-Policy"
+)
+
+// RateLimitInfo 限流器的状态
+type RateLimitInfo struct {
+	// Limit 窗口内允许的请求数
+	Limit int
+
+	// Remaining 窗口内剩余的请求数
+	Remaining int
+
+	// Reset 窗口重置（恢复配额）的时间
+	Reset time.Time
+
+	// Window 窗口长度，不为 0 时输出 RateLimit-Policy
+	Window time.Duration
+}
+
+// SetRateLimitHeaders 按限流器的状态设置限流响应头，放行与拒绝的响应都应设置：
+// X-RateLimit-Limit/Remaining/Reset（Reset 为 Unix 时间戳），
+// RateLimit-Limit/Remaining/Reset（Reset 为剩余秒数）及 RateLimit-Policy
+// 无论使用哪种限流器，都可以通过该方法输出统一的响应头
+func (c *Context) SetRateLimitHeaders(info RateLimitInfo) {
+	remaining := info.Remaining
+	if remaining < 0 {
+		remaining = 0
+	}
+	limit, rem := strconv.Itoa(info.Limit), strconv.Itoa(remaining)
+	c.SetRespHeader(HeaderXRateLimitLimit, limit)
+	c.SetRespHeader(HeaderXRateLimitRemaining, rem)
+	c.SetRespHeader(HeaderRateLimitLimit, limit)
+	c.SetRespHeader(HeaderRateLimitRemaining, rem)
+	if !info.Reset.IsZero() {
+		c.SetRespHeader(HeaderXRateLimitReset, strconv.FormatInt(info.Reset.Unix(), 10))
+		c.SetRespHeader(HeaderRateLimitReset, strconv.Itoa(resetSeconds(info.Reset)))
+	}
+	if info.Window > 0 {
+		c.SetRespHeader(HeaderRateLimitPolicy, limit+";w="+strconv.Itoa(int((info.Window+time.Second-1)/time.Second)))
+	}
+}
+
+// resetSeconds 距离 reset 的秒数（向上取整）
+func resetSeconds(reset time.Time) int {
+	d := time.Until(reset)
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// RateLimiter 限流器
+type RateLimiter interface {
+	// Allow 消耗 key 的一次配额，返回是否放行及限流器的状态
+	Allow(key string) (bool, RateLimitInfo, error)
+}
+
+// MemoryRateLimiter 进程内的固定窗口限流器，每个 key 在每个窗口内最多放行 Limit 个请求
+type MemoryRateLimiter struct {
+	Limit  int
+	Window time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*rateWindow
+	sweep   time.Time
+}
+
+type rateWindow struct {
+	count int
+	reset time.Time
+}
+
+// NewMemoryRateLimiter 创建 MemoryRateLimiter
+func NewMemoryRateLimiter(limit int, window time.Duration) *MemoryRateLimiter {
+	return &MemoryRateLimiter{Limit: limit, Window: window, windows: make(map[string]*rateWindow)}
+}
+
+func (l *MemoryRateLimiter) Allow(key string) (bool, RateLimitInfo, error) {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 定期清理已过期的窗口
+	if now.After(l.sweep) {
+		for k, w := range l.windows {
+			if !now.Before(w.reset) {
+				delete(l.windows, k)
+			}
+		}
+		l.sweep = now.Add(l.Window)
+	}
+
+	w, ok := l.windows[key]
+	if !ok || !now.Before(w.reset) {
+		w = &rateWindow{reset: now.Add(l.Window)}
+		l.windows[key] = w
+	}
+	allowed := w.count < l.Limit
+	if allowed {
+		w.count++
+	}
+	return allowed, RateLimitInfo{Limit: l.Limit, Remaining: l.Limit - w.count, Reset: w.reset, Window: l.Window}, nil
+}
+
+type RateLimitConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// Limiter 限流器
+	// Required.
+	Limiter RateLimiter
+
+	// KeyFunc 限流的 key，位于反向代理之后时应设置 e.IPExtractor（否则所有请求都是代理的 ip）
+	// Optional. Default value c.TrustedIP().
+	KeyFunc func(c *Context) string
+}
+
+// RateLimit 限流中间键，见 RateLimitWithConfig
+func RateLimit(limiter RateLimiter) echo.MiddlewareFunc {
+	return RateLimitWithConfig(RateLimitConfig{Limiter: limiter})
+}
+
+// RateLimitWithConfig 限流中间键，放行与拒绝的响应都携带限流响应头（见 SetRateLimitHeaders），
+// 拒绝时返回携带 Retry-After 的 429
+func RateLimitWithConfig(conf RateLimitConfig) echo.MiddlewareFunc {
+	if conf.Limiter == nil {
+		panic("uecho: rate limit middleware requires a limiter")
+	}
+	if conf.KeyFunc == nil {
+		conf.KeyFunc = func(c *Context) string { return c.TrustedIP() }
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) error {
+			if conf.Skipper != nil && conf.Skipper(c) {
+				return next(c)
+			}
+
+			allowed, info, err := conf.Limiter.Allow(conf.KeyFunc(c))
+			if err != nil {
+				return c.Abort(ErrInternal).WithErr(err)
+			}
+			c.SetRateLimitHeaders(info)
+			if !allowed {
+				if !info.Reset.IsZero() {
+					c.SetRespHeader(HeaderRetryAfter, strconv.Itoa(resetSeconds(info.Reset)))
+				}
+				return c.Abort(ErrTooManyRequests).WithField("rate_limit", info.Limit)
+			}
+			return next(c)
+		}
+		return WrapHandler(HandlerFunc(f))
+	}
+}
@@ -0,0 +1,60 @@
+package uecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	ue := New(nil)
+	ue.GET("/", HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}), RateLimit(NewMemoryRateLimiter(2, time.Minute)))
+
+	for i, want := range []struct {
+		code      int
+		remaining string
+	}{{http.StatusNoContent, "1"}, {http.StatusNoContent, "0"}, {http.StatusTooManyRequests, "0"}} {
+		// 轮换 X-Forwarded-For 不会得到新的额度
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Forwarded-For", "10.0.0."+strconv.Itoa(i))
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, req)
+		h := rec.Header()
+		if rec.Code != want.code || h.Get(HeaderXRateLimitRemaining) != want.remaining || h.Get(HeaderRateLimitRemaining) != want.remaining {
+			t.Errorf("request %d: got %d remaining %q", i, rec.Code, h.Get(HeaderXRateLimitRemaining))
+		}
+		if h.Get(HeaderXRateLimitLimit) != "2" || h.Get(HeaderRateLimitPolicy) != "2;w=60" {
+			t.Errorf("request %d: headers %v", i, h)
+		}
+		if reset, _ := strconv.Atoi(h.Get(HeaderRateLimitReset)); reset < 59 || reset > 60 {
+			t.Errorf("request %d: RateLimit-Reset %q", i, h.Get(HeaderRateLimitReset))
+		}
+		if reset, _ := strconv.ParseInt(h.Get(HeaderXRateLimitReset), 10, 64); reset < time.Now().Unix() {
+			t.Errorf("request %d: X-RateLimit-Reset %q", i, h.Get(HeaderXRateLimitReset))
+		}
+		if retry := h.Get(HeaderRetryAfter); (rec.Code == http.StatusTooManyRequests) != (retry != "") {
+			t.Errorf("request %d: Retry-After %q", i, retry)
+		}
+	}
+}
+
+func TestMemoryRateLimiterWindow(t *testing.T) {
+	l := NewMemoryRateLimiter(1, 20*time.Millisecond)
+	if ok, _, _ := l.Allow("a"); !ok {
+		t.Fatal("first request rejected")
+	}
+	if ok, _, _ := l.Allow("a"); ok {
+		t.Fatal("second request allowed")
+	}
+	if ok, _, _ := l.Allow("b"); !ok {
+		t.Fatal("other key rejected")
+	}
+	time.Sleep(25 * time.Millisecond)
+	if ok, info, _ := l.Allow("a"); !ok || info.Remaining != 0 {
+		t.Fatalf("after window: %v %+v", ok, info)
+	}
+}
@@ -1 +1,91 @@
-package uecho
\ No newline at end of file
+package uecho
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type RecoverConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// StackSize panic 所在 goroutine 堆栈的最大长度
+	// Optional. Default value 4KB.
+	StackSize int
+
+	// GoroutineDump 为 true 时抓取全部 goroutine 的堆栈（"goroutines" 字段）
+	// Optional. Default value false.
+	GoroutineDump bool
+
+	// MaxDumpSize goroutine dump 的最大长度，超出部分截断
+	// Optional. Default value 64KB.
+	MaxDumpSize int
+
+	// OnError panic 恢复后依次调用，err 携带 stack（及 goroutines）字段
+	// Optional.
+	OnError []func(c *Context, err ErrReply)
+}
+
+// Recover panic 恢复中间键
+func Recover() echo.MiddlewareFunc {
+	return RecoverWithConfig(RecoverConfig{})
+}
+
+// RecoverWithConfig panic 恢复中间键，panic 转换为 ErrInternal，
+// 堆栈作为 errReply 的字段输出到 Logger 的 ERROR 日志，应在 Logger 之后注册
+func RecoverWithConfig(conf RecoverConfig) echo.MiddlewareFunc {
+	if conf.StackSize <= 0 {
+		conf.StackSize = 4 << 10
+	}
+	if conf.MaxDumpSize <= 0 {
+		conf.MaxDumpSize = 64 << 10
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) (err error) {
+			if conf.Skipper != nil && conf.Skipper(c) {
+				return next(c)
+			}
+
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+
+				perr, ok := r.(error)
+				if !ok {
+					perr = fmt.Errorf("%v", r)
+				}
+				er := c.Abort(ErrInternal).WithErr(perr).WithField("stack", stackTrace(conf.StackSize, false))
+				if conf.GoroutineDump {
+					er = er.WithField("goroutines", stackTrace(conf.MaxDumpSize, true))
+				}
+				for _, fn := range conf.OnError {
+					fn(c, er)
+				}
+				err = er
+			}()
+			return next(c)
+		}
+
+		return WrapHandler(HandlerFunc(f))
+	}
+}
+
+// stackTrace 返回当前（all 为 true 时为全部 goroutine）堆栈，最长 size 字节
+func stackTrace(size int, all bool) string {
+	buf := make([]byte, size)
+	n := runtime.Stack(buf, all)
+	if n == size && all {
+		return string(buf[:n]) + "\n... goroutine dump truncated"
+	}
+	return string(buf[:n])
+}
@@ -0,0 +1,35 @@
+package uecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	var fields map[string]interface{}
+	ue := New(nil)
+	ue.Use(RecoverWithConfig(RecoverConfig{
+		GoroutineDump: true,
+		MaxDumpSize:   1024,
+		OnError: []func(*Context, ErrReply){func(c *Context, err ErrReply) {
+			fields = err.(*errReply).fields
+		}},
+	}))
+	ue.GET("/panic", HandlerFunc(func(c *Context) error {
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if stack, _ := fields["stack"].(string); !strings.Contains(stack, "TestRecover") {
+		t.Fatalf("stack = %q", stack)
+	}
+	if dump, _ := fields["goroutines"].(string); len(dump) == 0 || len(dump) > 1024+len("\n... goroutine dump truncated") {
+		t.Fatalf("goroutines dump length = %d", len(dump))
+	}
+}
@@ -0,0 +1,175 @@
+package uecho
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// HeaderXShadowRequest 镜像请求携带的请求头，便于 staging 服务识别
+const HeaderXShadowRequest = "X-Shadow-Request"
+
+// DefaultShadowStripHeaders 镜像请求默认删除的请求头（凭证），避免线上凭证泄露到镜像目标
+var DefaultShadowStripHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+	"X-Csrf-Token",
+}
+
+type ShadowConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// Target 镜像流量的目标服务地址，例如 http://staging.internal:8080
+	// Required.
+	Target string
+
+	// Percent 镜像的请求比例，取值 0~100
+	Percent float64
+
+	// Client 发送镜像请求的 http client
+	// Optional. Default value has a 5s timeout.
+	Client *http.Client
+
+	// MaxBodySize 请求体超过该大小时不镜像
+	// Optional. Default value 1MB.
+	MaxBodySize int64
+
+	// MaxInFlight 同时进行中的镜像请求上限，超过时丢弃新的镜像请求
+	// Optional. Default value 100.
+	MaxInFlight int
+
+	// StripHeaders 镜像请求中删除的请求头，为 nil 时使用默认值，不删除时设置为 []string{}
+	// Optional. Default value DefaultShadowStripHeaders.
+	StripHeaders []string
+}
+
+// Shadow 将 percent% 的线上请求异步复制到 target，响应被丢弃，不影响正常请求
+func Shadow(target string, percent float64) echo.MiddlewareFunc {
+	return ShadowWithConfig(ShadowConfig{
+		Target:  target,
+		Percent: percent,
+	})
+}
+
+// ShadowWithConfig 流量镜像中间键
+func ShadowWithConfig(conf ShadowConfig) echo.MiddlewareFunc {
+	target, err := url.Parse(conf.Target)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		panic("uecho: shadow middleware requires a valid target url")
+	}
+	if conf.Client == nil {
+		conf.Client = &http.Client{Timeout: 5 * time.Second}
+	}
+	if conf.MaxBodySize <= 0 {
+		conf.MaxBodySize = 1 << 20
+	}
+	if conf.MaxInFlight <= 0 {
+		conf.MaxInFlight = 100
+	}
+	if conf.StripHeaders == nil {
+		conf.StripHeaders = DefaultShadowStripHeaders
+	}
+	inflight := make(chan struct{}, conf.MaxInFlight)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) error {
+			if conf.Skipper != nil && conf.Skipper(c) {
+				return next(c)
+			}
+			if conf.Percent <= 0 || rand.Float64()*100 >= conf.Percent {
+				return next(c)
+			}
+
+			req := c.Request()
+			// 读取请求体失败时不镜像，由处理函数自行处理请求体的错误
+			body, ok, err := rebufferBody(req, conf.MaxBodySize)
+			if err != nil {
+				c.Logrus().WithError(err).Debug("shadow: read request body failed")
+			}
+			if !ok {
+				return next(c)
+			}
+
+			select {
+			case inflight <- struct{}{}:
+			default:
+				return next(c)
+			}
+
+			shadow := shadowRequest(req, target, body, conf.StripHeaders)
+			logger := c.Logrus()
+			go func() {
+				defer func() { <-inflight }()
+				resp, err := conf.Client.Do(shadow)
+				if err != nil {
+					logger.WithError(err).WithField("target", conf.Target).Debug("shadow request failed")
+					return
+				}
+				_, _ = io.Copy(ioutil.Discard, resp.Body)
+				resp.Body.Close()
+			}()
+			return next(c)
+		}
+
+		return WrapHandler(HandlerFunc(f))
+	}
+}
+
+// rebufferBody 读取请求体并重新放回 req.Body，请求体超过 limit 或读取失败时返回 false（已读取的部分同样会被放回）
+func rebufferBody(req *http.Request, limit int64) ([]byte, bool, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true, nil
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, limit+1))
+	if err != nil || int64(len(body)) > limit {
+		req.Body = readCloser{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+		return nil, false, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, true, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// shadowRequest 构造发往 target 的镜像请求，删除 strip 中的请求头；不使用原请求的 ctx，避免原请求结束时被取消
+func shadowRequest(req *http.Request, target *url.URL, body []byte, strip []string) *http.Request {
+	u := *target
+	u.Path = singleJoiningSlash(target.Path, req.URL.Path)
+	u.RawQuery = req.URL.RawQuery
+
+	shadow, _ := http.NewRequestWithContext(context.Background(), req.Method, u.String(), bytes.NewReader(body))
+	shadow.Header = req.Header.Clone()
+	for _, key := range strip {
+		shadow.Header.Del(key)
+	}
+	shadow.Header.Set(HeaderXShadowRequest, "1")
+	return shadow
+}
+
+func singleJoiningSlash(a, b string) string {
+	aslash := len(a) > 0 && a[len(a)-1] == '/'
+	bslash := len(b) > 0 && b[0] == '/'
+	switch {
+	case aslash && bslash:
+		return a + b[1:]
+	case !aslash && !bslash:
+		return a + "/" + b
+	}
+	return a + b
+}
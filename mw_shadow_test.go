@@ -0,0 +1,125 @@
+package uecho
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type shadowed struct {
+	path, body, header string
+	credentials        string // Authorization、Cookie
+	trace              string
+}
+
+func newShadowTarget(t *testing.T) (*httptest.Server, chan shadowed) {
+	ch := make(chan shadowed, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		ch <- shadowed{r.URL.RequestURI(), string(b), r.Header.Get(HeaderXShadowRequest),
+			r.Header.Get("Authorization") + r.Header.Get("Cookie"), r.Header.Get("X-Trace")}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, ch
+}
+
+func newShadowApp(conf ShadowConfig, got *string) *UEcho {
+	ue := New(nil)
+	ue.Use(ShadowWithConfig(conf))
+	ue.POST("/orders", HandlerFunc(func(c *Context) error {
+		b, err := ioutil.ReadAll(c.Request().Body)
+		*got = string(b)
+		if err != nil {
+			return c.Abort(ErrIllegalparams).WithErr(err)
+		}
+		return c.NoContent(http.StatusNoContent)
+	}))
+	return ue
+}
+
+func expectNoShadow(t *testing.T, ch chan shadowed) {
+	select {
+	case s := <-ch:
+		t.Errorf("unexpected shadow request %+v", s)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestShadow(t *testing.T) {
+	srv, ch := newShadowTarget(t)
+	var got string
+	ue := newShadowApp(ShadowConfig{Target: srv.URL + "/v2", Percent: 100}, &got)
+
+	req := httptest.NewRequest(http.MethodPost, "/orders?id=1", strings.NewReader(`{"id":1}`))
+	req.Header.Set("Authorization", "Bearer prod-token")
+	req.Header.Set("Cookie", "session=prod")
+	req.Header.Set("X-Trace", "t1")
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent || got != `{"id":1}` {
+		t.Fatalf("live request: status = %d, body = %q", rec.Code, got)
+	}
+	// 默认删除凭证请求头，其他请求头原样转发
+	select {
+	case s := <-ch:
+		if s.path != "/v2/orders?id=1" || s.body != `{"id":1}` || s.header != "1" || s.credentials != "" || s.trace != "t1" {
+			t.Errorf("shadow request = %+v", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request was not mirrored")
+	}
+}
+
+func TestShadowSampling(t *testing.T) {
+	srv, ch := newShadowTarget(t)
+	var got string
+	ue := newShadowApp(ShadowConfig{Target: srv.URL, Percent: 0}, &got)
+	for i := 0; i < 10; i++ {
+		ue.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("x")))
+	}
+	expectNoShadow(t, ch)
+}
+
+func TestShadowBodyLimit(t *testing.T) {
+	srv, ch := newShadowTarget(t)
+	var got string
+	ue := newShadowApp(ShadowConfig{Target: srv.URL, Percent: 100, MaxBodySize: 4}, &got)
+
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader("0123456789")))
+	if rec.Code != http.StatusNoContent || got != "0123456789" {
+		t.Fatalf("live request: status = %d, body = %q", rec.Code, got)
+	}
+	expectNoShadow(t, ch)
+}
+
+type failingReader struct {
+	data string
+	read bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if !r.read {
+		r.read = true
+		return copy(p, r.data), nil
+	}
+	return 0, errors.New("connection reset")
+}
+
+func TestShadowBodyReadError(t *testing.T) {
+	srv, ch := newShadowTarget(t)
+	var got string
+	ue := newShadowApp(ShadowConfig{Target: srv.URL, Percent: 100}, &got)
+
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", &failingReader{data: "part"}))
+	// 读取失败由处理函数自行处理，已读取的部分仍然可见
+	if rec.Code != ErrIllegalparams.HTTPCode() || got != "part" {
+		t.Fatalf("live request: status = %d, body = %q", rec.Code, got)
+	}
+	expectNoShadow(t, ch)
+}
@@ -0,0 +1,181 @@
+package uecho
+
+import (
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// MetaPriority 路由优先级的元数据 key
+const MetaPriority = "priority"
+
+// Priority 设置路由优先级（默认 0），过载时优先级低的路由先被拒绝
+func (r *Route) Priority(p int) *Route {
+	return r.Meta(MetaPriority, p)
+}
+
+// priority 返回路由优先级
+func (r *Route) priority() int {
+	v, _ := r.GetMeta(MetaPriority)
+	p, _ := v.(int)
+	return p
+}
+
+type LoadShedConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// Latency 请求处理耗时的 Percentile 分位超过该值时视为过载，0 表示不按耗时判断
+	// 一个评估间隔内没有新的耗时样本（例如请求均被拒绝）时丢弃旧样本，不再按耗时视为过载
+	Latency time.Duration
+
+	// Percentile 耗时分位
+	// Optional. Default value 0.95.
+	Percentile float64
+
+	// MaxGoroutines goroutine 数量超过该值时视为过载，0 表示不按 goroutine 数判断
+	MaxGoroutines int
+
+	// Overloaded 用户自定义的过载信号，返回 true 时视为过载
+	// Optional.
+	Overloaded func() bool
+
+	// Window 参与分位计算的最近请求数
+	// Optional. Default value 1000.
+	Window int
+
+	// Interval 过载状态的评估间隔，每个间隔内拒绝级别最多升高或降低一级
+	// Optional. Default value 1s.
+	Interval time.Duration
+
+	// MaxLevel 最高拒绝级别，优先级 >= MaxLevel 的路由永远不会被拒绝
+	// Optional. Default value 1 (只拒绝优先级为 0 的路由).
+	MaxLevel int
+
+	// RetryAfter 拒绝时 Retry-After 响应头的值
+	// Optional. Default value 1s.
+	RetryAfter time.Duration
+}
+
+// LoadShed 自适应降载中间键
+// 按 Interval 评估服务是否过载：过载时拒绝级别升高一级，恢复后逐级降低；
+// 优先级（Route.Priority）低于当前级别的路由返回 503
+func LoadShed(conf LoadShedConfig) echo.MiddlewareFunc {
+	if conf.Percentile <= 0 || conf.Percentile > 1 {
+		conf.Percentile = 0.95
+	}
+	if conf.Window <= 0 {
+		conf.Window = 1000
+	}
+	if conf.Interval <= 0 {
+		conf.Interval = time.Second
+	}
+	if conf.MaxLevel <= 0 {
+		conf.MaxLevel = 1
+	}
+	if conf.RetryAfter <= 0 {
+		conf.RetryAfter = time.Second
+	}
+	retryAfter := strconv.Itoa(int((conf.RetryAfter + time.Second - 1) / time.Second))
+
+	s := &shedder{
+		conf:    conf,
+		samples: make([]time.Duration, 0, conf.Window),
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) error {
+			if conf.Skipper != nil && conf.Skipper(c) {
+				return next(c)
+			}
+
+			level := s.evaluate(time.Now())
+			if c.Route().priority() < level {
+				c.SetRespHeader(HeaderRetryAfter, retryAfter)
+				return c.Abort(ErrServiceUnavailable).WithField("shed_level", level)
+			}
+
+			start := time.Now()
+			err := next(c)
+			s.observe(time.Since(start))
+			return err
+		}
+
+		return WrapHandler(HandlerFunc(f))
+	}
+}
+
+type shedder struct {
+	conf LoadShedConfig
+
+	level    int32
+	lastEval int64 // unix nano
+
+	mu      sync.Mutex
+	samples []time.Duration // 环形缓冲
+	next    int
+	fresh   int // 上次评估之后新增的样本数
+}
+
+func (s *shedder) observe(d time.Duration) {
+	s.mu.Lock()
+	if len(s.samples) < s.conf.Window {
+		s.samples = append(s.samples, d)
+	} else {
+		s.samples[s.next] = d
+		s.next = (s.next + 1) % s.conf.Window
+	}
+	s.fresh++
+	s.mu.Unlock()
+}
+
+// percentile 返回耗时分位，上次评估之后没有新样本时丢弃旧样本并返回 0，
+// 避免请求全部被拒绝后旧的慢样本使拒绝级别无法降低
+func (s *shedder) percentile() time.Duration {
+	s.mu.Lock()
+	if s.fresh == 0 {
+		s.samples, s.next = s.samples[:0], 0
+	}
+	s.fresh = 0
+	sorted := append([]time.Duration(nil), s.samples...)
+	s.mu.Unlock()
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[int(float64(len(sorted)-1)*s.conf.Percentile)]
+}
+
+func (s *shedder) overloaded() bool {
+	if s.conf.Latency > 0 && s.percentile() > s.conf.Latency {
+		return true
+	}
+	if s.conf.MaxGoroutines > 0 && runtime.NumGoroutine() > s.conf.MaxGoroutines {
+		return true
+	}
+	return s.conf.Overloaded != nil && s.conf.Overloaded()
+}
+
+// evaluate 到达评估间隔时调整拒绝级别（同一间隔只有一个请求执行评估），返回当前级别
+func (s *shedder) evaluate(now time.Time) int {
+	last := atomic.LoadInt64(&s.lastEval)
+	if now.UnixNano()-last >= int64(s.conf.Interval) &&
+		atomic.CompareAndSwapInt64(&s.lastEval, last, now.UnixNano()) {
+		level := atomic.LoadInt32(&s.level)
+		if s.overloaded() {
+			if level < int32(s.conf.MaxLevel) {
+				atomic.StoreInt32(&s.level, level+1)
+			}
+		} else if level > 0 {
+			atomic.StoreInt32(&s.level, level-1)
+		}
+	}
+	return int(atomic.LoadInt32(&s.level))
+}
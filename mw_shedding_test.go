@@ -0,0 +1,83 @@
+package uecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadShedLatency(t *testing.T) {
+	ue := New(nil)
+	ue.Use(LoadShed(LoadShedConfig{Latency: 5 * time.Millisecond, Interval: 20 * time.Millisecond}))
+	ue.GET("/slow", HandlerFunc(func(c *Context) error {
+		time.Sleep(15 * time.Millisecond)
+		return c.NoContent(http.StatusNoContent)
+	}))
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+		return rec
+	}
+
+	for i := 0; i < 2; i++ {
+		if rec := get(); rec.Code != http.StatusNoContent {
+			t.Fatalf("warm up: status = %d", rec.Code)
+		}
+	}
+
+	// 耗时超过阈值，拒绝级别升高
+	time.Sleep(25 * time.Millisecond)
+	rec := get()
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get(HeaderRetryAfter) != "1" {
+		t.Fatalf("overloaded: status = %d, Retry-After = %q", rec.Code, rec.Header().Get(HeaderRetryAfter))
+	}
+
+	// 请求全部被拒绝，没有新样本，下一个间隔恢复
+	time.Sleep(25 * time.Millisecond)
+	if rec := get(); rec.Code != http.StatusNoContent {
+		t.Fatalf("recovered: status = %d", rec.Code)
+	}
+}
+
+func TestLoadShedPriority(t *testing.T) {
+	var overloaded int32 = 1
+	ue := New(nil)
+	ue.Use(LoadShed(LoadShedConfig{
+		Overloaded: func() bool { return atomic.LoadInt32(&overloaded) == 1 },
+		Interval:   20 * time.Millisecond,
+		MaxLevel:   2,
+	}))
+	ok := HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	ue.GET("/low", ok)
+	ue.GET("/normal", ok).Priority(1)
+	ue.GET("/vip", ok).Priority(2)
+	status := func(path string) int {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	// 每个间隔最多升高一级
+	if status("/low") != http.StatusServiceUnavailable || status("/normal") != http.StatusNoContent {
+		t.Fatal("level 1: expected only priority 0 to be shed")
+	}
+	time.Sleep(25 * time.Millisecond)
+	if status("/normal") != http.StatusServiceUnavailable || status("/vip") != http.StatusNoContent {
+		t.Fatal("level 2: expected priority < 2 to be shed")
+	}
+
+	// 恢复后逐级降低
+	atomic.StoreInt32(&overloaded, 0)
+	time.Sleep(25 * time.Millisecond)
+	if status("/normal") != http.StatusNoContent || status("/low") != http.StatusServiceUnavailable {
+		t.Fatal("level 1 after recovery")
+	}
+	time.Sleep(25 * time.Millisecond)
+	if status("/low") != http.StatusNoContent {
+		t.Fatal("level 0 after recovery")
+	}
+}
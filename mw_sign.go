@@ -0,0 +1,283 @@
+package uecho
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"hash"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// HeaderDigest 响应体摘要（RFC 3230），格式为 SHA-256=<base64>
+const HeaderDigest = "Digest"
+
+// signStatus 签名字符串中表示响应状态码的伪头部
+const signStatus = "(status)"
+
+// ResponseSigner 响应签名算法
+type ResponseSigner interface {
+	// Algorithm 算法名称，例如 hmac-sha256、ed25519
+	Algorithm() string
+	Sign(data []byte) ([]byte, error)
+}
+
+// ResponseVerifier 校验响应签名，见 VerifyResponse
+type ResponseVerifier interface {
+	Verify(data, signature []byte) bool
+}
+
+// HMACSigner HMAC 签名，同时用于校验
+type HMACSigner struct {
+	Key []byte
+
+	// Hash Optional. Default value sha256.New.
+	Hash func() hash.Hash
+
+	// Name 算法名称
+	// Optional. Default value "hmac-sha256".
+	Name string
+}
+
+func (s HMACSigner) Algorithm() string {
+	if s.Name == "" {
+		return "hmac-sha256"
+	}
+	return s.Name
+}
+
+func (s HMACSigner) Sign(data []byte) ([]byte, error) {
+	h := s.Hash
+	if h == nil {
+		h = sha256.New
+	}
+	mac := hmac.New(h, s.Key)
+	mac.Write(data)
+	return mac.Sum(nil), nil
+}
+
+func (s HMACSigner) Verify(data, signature []byte) bool {
+	expected, _ := s.Sign(data)
+	return hmac.Equal(expected, signature)
+}
+
+// Ed25519Signer Ed25519 签名，消费方以公钥（Ed25519Verifier）校验
+type Ed25519Signer ed25519.PrivateKey
+
+func (s Ed25519Signer) Algorithm() string {
+	return "ed25519"
+}
+
+func (s Ed25519Signer) Sign(data []byte) ([]byte, error) {
+	return ed25519.Sign(ed25519.PrivateKey(s), data), nil
+}
+
+// Ed25519Verifier Ed25519 签名校验
+type Ed25519Verifier ed25519.PublicKey
+
+func (v Ed25519Verifier) Verify(data, signature []byte) bool {
+	return ed25519.Verify(ed25519.PublicKey(v), data, signature)
+}
+
+type SignResponseConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// Signer 签名算法
+	// Required.
+	Signer ResponseSigner
+
+	// KeyID 密钥标识，写入签名头供消费方选择密钥
+	// Optional.
+	KeyID string
+
+	// Headers 参与签名的响应头，Digest 总是参与签名
+	// Optional. Default value []string{"Content-Type"}.
+	Headers []string
+
+	// SignatureHeader 携带签名的响应头
+	// Optional. Default value "X-Signature".
+	SignatureHeader string
+}
+
+// SignResponse 响应签名中间键，见 SignResponseWithConfig
+func SignResponse(signer ResponseSigner) echo.MiddlewareFunc {
+	return SignResponseWithConfig(SignResponseConfig{Signer: signer})
+}
+
+// SignResponseWithConfig 响应签名中间键：缓冲完整响应（包括异常响应），输出 Digest 响应头，
+// 并对状态码、选定的响应头及 Digest 签名，签名头格式为
+//
+//	X-Signature: keyId="k1",algorithm="hmac-sha256",headers="(status) content-type digest",signature="<base64>"
+//
+// 签名字符串为按 headers 顺序的 "name: value" 行，以 "\n" 连接，消费方可通过 VerifyResponse 校验
+// 响应被完整缓冲，SSE、WebSocket 等流式响应应通过 Skipper 跳过
+func SignResponseWithConfig(conf SignResponseConfig) echo.MiddlewareFunc {
+	if conf.Signer == nil {
+		panic("uecho: sign response middleware requires a signer")
+	}
+	if conf.Headers == nil {
+		conf.Headers = []string{echo.HeaderContentType}
+	}
+	if conf.SignatureHeader == "" {
+		conf.SignatureHeader = "X-Signature"
+	}
+	names := []string{signStatus}
+	for _, h := range conf.Headers {
+		if !strings.EqualFold(h, HeaderDigest) {
+			names = append(names, strings.ToLower(h))
+		}
+	}
+	names = append(names, strings.ToLower(HeaderDigest))
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) (err error) {
+			if conf.Skipper != nil && conf.Skipper(c) {
+				return next(c)
+			}
+
+			res := c.Response()
+			sw := &signWriter{ResponseWriter: res.Writer}
+			res.Writer = sw
+			defer func() {
+				res.Writer = sw.ResponseWriter
+			}()
+
+			if err = next(c); err != nil {
+				c.Error(err)
+			}
+			res.Writer = sw.ResponseWriter
+			if sw.code == 0 {
+				return err
+			}
+
+			body := sw.buf.Bytes()
+			header := res.Header()
+			sum := sha256.Sum256(body)
+			header.Set(HeaderDigest, "SHA-256="+base64.StdEncoding.EncodeToString(sum[:]))
+			sig, serr := conf.Signer.Sign(signingString(names, sw.code, header))
+			if serr != nil {
+				c.Logrus().WithError(serr).Error("sign response failed")
+			} else {
+				header.Set(conf.SignatureHeader, formatSignature(conf.KeyID, conf.Signer.Algorithm(), names, sig))
+			}
+			if header.Get(echo.HeaderContentLength) == "" {
+				header.Set(echo.HeaderContentLength, strconv.Itoa(len(body)))
+			}
+
+			sw.ResponseWriter.WriteHeader(sw.code)
+			if _, werr := sw.ResponseWriter.Write(body); werr != nil && err == nil {
+				err = werr
+			}
+			return err
+		}
+		return WrapHandler(HandlerFunc(f))
+	}
+}
+
+// signWriter 缓冲响应状态码及响应体
+type signWriter struct {
+	http.ResponseWriter
+	code int
+	buf  bytes.Buffer
+}
+
+func (w *signWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *signWriter) Write(p []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.buf.Write(p)
+}
+
+// Flush 响应被完整缓冲，Flush 不做任何处理
+func (w *signWriter) Flush() {}
+
+func (w *signWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("uecho: response writer does not support hijacking")
+}
+
+// signingString 按 names 顺序生成签名字符串
+func signingString(names []string, status int, header http.Header) []byte {
+	var b bytes.Buffer
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(name)
+		b.WriteString(": ")
+		if name == signStatus {
+			b.WriteString(strconv.Itoa(status))
+		} else {
+			b.WriteString(header.Get(name))
+		}
+	}
+	return b.Bytes()
+}
+
+func formatSignature(keyID, algorithm string, names []string, sig []byte) string {
+	var b strings.Builder
+	if keyID != "" {
+		b.WriteString(`keyId="` + keyID + `",`)
+	}
+	b.WriteString(`algorithm="` + algorithm + `",headers="` + strings.Join(names, " ") + `",signature="`)
+	b.WriteString(base64.StdEncoding.EncodeToString(sig) + `"`)
+	return b.String()
+}
+
+// parseSignature 解析签名头的 key="value" 参数
+func parseSignature(s string) map[string]string {
+	params := make(map[string]string)
+	for _, part := range strings.Split(s, ",") {
+		i := strings.IndexByte(part, '=')
+		if i < 0 {
+			continue
+		}
+		params[strings.TrimSpace(part[:i])] = strings.Trim(strings.TrimSpace(part[i+1:]), `"`)
+	}
+	return params
+}
+
+// ErrResponseSignature 响应签名校验失败
+var ErrResponseSignature = errors.New("uecho: response signature mismatch")
+
+// VerifyResponse 校验 SignResponse 签名的响应，signatureHeader 为空时使用 "X-Signature"
+func VerifyResponse(status int, header http.Header, body []byte, signatureHeader string, verifier ResponseVerifier) error {
+	if signatureHeader == "" {
+		signatureHeader = "X-Signature"
+	}
+	sum := sha256.Sum256(body)
+	if header.Get(HeaderDigest) != "SHA-256="+base64.StdEncoding.EncodeToString(sum[:]) {
+		return ErrResponseSignature
+	}
+	params := parseSignature(header.Get(signatureHeader))
+	sig, err := base64.StdEncoding.DecodeString(params["signature"])
+	if err != nil || params["headers"] == "" {
+		return ErrResponseSignature
+	}
+	names := strings.Split(params["headers"], " ")
+	if names[len(names)-1] != strings.ToLower(HeaderDigest) {
+		return ErrResponseSignature
+	}
+	if !verifier.Verify(signingString(names, status, header), sig) {
+		return ErrResponseSignature
+	}
+	return nil
+}
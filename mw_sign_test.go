@@ -0,0 +1,52 @@
+package uecho
+
+import (
+	"crypto/ed25519"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSignResponse(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hmacSigner := HMACSigner{Key: []byte("secret")}
+
+	for _, tc := range []struct {
+		signer   ResponseSigner
+		verifier ResponseVerifier
+	}{
+		{hmacSigner, hmacSigner},
+		{Ed25519Signer(priv), Ed25519Verifier(pub)},
+	} {
+		ue := New(nil)
+		ue.Use(SignResponseWithConfig(SignResponseConfig{Signer: tc.signer, KeyID: "k1"}))
+		ue.GET("/ok", HandlerFunc(func(c *Context) error {
+			return c.JSON(http.StatusOK, map[string]string{"hello": "world"})
+		}))
+		ue.GET("/fail", HandlerFunc(func(c *Context) error {
+			return c.Abort(ErrForbidden)
+		}))
+
+		for _, path := range []string{"/ok", "/fail"} {
+			rec := httptest.NewRecorder()
+			ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			sig := rec.Header().Get("X-Signature")
+			if !strings.Contains(sig, `keyId="k1"`) || !strings.Contains(sig, `headers="(status) content-type digest"`) {
+				t.Errorf("%s %s: signature header %q", tc.signer.Algorithm(), path, sig)
+			}
+			if err := VerifyResponse(rec.Code, rec.Header(), rec.Body.Bytes(), "", tc.verifier); err != nil {
+				t.Errorf("%s %s: %v", tc.signer.Algorithm(), path, err)
+			}
+			if err := VerifyResponse(rec.Code, rec.Header(), append(rec.Body.Bytes(), ' '), "", tc.verifier); err == nil {
+				t.Errorf("%s %s: tampered body verified", tc.signer.Algorithm(), path)
+			}
+			if err := VerifyResponse(http.StatusTeapot, rec.Header(), rec.Body.Bytes(), "", tc.verifier); err == nil {
+				t.Errorf("%s %s: tampered status verified", tc.signer.Algorithm(), path)
+			}
+		}
+	}
+}
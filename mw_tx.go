@@ -0,0 +1,149 @@
+package uecho
+
+import (
+	"database/sql"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// MetaTx 路由开启事务的元数据 key，值为 true 或传给 TxProvider 的事务选项（例如 *sql.TxOptions）
+const MetaTx = "tx"
+
+const txKey = "_uecho_tx"
+
+// Tx 事务
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// TxProvider 开启事务，opts 为路由元数据中 MetaTx 的值
+type TxProvider interface {
+	Begin(c *Context, opts interface{}) (Tx, error)
+}
+
+// TxProviderFunc TxProvider 的函数形式
+type TxProviderFunc func(c *Context, opts interface{}) (Tx, error)
+
+func (f TxProviderFunc) Begin(c *Context, opts interface{}) (Tx, error) {
+	return f(c, opts)
+}
+
+// SQLTxProvider 以 database/sql 开启事务，事务继承请求的 context（包括路由的处理时限）
+// 路由元数据 MetaTx 的值为 *sql.TxOptions 时作为事务选项
+type SQLTxProvider struct {
+	DB *sql.DB
+}
+
+func (p SQLTxProvider) Begin(c *Context, opts interface{}) (Tx, error) {
+	txOpts, _ := opts.(*sql.TxOptions)
+	return p.DB.BeginTx(c.RequestContext(), txOpts)
+}
+
+// Transactional 路由开启事务（见 Transaction 中间键），opts 为可选的事务选项
+func (r *Route) Transactional(opts ...interface{}) *Route {
+	if len(opts) > 0 {
+		return r.Meta(MetaTx, opts[0])
+	}
+	return r.Meta(MetaTx, true)
+}
+
+type TxConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// Provider 开启事务
+	// Required.
+	Provider TxProvider
+
+	// MetaKey 路由元数据中开启事务的 key
+	// Optional. Default value MetaTx.
+	MetaKey string
+}
+
+// Transaction 事务中间键，见 TransactionWithConfig
+func Transaction(provider TxProvider) echo.MiddlewareFunc {
+	return TransactionWithConfig(TxConfig{Provider: provider})
+}
+
+// TransactionWithConfig 事务中间键：路由元数据声明了 MetaTx 的请求，处理前开启事务（c.Tx() 获取），
+// 处理函数返回 nil 时提交，返回错误（errReply 等）或 panic 时回滚，panic 时丢弃已缓冲的响应
+// 处理期间的响应被缓冲，提交成功后才写出，提交失败时丢弃并返回 500；
+// 调用过 Flush 的流式响应已直接输出，提交失败时只记录日志
+func TransactionWithConfig(conf TxConfig) echo.MiddlewareFunc {
+	if conf.Provider == nil {
+		panic("uecho: transaction middleware requires a provider")
+	}
+	if conf.MetaKey == "" {
+		conf.MetaKey = MetaTx
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) (err error) {
+			if conf.Skipper != nil && conf.Skipper(c) {
+				return next(c)
+			}
+			opts, ok := c.Route().GetMeta(conf.MetaKey)
+			if !ok || opts == false {
+				return next(c)
+			}
+
+			tx, err := conf.Provider.Begin(c, opts)
+			if err != nil {
+				return c.Abort(ErrInternal).WithErr(err).WithField("tx", "begin")
+			}
+			c.Set(txKey, tx)
+
+			res := c.Response()
+			bw := &bufferWriter{ResponseWriter: res.Writer}
+			res.Writer = bw
+			committed := false
+			defer func() {
+				res.Writer = bw.ResponseWriter
+				if committed {
+					return
+				}
+				// panic 时丢弃已缓冲的响应，Recover 才能写出异常响应
+				if !bw.streaming {
+					bw.discard(res)
+				}
+				if rerr := tx.Rollback(); rerr != nil {
+					c.Logrus().WithError(rerr).Warn("transaction rollback failed")
+				}
+			}()
+
+			if err = next(c); err != nil {
+				res.Writer = bw.ResponseWriter
+				bw.flush()
+				return err
+			}
+
+			committed = true
+			if cerr := tx.Commit(); cerr != nil {
+				res.Writer = bw.ResponseWriter
+				if !bw.streaming {
+					bw.discard(res)
+					return c.Abort(ErrInternal).WithErr(cerr).WithField("tx", "commit")
+				}
+				c.Logrus().WithError(cerr).Error("transaction commit failed after the response was written")
+				return nil
+			}
+			res.Writer = bw.ResponseWriter
+			return bw.flush()
+		}
+		return WrapHandler(HandlerFunc(f))
+	}
+}
+
+// Tx 返回 Transaction 中间键为本次请求开启的事务，路由未开启事务时返回 nil
+func (c *Context) Tx() Tx {
+	tx, _ := c.Get(txKey).(Tx)
+	return tx
+}
+
+// SQLTx 返回 SQLTxProvider 开启的 *sql.Tx，路由未开启事务或不是 *sql.Tx 时返回 nil
+func (c *Context) SQLTx() *sql.Tx {
+	tx, _ := c.Tx().(*sql.Tx)
+	return tx
+}
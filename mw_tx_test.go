@@ -0,0 +1,100 @@
+package uecho
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fakeTx struct {
+	opts      interface{}
+	commitErr error
+	committed bool
+	rolled    bool
+}
+
+func (tx *fakeTx) Commit() error   { tx.committed = true; return tx.commitErr }
+func (tx *fakeTx) Rollback() error { tx.rolled = true; return nil }
+
+func TestTransaction(t *testing.T) {
+	var txs []*fakeTx
+	ue := New(nil)
+	ue.Use(Recover(), Transaction(TxProviderFunc(func(c *Context, opts interface{}) (Tx, error) {
+		tx := &fakeTx{opts: opts}
+		txs = append(txs, tx)
+		return tx, nil
+	})))
+	ue.GET("/ok", HandlerFunc(func(c *Context) error {
+		if c.Tx() == nil {
+			t.Error("c.Tx() is nil")
+		}
+		return c.NoContent(http.StatusNoContent)
+	})).Transactional("serializable")
+	ue.GET("/fail", HandlerFunc(func(c *Context) error {
+		return c.Abort(ErrForbidden)
+	})).Transactional()
+	ue.GET("/panic", HandlerFunc(func(c *Context) error {
+		panic("boom")
+	})).Transactional()
+	ue.GET("/plain", HandlerFunc(func(c *Context) error {
+		if c.Tx() != nil {
+			t.Error("c.Tx() is not nil")
+		}
+		return c.NoContent(http.StatusNoContent)
+	}))
+
+	for _, path := range []string{"/ok", "/fail", "/panic", "/plain"} {
+		ue.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	if len(txs) != 3 {
+		t.Fatalf("got %d transactions, want 3", len(txs))
+	}
+	if tx := txs[0]; !tx.committed || tx.rolled || tx.opts != "serializable" {
+		t.Errorf("/ok: %+v", tx)
+	}
+	for i, path := range []string{"/fail", "/panic"} {
+		if tx := txs[i+1]; tx.committed || !tx.rolled || tx.opts != true {
+			t.Errorf("%s: %+v", path, tx)
+		}
+	}
+}
+
+func TestTransactionCommitFailed(t *testing.T) {
+	ue := New(nil)
+	ue.Use(Transaction(TxProviderFunc(func(c *Context, opts interface{}) (Tx, error) {
+		return &fakeTx{commitErr: errors.New("serialization failure")}, nil
+	})))
+	ue.POST("/orders", HandlerFunc(func(c *Context) error {
+		c.SetRespHeader("X-Order", "1")
+		return c.SetPayload(OK.WithData("created"))
+	})).Transactional()
+
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", nil))
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "created") {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTransactionWriteThenPanic(t *testing.T) {
+	tx := &fakeTx{}
+	ue := New(nil)
+	ue.Use(Recover(), Transaction(TxProviderFunc(func(c *Context, opts interface{}) (Tx, error) {
+		return tx, nil
+	})))
+	ue.POST("/orders", HandlerFunc(func(c *Context) error {
+		c.SetPayload(OK.WithData("created"))
+		panic("boom")
+	})).Transactional()
+
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", nil))
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "created") || rec.Body.Len() == 0 {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if tx.committed || !tx.rolled {
+		t.Errorf("tx = %+v", tx)
+	}
+}
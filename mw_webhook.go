@@ -0,0 +1,348 @@
+package uecho
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"hash"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// webhookPayloadKey 校验（解密）后的回调消息在 Context 中的 key
+const webhookPayloadKey = "uecho.webhook_payload"
+
+// WebhookPayload 返回经 WeChat/Webhook 中间键校验（安全模式下为解密后）的消息体
+func (c *Context) WebhookPayload() []byte {
+	payload, _ := c.Get(webhookPayloadKey).([]byte)
+	return payload
+}
+
+type WeChatConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// Token 公众号/企业微信后台配置的 Token
+	// Required.
+	Token string
+
+	// EncodingAESKey 消息加解密密钥（43 位），设置后支持安全模式（encrypt_type=aes）
+	// Optional.
+	EncodingAESKey string
+
+	// AppID 安全模式下校验解密后消息中的 AppID（企业微信为 CorpID），为空时不校验
+	// Optional.
+	AppID string
+
+	// MaxBodySize 回调消息体的最大长度
+	// Optional. Default value 1MB.
+	MaxBodySize int64
+
+	// Replay 不为 nil 时以 timestamp、nonce 参数进行重放保护，重放的回调返回 ErrReplayed
+	// 微信会以相同的 timestamp、nonce 重试失败或超时的回调：处理失败时 nonce 被释放（Store 需实现 NonceReleaser），
+	// 重试可以正常处理；Store 未实现 NonceReleaser 时失败后的重试会被拒绝
+	// Optional.
+	Replay *ReplayGuard
+}
+
+// WeChat 微信回调签名校验中间键（明文模式）
+func WeChat(token string) echo.MiddlewareFunc {
+	return WeChatWithConfig(WeChatConfig{Token: token})
+}
+
+// WeChatWithConfig 微信回调签名校验中间键
+// GET 请求（服务器地址验证）校验通过后直接返回 echostr，POST 请求校验通过后通过 c.WebhookPayload() 获取消息
+func WeChatWithConfig(conf WeChatConfig) echo.MiddlewareFunc {
+	if conf.Token == "" {
+		panic("uecho: wechat middleware requires a token")
+	}
+	if conf.MaxBodySize <= 0 {
+		conf.MaxBodySize = 1 << 20
+	}
+	var aesKey []byte
+	if conf.EncodingAESKey != "" {
+		var err error
+		if aesKey, err = base64.StdEncoding.DecodeString(conf.EncodingAESKey + "="); err != nil || len(aesKey) != 32 {
+			panic("uecho: invalid wechat EncodingAESKey")
+		}
+	}
+
+	checkReplay := func(c *Context, timestamp, nonce string) error {
+		if conf.Replay == nil {
+			return nil
+		}
+		return conf.Replay.Check(c, timestamp, nonce)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) error {
+			if conf.Skipper != nil && conf.Skipper(c) {
+				return next(c)
+			}
+
+			timestamp, nonce := c.QueryParam("timestamp"), c.QueryParam("nonce")
+			msgSignature := c.QueryParam("msg_signature")
+			encrypted := aesKey != nil && (c.QueryParam("encrypt_type") == "aes" || msgSignature != "")
+
+			// 服务器地址验证
+			if echostr := c.QueryParam("echostr"); c.Method() == http.MethodGet && echostr != "" {
+				if !encrypted {
+					if !wechatSignatureEqual(c.QueryParam("signature"), conf.Token, timestamp, nonce) {
+						return c.Abort(ErrSignatureMismatch)
+					}
+					if err := checkReplay(c, timestamp, nonce); err != nil {
+						return err
+					}
+					return c.String(http.StatusOK, echostr)
+				}
+				if !wechatSignatureEqual(msgSignature, conf.Token, timestamp, nonce, echostr) {
+					return c.Abort(ErrSignatureMismatch)
+				}
+				if err := checkReplay(c, timestamp, nonce); err != nil {
+					return err
+				}
+				msg, err := wechatDecrypt(aesKey, echostr, conf.AppID)
+				if err != nil {
+					return c.Abort(ErrDecryptFailed).WithErr(err)
+				}
+				return c.String(http.StatusOK, string(msg))
+			}
+
+			body, err := readWebhookBody(c, conf.MaxBodySize)
+			if err != nil {
+				return err
+			}
+
+			if !encrypted {
+				if !wechatSignatureEqual(c.QueryParam("signature"), conf.Token, timestamp, nonce) {
+					return c.Abort(ErrSignatureMismatch)
+				}
+				if err := checkReplay(c, timestamp, nonce); err != nil {
+					return err
+				}
+				c.Set(webhookPayloadKey, body)
+				return nextReleasingNonce(c, next, conf.Replay, nonce)
+			}
+
+			var envelope struct {
+				Encrypt string `xml:"Encrypt"`
+			}
+			if err := xml.Unmarshal(body, &envelope); err != nil || envelope.Encrypt == "" {
+				return c.Abort(ErrDecryptFailed).WithErr(err)
+			}
+			if !wechatSignatureEqual(msgSignature, conf.Token, timestamp, nonce, envelope.Encrypt) {
+				return c.Abort(ErrSignatureMismatch)
+			}
+			if err := checkReplay(c, timestamp, nonce); err != nil {
+				return err
+			}
+			msg, err := wechatDecrypt(aesKey, envelope.Encrypt, conf.AppID)
+			if err != nil {
+				return c.Abort(ErrDecryptFailed).WithErr(err)
+			}
+			c.Set(webhookPayloadKey, msg)
+			return nextReleasingNonce(c, next, conf.Replay, nonce)
+		}
+
+		return WrapHandler(HandlerFunc(f))
+	}
+}
+
+// wechatSignature 微信签名：参数字典序排序后拼接，取 sha1
+func wechatSignature(params ...string) string {
+	sorted := append([]string{}, params...)
+	sort.Strings(sorted)
+	sum := sha1.Sum([]byte(strings.Join(sorted, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+func wechatSignatureEqual(signature string, params ...string) bool {
+	expected := wechatSignature(params...)
+	return subtle.ConstantTimeCompare([]byte(signature), []byte(expected)) == 1
+}
+
+// wechatDecrypt 解密安全模式消息
+// 明文格式：random(16B) + msg_len(4B, 大端) + msg + appid
+func wechatDecrypt(key []byte, encrypted, appID string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+		return nil, errors.New("wechat: invalid ciphertext length")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, key[:aes.BlockSize]).CryptBlocks(plain, ciphertext)
+
+	// PKCS#7，微信使用 32 字节块
+	pad := int(plain[len(plain)-1])
+	if pad < 1 || pad > 32 || pad > len(plain) {
+		return nil, errors.New("wechat: invalid padding")
+	}
+	plain = plain[:len(plain)-pad]
+	if len(plain) < 20 {
+		return nil, errors.New("wechat: invalid plaintext length")
+	}
+
+	content := plain[16:]
+	msgLen := int(binary.BigEndian.Uint32(content[:4]))
+	if msgLen > len(content)-4 {
+		return nil, errors.New("wechat: invalid message length")
+	}
+	msg := content[4 : 4+msgLen]
+	if appID != "" && !bytes.Equal(content[4+msgLen:], []byte(appID)) {
+		return nil, errors.New("wechat: appid mismatch")
+	}
+	return msg, nil
+}
+
+type WebhookConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// Secret HMAC 密钥
+	// Required.
+	Secret []byte
+
+	// Header 携带签名的请求头
+	// Optional. Default value "X-Signature".
+	Header string
+
+	// Prefix 签名值的前缀，例如 GitHub 的 "sha256="
+	// Optional.
+	Prefix string
+
+	// Hash HMAC 使用的哈希算法
+	// Optional. Default value sha256.New.
+	Hash func() hash.Hash
+
+	// MaxBodySize 回调消息体的最大长度
+	// Optional. Default value 1MB.
+	MaxBodySize int64
+
+	// Replay 不为 nil 时开启重放保护：时间戳（Unix 秒）与 nonce 分别从 TimestampHeader、NonceHeader 读取，
+	// 签名改为 "timestamp.nonce.body" 的 HMAC，重放的请求返回 ErrReplayed；处理失败时释放 nonce（见 ReplayGuard.Release）
+	// Optional.
+	Replay *ReplayGuard
+
+	// TimestampHeader 携带时间戳的请求头
+	// Optional. Default value "X-Timestamp".
+	TimestampHeader string
+
+	// NonceHeader 携带 nonce 的请求头
+	// Optional. Default value "X-Nonce".
+	NonceHeader string
+}
+
+// Webhook 通用 webhook 签名校验中间键，签名为请求体的 HMAC-SHA256（hex）
+func Webhook(secret string) echo.MiddlewareFunc {
+	return WebhookWithConfig(WebhookConfig{Secret: []byte(secret)})
+}
+
+// WebhookWithConfig 通用 webhook 签名校验中间键，校验通过后通过 c.WebhookPayload() 获取请求体
+func WebhookWithConfig(conf WebhookConfig) echo.MiddlewareFunc {
+	if len(conf.Secret) == 0 {
+		panic("uecho: webhook middleware requires a secret")
+	}
+	if conf.Header == "" {
+		conf.Header = "X-Signature"
+	}
+	if conf.Hash == nil {
+		conf.Hash = sha256.New
+	}
+	if conf.MaxBodySize <= 0 {
+		conf.MaxBodySize = 1 << 20
+	}
+	if conf.TimestampHeader == "" {
+		conf.TimestampHeader = "X-Timestamp"
+	}
+	if conf.NonceHeader == "" {
+		conf.NonceHeader = "X-Nonce"
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) error {
+			if conf.Skipper != nil && conf.Skipper(c) {
+				return next(c)
+			}
+
+			body, err := readWebhookBody(c, conf.MaxBodySize)
+			if err != nil {
+				return err
+			}
+
+			signature := c.GetHeader(conf.Header)
+			if !strings.HasPrefix(signature, conf.Prefix) {
+				return c.Abort(ErrSignatureMismatch)
+			}
+			got, err := hex.DecodeString(strings.TrimPrefix(signature, conf.Prefix))
+			if err != nil {
+				return c.Abort(ErrSignatureMismatch)
+			}
+
+			mac := hmac.New(conf.Hash, conf.Secret)
+			var timestamp, nonce string
+			if conf.Replay != nil {
+				timestamp, nonce = c.GetHeader(conf.TimestampHeader), c.GetHeader(conf.NonceHeader)
+				mac.Write([]byte(timestamp + "." + nonce + "."))
+			}
+			mac.Write(body)
+			if !hmac.Equal(got, mac.Sum(nil)) {
+				return c.Abort(ErrSignatureMismatch)
+			}
+			if conf.Replay != nil {
+				if err := conf.Replay.Check(c, timestamp, nonce); err != nil {
+					return err
+				}
+			}
+
+			c.Set(webhookPayloadKey, body)
+			return nextReleasingNonce(c, next, conf.Replay, nonce)
+		}
+
+		return WrapHandler(HandlerFunc(f))
+	}
+}
+
+// nextReleasingNonce 执行处理函数，失败（返回错误或 5xx 响应）时释放 nonce（见 ReplayGuard.Release），
+// 发送方（例如微信）以相同的 timestamp、nonce 重试失败或超时的回调时不会被当作重放
+func nextReleasingNonce(c *Context, next echo.HandlerFunc, g *ReplayGuard, nonce string) error {
+	err := next(c)
+	if g != nil && (err != nil || c.Response().Status >= http.StatusInternalServerError) {
+		if rerr := g.Release(nonce); rerr != nil {
+			c.Logrus().WithError(rerr).Warn("webhook: release nonce failed")
+		}
+	}
+	return err
+}
+
+// readWebhookBody 读取回调消息体（并放回 req.Body），读取失败时返回 400，超过 limit 时返回 413
+func readWebhookBody(c *Context, limit int64) ([]byte, error) {
+	body, ok, err := rebufferBody(c.Request(), limit)
+	if err != nil {
+		return nil, c.Abort(ErrIllegalparams).WithErr(err).WithField("webhook", "read body failed")
+	}
+	if !ok {
+		return nil, c.Abort(ErrRequestEntityTooLarge).WithField("webhook", "body exceeds "+strconv.FormatInt(limit, 10)+" bytes")
+	}
+	return body, nil
+}
@@ -0,0 +1,190 @@
+package uecho
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testAESKey = "abcdefghijklmnopqrstuvwxyz0123456789ABCDEFG"
+
+// wechatEncrypt 按微信安全模式加密消息
+func wechatEncrypt(t *testing.T, msg, appID string) string {
+	key, _ := base64.StdEncoding.DecodeString(testAESKey + "=")
+	plain := bytes.NewBufferString("0123456789abcdef")
+	binary.Write(plain, binary.BigEndian, uint32(len(msg)))
+	plain.WriteString(msg + appID)
+	pad := 32 - plain.Len()%32
+	plain.Write(bytes.Repeat([]byte{byte(pad)}, pad))
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciphertext := make([]byte, plain.Len())
+	cipher.NewCBCEncrypter(block, key[:aes.BlockSize]).CryptBlocks(ciphertext, plain.Bytes())
+	return base64.StdEncoding.EncodeToString(ciphertext)
+}
+
+func TestWeChat(t *testing.T) {
+	ue := New(nil)
+	ue.POST("/wechat", HandlerFunc(func(c *Context) error {
+		return c.String(http.StatusOK, string(c.WebhookPayload()))
+	}), WeChatWithConfig(WeChatConfig{Token: "token", EncodingAESKey: testAESKey, AppID: "wx123"}))
+
+	encrypted := wechatEncrypt(t, "<xml>hello</xml>", "wx123")
+	q := url.Values{}
+	q.Set("timestamp", "1600000000")
+	q.Set("nonce", "42")
+	q.Set("encrypt_type", "aes")
+	q.Set("msg_signature", wechatSignature("token", "1600000000", "42", encrypted))
+	body := "<xml><Encrypt><![CDATA[" + encrypted + "]]></Encrypt></xml>"
+
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wechat?"+q.Encode(), strings.NewReader(body)))
+	if rec.Code != http.StatusOK || rec.Body.String() != "<xml>hello</xml>" {
+		t.Fatalf("status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+
+	q.Set("msg_signature", "bad")
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wechat?"+q.Encode(), strings.NewReader(body)))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("bad signature: status = %d", rec.Code)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	ue := New(nil)
+	ue.POST("/hook", HandlerFunc(func(c *Context) error {
+		return c.String(http.StatusOK, string(c.WebhookPayload()))
+	}), WebhookWithConfig(WebhookConfig{Secret: []byte("secret"), Prefix: "sha256="}))
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("payload"))
+	req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader("payload"))
+	req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "payload" {
+		t.Fatalf("status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader("tampered"))
+	req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("tampered: status = %d", rec.Code)
+	}
+}
+
+func TestWebhookBodyTooLarge(t *testing.T) {
+	ue := New(nil)
+	ue.POST("/hook", HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}), WebhookWithConfig(WebhookConfig{Secret: []byte("secret"), MaxBodySize: 4}))
+
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader("payload")))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body)
+	}
+}
+
+func TestWebhookReplay(t *testing.T) {
+	ue := New(nil)
+	ue.POST("/hook", HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}), WebhookWithConfig(WebhookConfig{Secret: []byte("secret"), Replay: &ReplayGuard{Window: time.Minute}}))
+
+	send := func(timestamp, nonce string) *httptest.ResponseRecorder {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(timestamp + "." + nonce + ".payload"))
+		req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader("payload"))
+		req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+		req.Header.Set("X-Timestamp", timestamp)
+		req.Header.Set("X-Nonce", nonce)
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, req)
+		return rec
+	}
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	if rec := send(now, "n1"); rec.Code != http.StatusNoContent {
+		t.Fatalf("first: status = %d, body = %s", rec.Code, rec.Body)
+	}
+	for name, rec := range map[string]*httptest.ResponseRecorder{
+		"duplicate nonce": send(now, "n1"),
+		"expired":         send(strconv.FormatInt(time.Now().Add(-2*time.Minute).Unix(), 10), "n2"),
+		"missing nonce":   send(now, ""),
+	} {
+		if rec.Code != http.StatusForbidden || !strings.Contains(rec.Body.String(), "10303") {
+			t.Errorf("%s: status = %d, body = %s", name, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestWeChatReplay(t *testing.T) {
+	ue := New(nil)
+	ue.POST("/wechat", HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}), WeChatWithConfig(WeChatConfig{Token: "token", Replay: &ReplayGuard{}}))
+
+	q := url.Values{}
+	q.Set("timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+	q.Set("nonce", "42")
+	q.Set("signature", wechatSignature("token", q.Get("timestamp"), "42"))
+	for i, want := range []int{http.StatusNoContent, http.StatusForbidden} {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wechat?"+q.Encode(), strings.NewReader("<xml/>")))
+		if rec.Code != want {
+			t.Errorf("request %d: status = %d, want %d", i, rec.Code, want)
+		}
+	}
+}
+
+func TestWeChatReplayRetryAfterFailure(t *testing.T) {
+	fail := true
+	ue := New(nil)
+	ue.POST("/wechat", HandlerFunc(func(c *Context) error {
+		if fail {
+			return c.Abort(ErrServiceUnavailable)
+		}
+		return c.NoContent(http.StatusNoContent)
+	}), WeChatWithConfig(WeChatConfig{Token: "token", Replay: &ReplayGuard{}}))
+
+	q := url.Values{}
+	q.Set("timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+	q.Set("nonce", "43")
+	q.Set("signature", wechatSignature("token", q.Get("timestamp"), "43"))
+	send := func() int {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wechat?"+q.Encode(), strings.NewReader("<xml/>")))
+		return rec.Code
+	}
+
+	// 处理失败后微信以相同的 timestamp、nonce 重试，重试应被处理而不是当作重放
+	if code := send(); code != http.StatusServiceUnavailable {
+		t.Fatalf("first: status = %d", code)
+	}
+	fail = false
+	if code := send(); code != http.StatusNoContent {
+		t.Fatalf("retry: status = %d", code)
+	}
+	if code := send(); code != http.StatusForbidden {
+		t.Fatalf("replay after success: status = %d", code)
+	}
+}
@@ -0,0 +1,135 @@
+package uecho
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Representation 以某种格式写出 reply，view 为 c.Respond 传入的模板名（可能为空）
+type Representation func(c *Context, reply Reply, view string) error
+
+// Negotiator 根据请求的 Accept 头（含 q 值）在已注册的响应格式中选择一种
+type Negotiator struct {
+	// Default Accept 为空时使用的格式
+	Default string
+
+	// Fallback 为 true 时，没有客户端可接受的格式也使用 Default 响应，而不是返回 406
+	Fallback bool
+
+	types []string
+	reps  map[string]Representation
+}
+
+// NewNegotiator 创建 Negotiator，默认注册 JSON 信封、XML、HTML 模板及纯文本四种格式
+func NewNegotiator() *Negotiator {
+	n := &Negotiator{
+		Default: echo.MIMEApplicationJSON,
+		reps:    make(map[string]Representation),
+	}
+	n.Register(echo.MIMEApplicationJSON, jsonRepresentation)
+	n.Register(echo.MIMEApplicationXML, xmlRepresentation)
+	n.Register(echo.MIMETextXML, xmlRepresentation)
+	n.Register(echo.MIMETextHTML, htmlRepresentation)
+	n.Register(echo.MIMETextPlain, textRepresentation)
+	return n
+}
+
+// Register 注册（或覆盖）一种响应格式，先注册的格式在 q 值相同时优先
+func (n *Negotiator) Register(mediaType string, rep Representation) {
+	if _, ok := n.reps[mediaType]; !ok {
+		n.types = append(n.types, mediaType)
+	}
+	n.reps[mediaType] = rep
+}
+
+// Negotiate 返回 accept 对应的响应格式，html 为 false 时不考虑 text/html
+func (n *Negotiator) Negotiate(accept string, html bool) (string, bool) {
+	if t, ok := negotiate(accept, n.Default, n.types, html); ok || !n.Fallback {
+		return t, ok
+	}
+	return n.Default, true
+}
+
+// negotiate 按 accept 在 types 中选择格式，Accept 为空或 */* 时返回 def
+func negotiate(accept, def string, types []string, html bool) (string, bool) {
+	if accept == "" {
+		return def, true
+	}
+
+	for _, spec := range parseAccept(accept) {
+		if spec.q <= 0 {
+			continue
+		}
+		if spec.mediaType == "*/*" {
+			return def, true
+		}
+		for _, t := range types {
+			if t == echo.MIMETextHTML && !html {
+				continue
+			}
+			if matchMediaType(spec.mediaType, t) {
+				return t, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Respond 按客户端 Accept 选择格式写出 reply，view 为渲染 text/html 时使用的模板名，
+// 不传时不会选择 text/html；没有可接受的格式时返回 406 异常
+// 与 SetPayload 相同，http 状态码大于 400 就当作异常处理；e.Negotiator 为 nil 时以 JSON 信封响应
+func (c *Context) Respond(reply Reply, view ...string) error {
+	if reply.HTTPCode() >= 400 {
+		return c.SetPayload(reply)
+	}
+	n := c.ue.Negotiator
+	if n == nil {
+		return jsonRepresentation(c, reply, "")
+	}
+
+	var name string
+	if len(view) > 0 {
+		name = view[0]
+	}
+
+	c.SetRespHeader(echo.HeaderVary, echo.HeaderAccept)
+	mediaType, ok := n.Negotiate(c.GetHeader(echo.HeaderAccept), name != "")
+	if !ok {
+		return c.Abort(ErrNotAcceptable)
+	}
+	return n.reps[mediaType](c, reply, name)
+}
+
+// newHttpApiResponse JSON 响应，data 按 SetSparseFields 指定的字段过滤
+func newHttpApiResponse(c *Context, r Reply) *HttpApiResponse {
+	p := r.(*reply)
+	return &HttpApiResponse{
+		EC:   p.ec,
+		EM:   c.em(p),
+		Data: sparseData(c, p.data),
+	}
+}
+
+func jsonRepresentation(c *Context, r Reply, _ string) error {
+	return c.JSON(r.HTTPCode(), newHttpApiResponse(c, r))
+}
+
+func xmlRepresentation(c *Context, r Reply, _ string) error {
+	return c.XML(r.HTTPCode(), &HttpApiResponse{EC: r.EC(), EM: c.em(r), Data: r.(*reply).data})
+}
+
+func htmlRepresentation(c *Context, r Reply, view string) error {
+	return c.Render(r.HTTPCode(), view, &ViewData{Data: r.(*reply).data})
+}
+
+func textRepresentation(c *Context, r Reply, _ string) error {
+	if data := r.(*reply).data; data != nil {
+		return c.String(r.HTTPCode(), fmt.Sprint(data))
+	}
+	if em := c.em(r); em != "" {
+		return c.String(r.HTTPCode(), em)
+	}
+	return c.String(r.HTTPCode(), http.StatusText(r.HTTPCode()))
+}
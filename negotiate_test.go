@@ -0,0 +1,95 @@
+package uecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	n := NewNegotiator()
+	cases := []struct {
+		accept string
+		html   bool
+		want   string
+		ok     bool
+	}{
+		{"", false, "application/json", true},
+		{"*/*", false, "application/json", true},
+		{"application/xml", false, "application/xml", true},
+		{"text/html,application/xml;q=0.9", true, "text/html", true},
+		{"text/html,application/xml;q=0.9", false, "application/xml", true},
+		{"text/plain;q=0.5,application/json;q=0.8", false, "application/json", true},
+		{"text/*", false, "text/xml", true},
+		{"image/png", false, "", false},
+	}
+	for _, tc := range cases {
+		got, ok := n.Negotiate(tc.accept, tc.html)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("Negotiate(%q, %v) = %q, %v; want %q, %v", tc.accept, tc.html, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	n.Fallback = true
+	if got, ok := n.Negotiate("image/png", false); got != "application/json" || !ok {
+		t.Errorf("fallback: got %q, %v", got, ok)
+	}
+}
+
+func TestRespondWithoutNegotiator(t *testing.T) {
+	ue := New(nil)
+	ue.Negotiator = nil
+	ue.GET("/", HandlerFunc(func(c *Context) error {
+		return c.Respond(OK.WithData("hi"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/xml")
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") || !strings.Contains(rec.Body.String(), `"data":"hi"`) {
+		t.Fatalf("status = %d, content-type = %q, body = %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+}
+
+func TestErrorRenderers(t *testing.T) {
+	ue := New(nil)
+	ue.RegisterErrorRenderer("application/xml", func(c *Context, r Reply) error {
+		return c.XML(r.HTTPCode(), &HttpApiResponse{EC: r.EC(), EM: r.EM()})
+	})
+	ue.GET("/fail", HandlerFunc(func(c *Context) error {
+		return c.Abort(ErrIllegalparams)
+	}))
+
+	cases := []struct {
+		accept      string
+		contentType string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"text/html,application/xhtml+xml;q=0.9,*/*;q=0.8", "application/json"},
+		{"text/plain", "text/plain"},
+		{"application/xml", "application/xml"},
+		{"image/png", "application/json"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+		req.Header.Set("Accept", tc.accept)
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, req)
+		if rec.Code != ErrIllegalparams.HTTPCode() || !strings.HasPrefix(rec.Header().Get("Content-Type"), tc.contentType) {
+			t.Errorf("Accept %q: status = %d, content type = %q", tc.accept, rec.Code, rec.Header().Get("Content-Type"))
+		}
+	}
+
+	// 配置了 ErrorPage 时浏览器得到 HTML 错误页
+	ue.ErrorPage = &ErrorPageConfig{}
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("error page: content type = %q", ct)
+	}
+}
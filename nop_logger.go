@@ -0,0 +1,19 @@
+package uecho
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NopLogger 不输出任何日志的 logger，用于只依赖指标、链路追踪的服务及基准测试：
+// 作为 New 的参数或 LoggerConfig.Hosts、Groups 中的 logger 时，Logger 中间键不再构建日志字段
+// 其级别为 PanicLevel，不应修改
+var NopLogger = &logrus.Logger{
+	Out:       ioutil.Discard,
+	Formatter: new(logrus.TextFormatter),
+	Hooks:     make(logrus.LevelHooks),
+	Level:     logrus.PanicLevel,
+	ExitFunc:  os.Exit,
+}
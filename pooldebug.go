@@ -0,0 +1,325 @@
+package uecho
+
+import (
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	contextPoolLeaks          = DefaultMetrics.Counter("context_pool_leaks")
+	contextPoolDoubleReleases = DefaultMetrics.Counter("context_pool_double_releases")
+)
+
+type PoolDebugConfig struct {
+	// MaxAge 获取后超过该时间仍未释放（忘记 ReleaseContext、处理函数阻塞等），
+	// 或释放后超过该时间仍被引用（请求结束后仍被 goroutine 等持有）的 Context 视为泄漏
+	// Optional. Default value 30s.
+	MaxAge time.Duration
+
+	// StackDepth 获取、释放 Context 时记录的调用栈帧数
+	// Optional. Default value 32.
+	StackDepth int
+
+	// MaxReports 保留的重复释放记录数
+	// Optional. Default value 100.
+	MaxReports int
+
+	// Logger 输出泄漏、重复释放的 logger
+	// Optional. Default value logrus.StandardLogger().
+	Logger *logrus.Logger
+}
+
+// PoolDebug Context 池调试模式：跟踪 AcquireContext/ReleaseContext 的配对并记录获取、释放时的调用栈，
+// 发现泄漏（见 PoolDebugConfig.MaxAge）及重复释放的 Context 时输出日志，并计入 context_pool_leaks、context_pool_double_releases 指标
+// 开启后释放的 Context 不再放回池中（保证重复释放可以被识别、被持有的 Context 不会影响其他请求），
+// 并通过 finalizer 判断释放后是否仍被引用（检查时可能触发 GC），开销较大，只用于排查问题
+//
+//	ue.PoolDebug = uecho.NewPoolDebug(uecho.PoolDebugConfig{})
+//	admin.GET("/debug/context-pool", ue.PoolDebug.Handler())
+type PoolDebug struct {
+	conf PoolDebugConfig
+
+	mu        sync.Mutex
+	seq       uint64
+	live      map[*Context]*poolRecord
+	retained  map[uint64]*poolRecord // 已释放、尚未被回收
+	doubles   []ContextLeak
+	acquired  int64
+	released  int64
+	nextCheck time.Time
+}
+
+// poolRecord 一次 AcquireContext 的记录
+type poolRecord struct {
+	id           uint64
+	acquiredAt   time.Time
+	acquireStack string
+	method       string
+	path         string
+	releasedAt   time.Time
+	releaseStack string
+	gcChecked    bool // 释放超过 MaxAge 后已触发过 GC
+	reported     bool
+}
+
+// ContextLeak 泄漏或重复释放的 Context
+type ContextLeak struct {
+	ID           uint64        `json:"id"`
+	AcquiredAt   time.Time     `json:"acquired_at"`
+	Age          time.Duration `json:"age"`
+	Method       string        `json:"method,omitempty"`
+	Path         string        `json:"path,omitempty"`
+	AcquireStack string        `json:"acquire_stack"`
+
+	// Retained 已释放，但超过 MaxAge 后仍被引用
+	Retained bool `json:"retained,omitempty"`
+
+	// ReleaseStack 释放的调用栈，重复释放时为第一次释放的调用栈
+	ReleaseStack string `json:"release_stack,omitempty"`
+
+	// DoubleReleaseStack 重复释放时为再次释放的调用栈
+	DoubleReleaseStack string `json:"double_release_stack,omitempty"`
+}
+
+// PoolDebugStats Context 池调试统计
+type PoolDebugStats struct {
+	Acquired       int64         `json:"acquired"`
+	Released       int64         `json:"released"`
+	Live           int           `json:"live"`
+	Leaks          []ContextLeak `json:"leaks"`
+	DoubleReleases []ContextLeak `json:"double_releases"`
+}
+
+// NewPoolDebug 创建 PoolDebug，应在开始处理请求之前设置为 UEcho.PoolDebug
+func NewPoolDebug(conf PoolDebugConfig) *PoolDebug {
+	if conf.MaxAge <= 0 {
+		conf.MaxAge = 30 * time.Second
+	}
+	if conf.StackDepth <= 0 {
+		conf.StackDepth = 32
+	}
+	if conf.MaxReports <= 0 {
+		conf.MaxReports = 100
+	}
+	if conf.Logger == nil {
+		conf.Logger = logrus.StandardLogger()
+	}
+	return &PoolDebug{
+		conf:     conf,
+		live:     make(map[*Context]*poolRecord),
+		retained: make(map[uint64]*poolRecord),
+	}
+}
+
+// callers 返回调用栈，skip 为跳过的调用方层数
+func (d *PoolDebug) callers(skip int) string {
+	pcs := make([]uintptr, d.conf.StackDepth)
+	n := runtime.Callers(skip+2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var b strings.Builder
+	for {
+		frame, more := frames.Next()
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		b.WriteByte('\n')
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+func (d *PoolDebug) acquire(c *Context) {
+	stack := d.callers(2)
+	d.mu.Lock()
+	d.seq++
+	d.acquired++
+	rec := &poolRecord{id: d.seq, acquiredAt: time.Now(), acquireStack: stack}
+	d.live[c] = rec
+	c.poolRecord = rec
+	d.mu.Unlock()
+}
+
+// started 记录请求信息（ServeHTTP 中 Reset 之后）
+func (d *PoolDebug) started(c *Context) {
+	r := c.Request()
+	d.mu.Lock()
+	if rec := c.poolRecord; rec != nil {
+		rec.method, rec.path = r.Method, r.URL.Path
+	}
+	d.mu.Unlock()
+}
+
+// release 返回 false 表示 c 已被释放（或不是从池中获取的），此时不应再次放回池中
+func (d *PoolDebug) release(c *Context) bool {
+	stack := d.callers(2)
+	now := time.Now()
+	d.mu.Lock()
+	rec := c.poolRecord
+	if rec == nil || !rec.releasedAt.IsZero() {
+		leak := ContextLeak{DoubleReleaseStack: stack}
+		if rec != nil {
+			leak.ID, leak.AcquiredAt, leak.Age = rec.id, rec.acquiredAt, now.Sub(rec.acquiredAt)
+			leak.Method, leak.Path = rec.method, rec.path
+			leak.AcquireStack, leak.ReleaseStack = rec.acquireStack, rec.releaseStack
+		}
+		d.doubles = append(d.doubles, leak)
+		if len(d.doubles) > d.conf.MaxReports {
+			d.doubles = d.doubles[len(d.doubles)-d.conf.MaxReports:]
+		}
+		d.mu.Unlock()
+
+		contextPoolDoubleReleases.Inc()
+		d.conf.Logger.WithFields(logrus.Fields{
+			"context_id":     leak.ID,
+			"method":         leak.Method,
+			"path":           leak.Path,
+			"acquire_stack":  leak.AcquireStack,
+			"release_stack":  leak.ReleaseStack,
+			"double_release": leak.DoubleReleaseStack,
+		}).Error("context pool: context released twice")
+		return false
+	}
+	rec.releasedAt, rec.releaseStack = now, stack
+	delete(d.live, c)
+	d.retained[rec.id] = rec
+	d.released++
+	check := now.After(d.nextCheck)
+	if check {
+		d.nextCheck = now.Add(d.conf.MaxAge / 2)
+	}
+	d.mu.Unlock()
+
+	id := rec.id
+	runtime.SetFinalizer(c, func(*Context) { d.collected(id) })
+
+	if check {
+		d.Check()
+	}
+	return true
+}
+
+// collected Context 被回收（finalizer）
+func (d *PoolDebug) collected(id uint64) {
+	d.mu.Lock()
+	delete(d.retained, id)
+	d.mu.Unlock()
+}
+
+func (rec *poolRecord) leak(now time.Time) ContextLeak {
+	return ContextLeak{
+		ID:           rec.id,
+		AcquiredAt:   rec.acquiredAt,
+		Age:          now.Sub(rec.acquiredAt),
+		Method:       rec.method,
+		Path:         rec.path,
+		AcquireStack: rec.acquireStack,
+		Retained:     !rec.releasedAt.IsZero(),
+		ReleaseStack: rec.releaseStack,
+	}
+}
+
+// leaks 返回泄漏的 Context 及释放超过 MaxAge、需要触发 GC 确认是否仍被引用的记录数，调用方需持有 d.mu
+func (d *PoolDebug) leaks(now time.Time) ([]ContextLeak, []*poolRecord, int) {
+	var (
+		leaks   []ContextLeak
+		recs    []*poolRecord
+		pending int
+	)
+	for _, rec := range d.live {
+		if now.Sub(rec.acquiredAt) > d.conf.MaxAge {
+			leaks = append(leaks, rec.leak(now))
+			recs = append(recs, rec)
+		}
+	}
+	for _, rec := range d.retained {
+		if now.Sub(rec.releasedAt) <= d.conf.MaxAge {
+			continue
+		}
+		// 触发过 GC 后仍未被回收才视为仍被引用
+		if !rec.gcChecked {
+			pending++
+			continue
+		}
+		leaks = append(leaks, rec.leak(now))
+		recs = append(recs, rec)
+	}
+	sort.Slice(recs, func(i, j int) bool { return recs[i].id < recs[j].id })
+	sort.Slice(leaks, func(i, j int) bool { return leaks[i].ID < leaks[j].ID })
+	return leaks, recs, pending
+}
+
+// Check 检查泄漏的 Context，新发现的泄漏输出 error 日志，返回全部泄漏
+// 释放后是否仍被引用需要 GC 确认：必要时触发 GC，在之后的检查中报告
+// 释放 Context 时会定期调用，也可以由定时任务调用
+func (d *PoolDebug) Check() []ContextLeak {
+	now := time.Now()
+	d.mu.Lock()
+	leaks, recs, pending := d.leaks(now)
+	var reports []ContextLeak
+	for i, rec := range recs {
+		if !rec.reported {
+			rec.reported = true
+			reports = append(reports, leaks[i])
+		}
+	}
+	if pending > 0 {
+		for _, rec := range d.retained {
+			if now.Sub(rec.releasedAt) > d.conf.MaxAge {
+				rec.gcChecked = true
+			}
+		}
+	}
+	d.mu.Unlock()
+
+	if pending > 0 {
+		runtime.GC()
+	}
+	for _, leak := range reports {
+		contextPoolLeaks.Inc()
+		msg := "context pool: context not released"
+		if leak.Retained {
+			msg = "context pool: context retained after release"
+		}
+		d.conf.Logger.WithFields(logrus.Fields{
+			"context_id":    leak.ID,
+			"method":        leak.Method,
+			"path":          leak.Path,
+			"age":           leak.Age.String(),
+			"acquire_stack": leak.AcquireStack,
+			"release_stack": leak.ReleaseStack,
+		}).Error(msg)
+	}
+	return leaks
+}
+
+// Stats 返回调试统计
+func (d *PoolDebug) Stats() PoolDebugStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	leaks, _, _ := d.leaks(time.Now())
+	return PoolDebugStats{
+		Acquired:       d.acquired,
+		Released:       d.released,
+		Live:           len(d.live),
+		Leaks:          leaks,
+		DoubleReleases: append([]ContextLeak(nil), d.doubles...),
+	}
+}
+
+// Handler 查看 Context 池调试统计的管理接口，应注册在需要管理权限的分组下
+func (d *PoolDebug) Handler() HandlerFunc {
+	return func(c *Context) error {
+		d.Check()
+		return c.SetPayload(OK.WithData(d.Stats()))
+	}
+}
@@ -0,0 +1,104 @@
+package uecho
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newPoolDebugApp(maxAge time.Duration) (*UEcho, *bytes.Buffer) {
+	buf := new(bytes.Buffer)
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	ue := New(nil)
+	ue.PoolDebug = NewPoolDebug(PoolDebugConfig{MaxAge: maxAge, Logger: logger})
+	return ue, buf
+}
+
+func TestPoolDebugDoubleRelease(t *testing.T) {
+	ue, buf := newPoolDebugApp(time.Minute)
+	ue.GET("/", HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}))
+	for i := 0; i < 3; i++ {
+		ue.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	c := ue.AcquireContext()
+	ue.ReleaseContext(c)
+	ue.ReleaseContext(c)
+
+	stats := ue.PoolDebug.Stats()
+	if stats.Acquired != 4 || stats.Released != 4 || stats.Live != 0 || len(stats.Leaks) != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if len(stats.DoubleReleases) != 1 {
+		t.Fatalf("double releases = %d", len(stats.DoubleReleases))
+	}
+	d := stats.DoubleReleases[0]
+	for _, stack := range []string{d.AcquireStack, d.ReleaseStack, d.DoubleReleaseStack} {
+		if !strings.Contains(stack, "TestPoolDebugDoubleRelease") {
+			t.Errorf("stack = %q", stack)
+		}
+	}
+	if !strings.Contains(buf.String(), "context released twice") {
+		t.Errorf("log = %q", buf.String())
+	}
+}
+
+func TestPoolDebugLeaks(t *testing.T) {
+	ue, buf := newPoolDebugApp(10 * time.Millisecond)
+	var held *Context
+	ue.GET("/hold", HandlerFunc(func(c *Context) error {
+		held = c
+		return c.NoContent(http.StatusNoContent)
+	}))
+	ue.GET("/", HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}))
+	ue.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hold", nil))
+	ue.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	unreleased := ue.AcquireContext()
+
+	time.Sleep(20 * time.Millisecond)
+	ue.PoolDebug.Check() // 触发 GC
+	time.Sleep(10 * time.Millisecond)
+	leaks := ue.PoolDebug.Check()
+	if len(leaks) != 2 {
+		t.Fatalf("leaks = %+v", leaks)
+	}
+	if leaks[0].Path != "/hold" || !leaks[0].Retained || leaks[0].ReleaseStack == "" {
+		t.Errorf("retained leak = %+v", leaks[0])
+	}
+	if leaks[1].Retained || !strings.Contains(leaks[1].AcquireStack, "TestPoolDebugLeaks") {
+		t.Errorf("unreleased leak = %+v", leaks[1])
+	}
+	if n := strings.Count(buf.String(), "context not released"); n != 1 {
+		t.Errorf("not released logged %d times", n)
+	}
+	if n := strings.Count(buf.String(), "context retained after release"); n != 1 {
+		t.Errorf("retained logged %d times", n)
+	}
+
+	admin := httptest.NewRecorder()
+	ue.GET("/debug/context-pool", ue.PoolDebug.Handler())
+	ue.ServeHTTP(admin, httptest.NewRequest(http.MethodGet, "/debug/context-pool", nil))
+	var resp struct {
+		Data PoolDebugStats `json:"data"`
+	}
+	if err := json.Unmarshal(admin.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data.Leaks) != 2 || resp.Data.Live != 2 {
+		t.Errorf("admin stats = %+v", resp.Data)
+	}
+
+	ue.ReleaseContext(unreleased)
+	_ = held
+}
@@ -0,0 +1,65 @@
+package uecho
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	// MIMEApplicationProblemJSON RFC 7807 problem details
+	MIMEApplicationProblemJSON = "application/problem+json"
+
+	// MetaProblemJSON 路由异常以 application/problem+json 输出的元数据 key
+	MetaProblemJSON = "problem_json"
+)
+
+// ProblemDetails RFC 7807 problem details，ec 作为扩展字段
+type ProblemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	EC       int    `json:"ec"`
+	// Data 异常携带的数据，例如参数绑定失败的字段
+	Data interface{} `json:"data,omitempty"`
+	// RequestID 请求 id
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ProblemJSON 组内路由的异常以 application/problem+json 输出（代替 JSON 信封）
+func (g *Group) ProblemJSON() *Group {
+	return g.Meta(MetaProblemJSON, true)
+}
+
+// problemJSON 当前请求的异常是否以 application/problem+json 输出
+func (e *UEcho) problemJSON(c *Context) bool {
+	if v, ok := c.Route().GetMeta(MetaProblemJSON); ok {
+		enabled, _ := v.(bool)
+		return enabled
+	}
+	return e.ProblemJSON
+}
+
+// problemJSONRenderer 以 application/problem+json 输出异常，type 由 UEcho.ProblemType 生成
+func problemJSONRenderer(c *Context, r Reply) error {
+	problem := &ProblemDetails{
+		Type:      "about:blank",
+		Title:     http.StatusText(r.HTTPCode()),
+		Status:    r.HTTPCode(),
+		Detail:    r.EM(),
+		Instance:  c.Request().RequestURI,
+		EC:        r.EC(),
+		Data:      replyData(r),
+		RequestID: c.RequestID(),
+	}
+	if c.ue.ProblemType != nil {
+		problem.Type = c.ue.ProblemType(r)
+	}
+
+	b, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	return c.Blob(r.HTTPCode(), MIMEApplicationProblemJSON, b)
+}
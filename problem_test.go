@@ -0,0 +1,53 @@
+package uecho
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProblemJSON(t *testing.T) {
+	ue := New(nil)
+	fail := HandlerFunc(func(c *Context) error {
+		return c.Abort(ErrIllegalparams)
+	})
+	ue.GET("/v1/fail", fail)
+	ue.Group("/v2").ProblemJSON().GET("/fail", fail)
+
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/fail", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json; charset=UTF-8" {
+		t.Fatalf("v1 content type = %q", ct)
+	}
+
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v2/fail?x=1", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != MIMEApplicationProblemJSON {
+		t.Fatalf("v2 content type = %q", ct)
+	}
+	var problem ProblemDetails
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	want := ProblemDetails{
+		Type:     "about:blank",
+		Title:    http.StatusText(ErrIllegalparams.HTTPCode()),
+		Status:   ErrIllegalparams.HTTPCode(),
+		Detail:   ErrIllegalparams.EM(),
+		Instance: "/v2/fail?x=1",
+		EC:       ErrIllegalparams.EC(),
+		// 异常响应携带与响应头相同的请求 id
+		RequestID: rec.Header().Get("X-Request-Id"),
+	}
+	if problem.RequestID == "" || problem != want {
+		t.Fatalf("problem = %+v", problem)
+	}
+
+	ue.ProblemJSON = true
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/fail", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != MIMEApplicationProblemJSON {
+		t.Fatalf("global: content type = %q", ct)
+	}
+}
@@ -0,0 +1,106 @@
+package uecho
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// HeaderXTenantID 租户 id 请求头
+	HeaderXTenantID = "X-Tenant-ID"
+	// HeaderAcceptLanguage 语言请求头
+	HeaderAcceptLanguage = "Accept-Language"
+)
+
+// tenantKey 租户 id 在 Context 中的 key
+const tenantKey = "uecho.tenant"
+
+// PropagatedHeaders 调用下游服务时需要透传的链路追踪请求头
+var PropagatedHeaders = []string{
+	// W3C Trace Context
+	"traceparent",
+	"tracestate",
+	"baggage",
+	// Zipkin B3
+	"b3",
+	"X-B3-TraceId",
+	"X-B3-SpanId",
+	"X-B3-ParentSpanId",
+	"X-B3-Sampled",
+	"X-B3-Flags",
+	// Jaeger
+	"uber-trace-id",
+}
+
+// Tenant 当前请求的租户 id，优先取 SetTenant 设置的值，其次取 X-Tenant-ID 请求头
+func (c *Context) Tenant() string {
+	if tenant, ok := c.Get(tenantKey).(string); ok {
+		return tenant
+	}
+	return c.GetHeader(HeaderXTenantID)
+}
+
+// SetTenant 设置当前请求的租户 id（例如由认证中间键解析得到）
+func (c *Context) SetTenant(tenant string) {
+	c.Set(tenantKey, tenant)
+}
+
+// OutgoingHeaders 调用下游服务时需要携带的请求头：请求 id、链路追踪、语言及租户
+func (c *Context) OutgoingHeaders() http.Header {
+	h := make(http.Header)
+	for _, key := range PropagatedHeaders {
+		if v := c.Request().Header.Values(key); len(v) > 0 {
+			h[http.CanonicalHeaderKey(key)] = append([]string(nil), v...)
+		}
+	}
+	if id := c.RequestID(); id != "" {
+		h.Set(echo.HeaderXRequestID, id)
+	}
+	h.Set(HeaderAcceptLanguage, c.Lang())
+	if tenant := c.Tenant(); tenant != "" {
+		h.Set(HeaderXTenantID, tenant)
+	}
+	return h
+}
+
+type outgoingHeadersKey struct{}
+
+// OutgoingContext 返回携带 OutgoingHeaders 的 RequestContext，
+// 传给下游调用后可由 PropagateHeaders 或 PropagatingTransport 写入请求头
+func (c *Context) OutgoingContext() context.Context {
+	return context.WithValue(c.RequestContext(), outgoingHeadersKey{}, c.OutgoingHeaders())
+}
+
+// PropagateHeaders 将 ctx（由 c.OutgoingContext 得到）中的透传请求头写入 req，req 中已有的请求头不会被覆盖
+func PropagateHeaders(ctx context.Context, req *http.Request) {
+	h, _ := ctx.Value(outgoingHeadersKey{}).(http.Header)
+	for key, values := range h {
+		if _, ok := req.Header[key]; !ok {
+			req.Header[key] = append([]string(nil), values...)
+		}
+	}
+}
+
+var _ http.RoundTripper = (*PropagatingTransport)(nil)
+
+// PropagatingTransport 发送请求前按 req.Context() 透传请求头的 http.RoundTripper
+type PropagatingTransport struct {
+	// Base 实际发送请求的 RoundTripper
+	// Optional. Default value http.DefaultTransport.
+	Base http.RoundTripper
+}
+
+func (t *PropagatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if _, ok := req.Context().Value(outgoingHeadersKey{}).(http.Header); ok {
+		// RoundTripper 不应修改传入的请求
+		req = req.Clone(req.Context())
+		PropagateHeaders(req.Context(), req)
+	}
+	return base.RoundTrip(req)
+}
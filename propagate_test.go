@@ -0,0 +1,51 @@
+package uecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPropagatingTransport(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, key := range []string{"X-Request-ID", "traceparent", "Accept-Language", "X-Tenant-ID"} {
+			w.Header().Set("Echo-"+key, r.Header.Get(key))
+		}
+	}))
+	defer upstream.Close()
+
+	client := &http.Client{Transport: &PropagatingTransport{}}
+	ue := New(nil)
+	ue.GET("/proxy", HandlerFunc(func(c *Context) error {
+		c.SetLang(LANG_EN_US)
+		c.SetTenant("acme")
+		req, _ := http.NewRequestWithContext(c.OutgoingContext(), http.MethodGet, upstream.URL, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		for key, values := range resp.Header {
+			c.Response().Header()[key] = values
+		}
+		return c.NoContent(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/proxy", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+
+	want := map[string]string{
+		"Echo-X-Request-Id":    "req-1",
+		"Echo-Traceparent":     "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"Echo-Accept-Language": LANG_EN_US,
+		"Echo-X-Tenant-Id":     "acme",
+	}
+	for key, value := range want {
+		if got := rec.Header().Get(key); got != value {
+			t.Errorf("%s = %q, want %q", key, got, value)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package uecho
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// HeaderAPIKey 携带 API key 的请求头
+const HeaderAPIKey = "X-API-Key"
+
+// QuotaKey 配额的 key 在 Context 中的 key，由认证中间键在校验 API key 或租户之后写入
+const QuotaKey = "quota_key"
+
+// QuotaPeriod 配额周期
+type QuotaPeriod string
+
+const (
+	QuotaDaily   QuotaPeriod = "daily"
+	QuotaMonthly QuotaPeriod = "monthly"
+)
+
+// window 返回 t 所在周期的起止时间
+func (p QuotaPeriod) window(t time.Time) (start, end time.Time) {
+	y, m, d := t.Date()
+	switch p {
+	case QuotaMonthly:
+		start = time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+		return start, start.AddDate(0, 1, 0)
+	default:
+		start = time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+		return start, start.AddDate(0, 0, 1)
+	}
+}
+
+// QuotaLimit 一个周期内允许的调用次数
+type QuotaLimit struct {
+	Period QuotaPeriod `json:"period"`
+	Limit  int64       `json:"limit"`
+}
+
+// QuotaUsage 一个周期内的用量
+type QuotaUsage struct {
+	Period QuotaPeriod `json:"period"`
+	Limit  int64       `json:"limit"`
+	Used   int64       `json:"used"`
+	Start  time.Time   `json:"start"`
+	Reset  time.Time   `json:"reset"`
+}
+
+// QuotaStore 保存用量的存储，多实例部署时可实现为 Redis（INCRBY + EXPIREAT）等共享存储
+type QuotaStore interface {
+	// Incr 为 key 原子地增加 n 次用量（n 可以为负数）并返回增加后的用量，expire 之后 key 可以被清理
+	Incr(key string, n int64, expire time.Time) (int64, error)
+	// Get 返回 key 的用量，不存在时返回 0
+	Get(key string) (int64, error)
+}
+
+// MemoryQuotaStore 进程内的 QuotaStore，每个 key 的用量保留到周期结束
+type MemoryQuotaStore struct {
+	mu     sync.Mutex
+	counts map[string]quotaCount
+	sweep  time.Time
+}
+
+type quotaCount struct {
+	n      int64
+	expire time.Time
+}
+
+// NewMemoryQuotaStore 创建 MemoryQuotaStore
+func NewMemoryQuotaStore() *MemoryQuotaStore {
+	return &MemoryQuotaStore{counts: make(map[string]quotaCount)}
+}
+
+func (s *MemoryQuotaStore) Incr(key string, n int64, expire time.Time) (int64, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 定期清理已过期的用量
+	if now.After(s.sweep) {
+		for k, qc := range s.counts {
+			if now.After(qc.expire) {
+				delete(s.counts, k)
+			}
+		}
+		s.sweep = now.Add(time.Hour)
+	}
+
+	qc := s.counts[key]
+	qc.n += n
+	qc.expire = expire
+	s.counts[key] = qc
+	return qc.n, nil
+}
+
+func (s *MemoryQuotaStore) Get(key string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	qc, ok := s.counts[key]
+	if !ok || time.Now().After(qc.expire) {
+		return 0, nil
+	}
+	return qc.n, nil
+}
+
+type QuotaConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// Store 用量存储
+	// Optional. Default value NewMemoryQuotaStore().
+	Store QuotaStore
+
+	// Limits 每个 key 的配额
+	Limits []QuotaLimit
+
+	// LimitsFunc 返回 key（API key、租户）的配额，用于按套餐区分配额；返回 nil 时使用 Limits
+	LimitsFunc func(key string) []QuotaLimit
+
+	// KeyFunc 返回请求的 API key 或租户，返回空字符串时不计入配额
+	// 必须是经过认证的身份：直接读取请求头时客户端可以通过更换 key 绕过配额
+	// Optional. Default value reads string from c.Get(QuotaKey).
+	KeyFunc func(c *Context) string
+
+	// Location 计算周期起止时间使用的时区
+	// Optional. Default value time.Local.
+	Location *time.Location
+}
+
+// Quota 按 API key 或租户统计长周期（每日、每月）的调用次数，超过配额的调用以 ErrQuotaExceeded 拒绝
+type Quota struct {
+	conf QuotaConfig
+}
+
+// NewQuota 创建 Quota
+func NewQuota(conf QuotaConfig) *Quota {
+	if conf.Store == nil {
+		conf.Store = NewMemoryQuotaStore()
+	}
+	if conf.KeyFunc == nil {
+		conf.KeyFunc = func(c *Context) string {
+			key, _ := c.Get(QuotaKey).(string)
+			return key
+		}
+	}
+	if conf.Location == nil {
+		conf.Location = time.Local
+	}
+	return &Quota{conf: conf}
+}
+
+func (q *Quota) limits(key string) []QuotaLimit {
+	if q.conf.LimitsFunc != nil {
+		if limits := q.conf.LimitsFunc(key); limits != nil {
+			return limits
+		}
+	}
+	return q.conf.Limits
+}
+
+// quotaStoreKey 用量在 QuotaStore 中的 key：<key>:<period>:<周期开始时间>
+func quotaStoreKey(key string, period QuotaPeriod, start time.Time) string {
+	return key + ":" + string(period) + ":" + start.Format("20060102")
+}
+
+// Usage 返回 key 在当前各周期内的用量
+func (q *Quota) Usage(key string) ([]QuotaUsage, error) {
+	now := time.Now().In(q.conf.Location)
+	limits := q.limits(key)
+	usage := make([]QuotaUsage, 0, len(limits))
+	for _, l := range limits {
+		start, end := l.Period.window(now)
+		used, err := q.conf.Store.Get(quotaStoreKey(key, l.Period, start))
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, QuotaUsage{Period: l.Period, Limit: l.Limit, Used: used, Start: start, Reset: end})
+	}
+	return usage, nil
+}
+
+// quotaRefund 已增加用量、拒绝时需要退还的 key 及其过期时间
+type quotaRefund struct {
+	key    string
+	expire time.Time
+}
+
+// Middleware 统计调用次数，任一周期的用量超过配额时返回携带 Retry-After 的 ErrQuotaExceeded（429）
+// 先增加用量再比较（并发请求、共享存储的多个实例不会同时通过），被拒绝的调用退还已增加的用量
+func (q *Quota) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) error {
+			if q.conf.Skipper != nil && q.conf.Skipper(c) {
+				return next(c)
+			}
+			key := q.conf.KeyFunc(c)
+			if key == "" {
+				return next(c)
+			}
+
+			now := time.Now().In(q.conf.Location)
+			var counted []quotaRefund
+			refund := func() {
+				for _, k := range counted {
+					if _, err := q.conf.Store.Incr(k.key, -1, k.expire); err != nil {
+						c.Logrus().WithError(err).Warn("quota: refund failed")
+					}
+				}
+			}
+			for _, l := range q.limits(key) {
+				start, end := l.Period.window(now)
+				k := quotaStoreKey(key, l.Period, start)
+				used, err := q.conf.Store.Incr(k, 1, end)
+				if err != nil {
+					refund()
+					return c.Abort(ErrInternal).WithErr(err)
+				}
+				counted = append(counted, quotaRefund{key: k, expire: end})
+				if used > l.Limit {
+					refund()
+					c.SetRespHeader(HeaderRetryAfter, strconv.Itoa(resetSeconds(end)))
+					return c.Abort(ErrQuotaExceeded).WithFields(map[string]interface{}{
+						"quota_period": l.Period,
+						"quota_limit":  l.Limit,
+					})
+				}
+			}
+			return next(c)
+		}
+		return WrapHandler(HandlerFunc(f))
+	}
+}
+
+// UsageHandler 查询用量的管理接口，key 为路径参数 key，应注册在需要管理权限的分组下
+//
+//	admin.GET("/quota/:key", quota.UsageHandler())
+func (q *Quota) UsageHandler() HandlerFunc {
+	return func(c *Context) error {
+		key := c.Param("key")
+		usage, err := q.Usage(key)
+		if err != nil {
+			return c.Abort(ErrInternal).WithErr(err)
+		}
+		return c.SetPayload(OK.WithData(map[string]interface{}{
+			"key":   key,
+			"usage": usage,
+		}))
+	}
+}
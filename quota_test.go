@@ -0,0 +1,130 @@
+package uecho
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestQuota(t *testing.T) {
+	q := NewQuota(QuotaConfig{
+		Limits: []QuotaLimit{{Period: QuotaDaily, Limit: 2}, {Period: QuotaMonthly, Limit: 100}},
+		LimitsFunc: func(key string) []QuotaLimit {
+			if key == "gold" {
+				return []QuotaLimit{{Period: QuotaDaily, Limit: 3}}
+			}
+			return nil
+		},
+	})
+	ue := New(nil)
+	// 模拟认证中间键：校验通过的 API key 写入 QuotaKey
+	auth := func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if key := c.Request().Header.Get(HeaderAPIKey); key != "" {
+				c.Set(QuotaKey, key)
+			}
+			return next(c)
+		}
+	}
+	ue.GET("/api", HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}), auth, q.Middleware())
+	ue.GET("/admin/quota/:key", q.UsageHandler())
+
+	call := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api", nil)
+		if key != "" {
+			req.Header.Set(HeaderAPIKey, key)
+		}
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i, want := range []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		if rec := call("free"); rec.Code != want {
+			t.Errorf("free call %d: got %d, want %d", i, rec.Code, want)
+		}
+	}
+	rec := call("free")
+	var body struct {
+		EC int `json:"ec"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	if body.EC != 10429 || rec.Header().Get(HeaderRetryAfter) == "" {
+		t.Errorf("over quota: ec %d, Retry-After %q", body.EC, rec.Header().Get(HeaderRetryAfter))
+	}
+	for i := 0; i < 3; i++ {
+		if rec := call("gold"); rec.Code != http.StatusNoContent {
+			t.Errorf("gold call %d: got %d", i, rec.Code)
+		}
+	}
+	if rec := call(""); rec.Code != http.StatusNoContent {
+		t.Errorf("anonymous call: got %d", rec.Code)
+	}
+
+	// 被拒绝的调用不计入用量
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/quota/free", nil))
+	var usage struct {
+		Data struct {
+			Usage []QuotaUsage `json:"usage"`
+		} `json:"data"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &usage)
+	if u := usage.Data.Usage; len(u) != 2 || u[0].Used != 2 || u[1].Used != 2 || u[1].Limit != 100 || !u[0].Reset.After(time.Now()) {
+		t.Errorf("usage: %s", rec.Body)
+	}
+}
+
+func TestQuotaPeriodWindow(t *testing.T) {
+	now := time.Date(2024, 1, 31, 15, 4, 5, 0, time.UTC)
+	start, end := QuotaMonthly.window(now)
+	if !start.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("monthly: %v - %v", start, end)
+	}
+	start, end = QuotaDaily.window(now)
+	if !start.Equal(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("daily: %v - %v", start, end)
+	}
+}
+
+func TestQuotaConcurrent(t *testing.T) {
+	q := NewQuota(QuotaConfig{
+		Limits:  []QuotaLimit{{Period: QuotaDaily, Limit: 10}},
+		KeyFunc: func(c *Context) string { return "tenant" },
+	})
+	ue := New(nil)
+	ue.GET("/api", HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}), q.Middleware())
+
+	var (
+		wg     sync.WaitGroup
+		passed int32
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api", nil))
+			if rec.Code == http.StatusNoContent {
+				atomic.AddInt32(&passed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if passed != 10 {
+		t.Errorf("passed = %d, want 10", passed)
+	}
+	if usage, _ := q.Usage("tenant"); usage[0].Used != 10 {
+		t.Errorf("used = %d, want 10", usage[0].Used)
+	}
+}
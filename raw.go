@@ -0,0 +1,47 @@
+package uecho
+
+import (
+	"net/http"
+)
+
+// MetaRaw 路由以原始格式响应（不使用 {ec, em, data} 信封）的元数据 key
+const MetaRaw = "raw"
+
+// Raw 路由以原始格式响应：SetPayload 直接输出 data，异常以 text/plain 输出描述信息，
+// 适用于文件代理、第三方回调、健康检查等不能包装响应的接口
+func (r *Route) Raw() *Route {
+	return r.Meta(MetaRaw, true)
+}
+
+// Raw 组内路由以原始格式响应，见 Route.Raw
+func (g *Group) Raw() *Group {
+	return g.Meta(MetaRaw, true)
+}
+
+// rawMode 当前路由是否以原始格式响应
+func (c *Context) rawMode() bool {
+	v, _ := c.Route().GetMeta(MetaRaw)
+	raw, _ := v.(bool)
+	return raw
+}
+
+// Raw 以 contentType 原样输出 body，不经过 SetPayload 的信封包装，仍会被访问日志及指标统计（ec 为 code）
+func (c *Context) Raw(code int, contentType string, body []byte) error {
+	c.countReply(code)
+	return c.Blob(code, contentType, body)
+}
+
+// rawPayload 原始格式的 SetPayload：[]byte 按内容推断类型、string 以 text/plain 输出，
+// 其余类型以 JSON 输出，data 为 nil 时只输出状态码
+func rawPayload(c *Context, p *reply) error {
+	switch data := p.data.(type) {
+	case nil:
+		return c.NoContent(p.httpCode)
+	case []byte:
+		return c.Blob(p.httpCode, http.DetectContentType(data), data)
+	case string:
+		return c.String(p.httpCode, data)
+	default:
+		return c.JSON(p.httpCode, data)
+	}
+}
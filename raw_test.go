@@ -0,0 +1,50 @@
+package uecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRaw(t *testing.T) {
+	ue := New(nil)
+	ue.Metrics = NewMetrics()
+	ue.GET("/ping", HandlerFunc(func(c *Context) error {
+		return c.SetPayload(OK.WithData("pong"))
+	})).Raw()
+	ue.GET("/enveloped", HandlerFunc(func(c *Context) error {
+		return c.SetPayload(OK.WithData("pong"))
+	}))
+	g := ue.Group("/callback").Raw()
+	g.GET("/json", HandlerFunc(func(c *Context) error {
+		return c.SetPayload(OK.WithData(map[string]int{"n": 1}))
+	}))
+	g.GET("/fail", HandlerFunc(func(c *Context) error {
+		return c.Abort(ErrForbidden)
+	}))
+	g.GET("/xml", HandlerFunc(func(c *Context) error {
+		return c.Raw(http.StatusOK, "application/xml", []byte("<xml>success</xml>"))
+	}))
+
+	for _, tc := range []struct {
+		path, ctype, body string
+		code              int
+	}{
+		{"/ping", "text/plain; charset=UTF-8", "pong", http.StatusOK},
+		{"/enveloped", "application/json; charset=UTF-8", `{"ec":200,"em":"","data":"pong"}` + "\n", http.StatusOK},
+		{"/callback/json", "application/json; charset=UTF-8", `{"n":1}` + "\n", http.StatusOK},
+		{"/callback/fail", "text/plain; charset=UTF-8", "Forbidden", http.StatusForbidden},
+		{"/callback/xml", "application/xml", "<xml>success</xml>", http.StatusOK},
+	} {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Code != tc.code || rec.Header().Get("Content-Type") != tc.ctype || rec.Body.String() != tc.body {
+			t.Errorf("%s: got %d %q %q", tc.path, rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+		}
+	}
+
+	// c.Raw 同样按状态码统计响应数
+	if v := ue.Metrics.Counter(MetricResponses, "ec", "200", "route", "/callback/xml").Value(); v != 1 {
+		t.Errorf("raw responses = %d", v)
+	}
+}
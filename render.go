@@ -0,0 +1,270 @@
+package uecho
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"path"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RendererConfig 模板渲染器配置
+type RendererConfig struct {
+	// FS 模板所在的文件系统，可以是 os.DirFS 或 embed.FS
+	FS fs.FS
+
+	// Pages 页面模板（fs.Glob 格式），每个页面与布局、公共片段单独组成一个模板集合，
+	// 渲染时以页面在 FS 中的路径作为模板名，例如 "orders/list.html"
+	Pages []string
+
+	// Layouts 布局模板（fs.Glob 格式），布局通过 {{template "content" .}} 引用页面内容
+	Layouts []string
+
+	// Partials 公共片段模板（fs.Glob 格式），所有页面都可以引用
+	Partials []string
+
+	// Layout 默认布局的模板名（文件名），为空时直接渲染页面
+	Layout string
+
+	// Funcs 模板函数
+	Funcs template.FuncMap
+
+	// CSRFContextKey CSRF 中间件在 Context 中保存 token 的 key
+	// Optional. Default value "csrf".
+	CSRFContextKey string
+}
+
+// ViewData 传递给模板的数据，Data 为 handler 传入的数据，其余字段在渲染时按请求注入
+type ViewData struct {
+	Data interface{}
+
+	// Layout 本次渲染使用的布局，为空时使用 RendererConfig.Layout
+	Layout string
+
+	RequestID string
+	CSRFToken string
+	Lang      string
+}
+
+var _ echo.Renderer = (*Renderer)(nil)
+
+// Renderer 基于 html/template 的模板渲染器，支持布局、公共片段及按请求注入数据
+// Debug 模式下每次渲染都会重新从磁盘读取模板，修改模板后无需重启服务
+type Renderer struct {
+	conf RendererConfig
+
+	mu    sync.RWMutex
+	pages map[string]*template.Template
+}
+
+// NewRenderer 创建模板渲染器
+func NewRenderer(conf RendererConfig) *Renderer {
+	if conf.CSRFContextKey == "" {
+		conf.CSRFContextKey = "csrf"
+	}
+	return &Renderer{conf: conf}
+}
+
+// Load 解析并缓存全部模板
+func (r *Renderer) Load() error {
+	pages, err := r.parse()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.pages = pages
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Renderer) glob(patterns []string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(r.conf.FS, pattern)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+func (r *Renderer) parse() (map[string]*template.Template, error) {
+	shared, err := r.glob(append(append([]string{}, r.conf.Layouts...), r.conf.Partials...))
+	if err != nil {
+		return nil, err
+	}
+	pages, err := r.glob(r.conf.Pages)
+	if err != nil {
+		return nil, err
+	}
+
+	base := template.New("").Funcs(r.conf.Funcs)
+	if len(shared) > 0 {
+		if base, err = base.ParseFS(r.conf.FS, shared...); err != nil {
+			return nil, err
+		}
+	}
+
+	set := make(map[string]*template.Template, len(pages))
+	for _, page := range pages {
+		t, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if set[page], err = t.ParseFS(r.conf.FS, page); err != nil {
+			return nil, err
+		}
+	}
+	return set, nil
+}
+
+// lookup 返回页面对应的模板集合，debug 为 true 时跳过缓存重新解析
+func (r *Renderer) lookup(name string, debug bool) (*template.Template, error) {
+	var pages map[string]*template.Template
+	if debug {
+		var err error
+		if pages, err = r.parse(); err != nil {
+			return nil, err
+		}
+	} else {
+		r.mu.RLock()
+		pages = r.pages
+		r.mu.RUnlock()
+		if pages == nil {
+			if err := r.Load(); err != nil {
+				return nil, err
+			}
+			r.mu.RLock()
+			pages = r.pages
+			r.mu.RUnlock()
+		}
+	}
+
+	t, ok := pages[name]
+	if !ok {
+		return nil, fmt.Errorf("renderer: template %q not found", name)
+	}
+	return t, nil
+}
+
+// Render implements `echo.Renderer` interface.
+func (r *Renderer) Render(w io.Writer, name string, data interface{}, c echo.Context) error {
+	t, err := r.lookup(name, c.Echo().Debug)
+	if err != nil {
+		return err
+	}
+
+	vd := r.viewData(data, c)
+	layout := vd.Layout
+	if layout == "" {
+		layout = r.conf.Layout
+	}
+	if layout == "" {
+		return t.ExecuteTemplate(w, path.Base(name), vd)
+	}
+	return t.ExecuteTemplate(w, layout, vd)
+}
+
+// viewData 包装 handler 传入的数据，并注入请求相关信息
+func (r *Renderer) viewData(data interface{}, c echo.Context) *ViewData {
+	vd := new(ViewData)
+	if v, ok := data.(*ViewData); ok {
+		*vd = *v
+	} else {
+		vd.Data = data
+	}
+
+	if token, ok := c.Get(r.conf.CSRFContextKey).(string); ok && vd.CSRFToken == "" {
+		vd.CSRFToken = token
+	}
+	if uc, ok := c.(*Context); ok {
+		if vd.RequestID == "" {
+			vd.RequestID = uc.RequestID()
+		}
+		if vd.Lang == "" {
+			vd.Lang = uc.Lang()
+		}
+	}
+	return vd
+}
+
+// LoadTemplates 按配置加载模板并设置为默认的 Renderer，之后 c.Render 即可直接使用
+func (e *UEcho) LoadTemplates(conf RendererConfig) error {
+	r := NewRenderer(conf)
+	if err := r.Load(); err != nil {
+		return err
+	}
+	e.Renderer = r
+	return nil
+}
+
+// Exists 是否存在名为 name 的页面模板
+func (r *Renderer) Exists(name string, debug bool) bool {
+	_, err := r.lookup(name, debug)
+	return err == nil
+}
+
+// ErrorPageConfig HTML 错误页配置
+// 客户端偏好 text/html 时，DefaultHTTPErrorHandler 依次查找以下页面模板渲染错误页：
+//
+//	{Dir}/{ec}.{lang}{Ext}, {Dir}/{ec}{Ext}, {Dir}/{status}.{lang}{Ext}, {Dir}/{status}{Ext}, {Dir}/default{Ext}
+//
+// 均不存在时仍返回 JSON
+type ErrorPageConfig struct {
+	// Dir 错误页模板所在目录
+	// Optional. Default value "errors".
+	Dir string
+
+	// Ext 模板文件扩展名
+	// Optional. Default value ".html".
+	Ext string
+}
+
+// ErrorPageData 错误页模板中 ViewData.Data 的值
+type ErrorPageData struct {
+	Status int
+	EC     int
+	EM     string
+}
+
+func (conf *ErrorPageConfig) candidates(status, ec int, lang string) []string {
+	dir, ext := conf.Dir, conf.Ext
+	if dir == "" {
+		dir = "errors"
+	}
+	if ext == "" {
+		ext = ".html"
+	}
+	return []string{
+		fmt.Sprintf("%s/%d.%s%s", dir, ec, lang, ext),
+		fmt.Sprintf("%s/%d%s", dir, ec, ext),
+		fmt.Sprintf("%s/%d.%s%s", dir, status, lang, ext),
+		fmt.Sprintf("%s/%d%s", dir, status, ext),
+		fmt.Sprintf("%s/default%s", dir, ext),
+	}
+}
+
+// renderErrorPage 渲染 HTML 错误页，没有可用的模板时返回 false
+func (e *UEcho) renderErrorPage(c *Context, status, ec int, em string) bool {
+	if e.ErrorPage == nil || e.Renderer == nil {
+		return false
+	}
+
+	data := &ViewData{Data: &ErrorPageData{Status: status, EC: ec, EM: em}}
+	r, isRenderer := e.Renderer.(*Renderer)
+	for _, name := range e.ErrorPage.candidates(status, ec, c.Lang()) {
+		if isRenderer && !r.Exists(name, e.Debug) {
+			continue
+		}
+		// Render 先渲染到 buffer，失败时响应尚未写出，可以继续尝试下一个模板
+		if err := c.Render(status, name, data); err == nil {
+			return true
+		}
+	}
+	return false
+}
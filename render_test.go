@@ -0,0 +1,125 @@
+package uecho
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRendererLayout(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html":  {Data: []byte(`<html lang="{{.Lang}}">{{template "content" .}}</html>`)},
+		"partials/name.html": {Data: []byte(`{{define "name"}}<b>{{.}}</b>{{end}}`)},
+		"pages/hello.html":   {Data: []byte(`{{define "content"}}hello {{template "name" .Data}}{{end}}`)},
+	}
+
+	ue := New(nil)
+	err := ue.LoadTemplates(RendererConfig{
+		FS:       fsys,
+		Pages:    []string{"pages/*.html"},
+		Layouts:  []string{"layouts/*.html"},
+		Partials: []string{"partials/*.html"},
+		Layout:   "base.html",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ue.GET("/hello", HandlerFunc(func(c *Context) error {
+		c.SetLang(LANG_EN_US)
+		return c.Render(http.StatusOK, "pages/hello.html", "uecho")
+	}))
+
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/hello", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if want := `<html lang="en-US">hello <b>uecho</b></html>`; rec.Body.String() != want {
+		t.Fatalf("body = %q, want %q", rec.Body.String(), want)
+	}
+}
+
+func TestErrorPage(t *testing.T) {
+	fsys := fstest.MapFS{
+		"errors/404.html":       {Data: []byte(`not found: {{.Data.EC}}`)},
+		"errors/404.en-US.html": {Data: []byte(`en not found`)},
+	}
+
+	ue := New(nil)
+	ue.ErrorPage = &ErrorPageConfig{}
+	if err := ue.LoadTemplates(RendererConfig{FS: fsys, Pages: []string{"errors/*.html"}}); err != nil {
+		t.Fatal(err)
+	}
+	ue.GET("/missing", HandlerFunc(func(c *Context) error {
+		return c.Abort(ErrNotFound)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.8")
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound || rec.Body.String() != "not found: 404" {
+		t.Fatalf("html: status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/missing", nil)
+	req.Header.Set("Accept", "application/json")
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json; charset=UTF-8" {
+		t.Fatalf("json: content type = %q", ct)
+	}
+}
+
+func TestDebugMode(t *testing.T) {
+	for _, debug := range []bool{false, true} {
+		fsys := fstest.MapFS{"pages/hello.html": {Data: []byte(`v1`)}}
+		dir := t.TempDir()
+		asset := filepath.Join(dir, "app.js")
+		if err := ioutil.WriteFile(asset, []byte("v1"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		ue := New(nil)
+		ue.Debug = debug
+		ue.StaticCache = NewStaticCache(StaticCacheConfig{})
+		if err := ue.LoadTemplates(RendererConfig{FS: fsys, Pages: []string{"pages/*.html"}}); err != nil {
+			t.Fatal(err)
+		}
+		ue.GET("/hello", HandlerFunc(func(c *Context) error {
+			return c.Render(http.StatusOK, "pages/hello.html", nil)
+		}))
+		ue.Static("/assets", dir)
+		get := func(path string) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+			return rec
+		}
+
+		get("/hello")
+		get("/assets/app.js")
+		fsys["pages/hello.html"] = &fstest.MapFile{Data: []byte(`v2`)}
+		if err := ioutil.WriteFile(asset, []byte("v2"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// Debug 模式下重新加载模板、不缓存静态文件；否则使用已加载的模板及缓存
+		want := "v1"
+		if debug {
+			want = "v2"
+		}
+		if body := get("/hello").Body.String(); body != want {
+			t.Errorf("debug=%v: template = %q, want %q", debug, body, want)
+		}
+		rec := get("/assets/app.js")
+		if body := rec.Body.String(); body != want {
+			t.Errorf("debug=%v: static = %q, want %q", debug, body, want)
+		}
+		if cc := rec.Header().Get("Cache-Control"); (cc == "no-cache") != debug {
+			t.Errorf("debug=%v: Cache-Control = %q", debug, cc)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package uecho
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultReplayWindow 默认的 ReplayGuard.Window
+const DefaultReplayWindow = 5 * time.Minute
+
+// NonceStore 记录已使用的 nonce，多实例部署时可实现为 Redis（SET key 1 NX PX ttl）等共享存储
+type NonceStore interface {
+	// Add 记录 nonce 并保留 ttl，nonce 已存在时返回 false
+	Add(nonce string, ttl time.Duration) (bool, error)
+}
+
+// NonceReleaser NonceStore 可选实现的接口：回调处理失败时删除 nonce，发送方以相同的 timestamp、nonce 重试时不会被当作重放
+type NonceReleaser interface {
+	Remove(nonce string) error
+}
+
+// MemoryNonceStore 进程内的 NonceStore
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	sweep  time.Time
+}
+
+// NewMemoryNonceStore 创建 MemoryNonceStore
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+func (s *MemoryNonceStore) Add(nonce string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 定期清理已过期的 nonce
+	if now.After(s.sweep) {
+		for n, expire := range s.nonces {
+			if now.After(expire) {
+				delete(s.nonces, n)
+			}
+		}
+		s.sweep = now.Add(ttl)
+	}
+
+	if expire, ok := s.nonces[nonce]; ok && !now.After(expire) {
+		return false, nil
+	}
+	s.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+func (s *MemoryNonceStore) Remove(nonce string) error {
+	s.mu.Lock()
+	delete(s.nonces, nonce)
+	s.mu.Unlock()
+	return nil
+}
+
+// ReplayGuard 重放保护：请求的时间戳（Unix 秒）须在 Window 内，且 nonce 在有效期内只能使用一次
+// 时间戳与 nonce 必须参与签名，应在签名校验通过之后检查
+type ReplayGuard struct {
+	// Store 已使用的 nonce
+	// Optional. Default value NewMemoryNonceStore().
+	Store NonceStore
+
+	// Window 时间戳与服务器时间允许的最大偏差
+	// Optional. Default value 5m.
+	Window time.Duration
+
+	once sync.Once
+}
+
+func (g *ReplayGuard) init() {
+	g.once.Do(func() {
+		if g.Store == nil {
+			g.Store = NewMemoryNonceStore()
+		}
+		if g.Window <= 0 {
+			g.Window = DefaultReplayWindow
+		}
+	})
+}
+
+// Check 检查时间戳与 nonce，疑似重放时返回 ErrReplayed
+func (g *ReplayGuard) Check(c *Context, timestamp, nonce string) error {
+	g.init()
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || nonce == "" {
+		return c.Abort(ErrReplayed).WithErr(err).WithField("replay", "missing timestamp or nonce")
+	}
+	skew := time.Since(time.Unix(ts, 0))
+	if skew > g.Window || skew < -g.Window {
+		return c.Abort(ErrReplayed).WithField("replay", "timestamp out of window")
+	}
+
+	// 时间戳在 [now-Window, now+Window] 内均可通过，nonce 至少需要保留 2*Window
+	ok, err := g.Store.Add(nonce, 2*g.Window)
+	if err != nil {
+		return c.Abort(ErrInternal).WithErr(err)
+	}
+	if !ok {
+		return c.Abort(ErrReplayed).WithField("replay", "duplicate nonce")
+	}
+	return nil
+}
+
+// Release 释放 nonce（Store 实现了 NonceReleaser 时），处理失败后允许发送方重试；
+// Store 未实现 NonceReleaser 时不做处理，失败后的重试会被当作重放拒绝
+func (g *ReplayGuard) Release(nonce string) error {
+	g.init()
+	if r, ok := g.Store.(NonceReleaser); ok {
+		return r.Remove(nonce)
+	}
+	return nil
+}
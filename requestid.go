@@ -0,0 +1,51 @@
+package uecho
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/labstack/echo/v4"
+)
+
+// randomHex 返回 n 字节随机数的 hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// maxRequestIDLength 请求携带的 X-Request-Id 的最大长度
+const maxRequestIDLength = 128
+
+// validRequestID 请求携带的 X-Request-Id 是否可以沿用：不超过 maxRequestIDLength，
+// 只包含字母、数字及 - _ . :（防止日志注入、响应头注入及超长 id）
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		switch b := id[i]; {
+		case b >= '0' && b <= '9', b >= 'a' && b <= 'z', b >= 'A' && b <= 'Z':
+		case b == '-', b == '_', b == '.', b == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// ensureRequestID 在执行 Pre 中间键之前确保请求携带 X-Request-Id：未携带或不合法（见 validRequestID）时
+// 以 RequestIDGenerator 生成并写入请求头，同时写入响应头，路由匹配之前发生的异常、访问日志及异常响应同样可以取得（c.RequestID()）
+func (e *UEcho) ensureRequestID(c *Context) {
+	req := c.Request()
+	id := req.Header.Get(echo.HeaderXRequestID)
+	if !validRequestID(id) {
+		if e.RequestIDGenerator != nil {
+			id = e.RequestIDGenerator()
+		} else {
+			id = randomHex(16)
+		}
+		req.Header.Set(echo.HeaderXRequestID, id)
+	}
+	c.Response().Header().Set(echo.HeaderXRequestID, id)
+}
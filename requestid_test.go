@@ -0,0 +1,57 @@
+package uecho
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestRequestID(t *testing.T) {
+	ue := New(nil)
+	ue.RequestIDGenerator = func() string { return "generated" }
+	var preID string
+	ue.Pre(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return WrapHandler(HandlerFunc(func(c *Context) error {
+			preID = c.RequestID()
+			if c.QueryParam("deny") != "" {
+				return c.Abort(ErrForbidden)
+			}
+			return next(c)
+		}))
+	})
+	ue.GET("/", HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}))
+
+	for _, tc := range []struct {
+		target, header, want string
+	}{
+		{"/?deny=1", "", "generated"},
+		{"/?deny=1", "upstream", "upstream"},
+		{"/", "3f2b-7a.c:1_x", "3f2b-7a.c:1_x"},
+		{"/", "bad id\r\nlevel=error", "generated"},
+		{"/", strings.Repeat("a", maxRequestIDLength+1), "generated"},
+		{"/", "", "generated"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		if tc.header != "" {
+			req.Header.Set(echo.HeaderXRequestID, tc.header)
+		}
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, req)
+		if preID != tc.want || rec.Header().Get(echo.HeaderXRequestID) != tc.want {
+			t.Errorf("%s: pre-middleware id %q, response header %q, want %q", tc.target, preID, rec.Header().Get(echo.HeaderXRequestID), tc.want)
+		}
+		if rec.Code == http.StatusForbidden {
+			var body HttpApiResponse
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if body.RequestID != tc.want {
+				t.Errorf("%s: envelope request_id %q, want %q", tc.target, body.RequestID, tc.want)
+			}
+		}
+	}
+}
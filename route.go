@@ -2,6 +2,7 @@ package uecho
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -20,16 +21,94 @@ var methods = [...]string{
 	echo.REPORT,
 }
 
+// Route 路由信息，在 echo.Route 的基础上增加 host 及路由元数据
+//
+// 不兼容变更：GET、POST、Add 等注册方法（UEcho、Group）的返回值由 *echo.Route 改为 *Route。
+// 读写 Name、Method、Path 等字段的代码不受影响（echo.Route 内嵌）；
+// 需要 *echo.Route 的地方改为 &r.Route，声明为 *echo.Route 类型的变量改为 *uecho.Route
+type Route struct {
+	echo.Route
+	Host string `json:"host,omitempty"`
+	// Group 注册路由的分组前缀，不是通过分组注册时为空
+	Group string `json:"group,omitempty"`
+	// CallSite 注册路由的代码位置（file:line）
+	CallSite string `json:"call_site,omitempty"`
+	// Middleware 路由及分组中间键的数量（不含 UEcho#Use 及 host 级别的中间键）
+	Middleware int `json:"middleware"`
+
+	meta     map[string]interface{}
+	catchAll bool // Group#Use 为分组中间键注册的兜底路由
+}
+
+// Meta 设置路由元数据（例如 .Meta("perm", "orders:write")），供中间键按路由读取
+// 应在注册路由时设置，处理请求期间不可修改
+func (r *Route) Meta(key string, value interface{}) *Route {
+	if r.meta == nil {
+		r.meta = make(map[string]interface{})
+	}
+	r.meta[key] = value
+	return r
+}
+
+// GetMeta 返回路由元数据
+func (r *Route) GetMeta(key string) (interface{}, bool) {
+	if r == nil {
+		return nil, false
+	}
+	v, ok := r.meta[key]
+	return v, ok
+}
+
+const (
+	// MetaTimeout 路由处理时限的元数据 key
+	MetaTimeout = "timeout"
+	// MetaRetryBudget 路由重试预算的元数据 key
+	MetaRetryBudget = "retry_budget"
+)
+
+// Timeout 设置路由的处理时限（覆盖 UEcho.RouteTimeout），处理请求前 Request().Context() 会被加上对应的 deadline，
+// 下游的 DB/RPC 调用使用 c.RequestContext() 即可继承该时限；超时后返回错误且尚未写出响应的请求以 504 响应
+func (r *Route) Timeout(d time.Duration) *Route {
+	return r.Meta(MetaTimeout, d)
+}
+
+// timeout 返回路由设置的处理时限
+func (r *Route) timeout() time.Duration {
+	v, _ := r.GetMeta(MetaTimeout)
+	d, _ := v.(time.Duration)
+	return d
+}
+
+// RetryBudget 设置路由每个请求内下游调用可用的重试次数（覆盖 UEcho.RetryBudget），见 Context.RetryBudget
+func (r *Route) RetryBudget(n int) *Route {
+	return r.Meta(MetaRetryBudget, n)
+}
+
+// retries 返回路由设置的重试次数
+func (r *Route) retries() (int, bool) {
+	v, ok := r.GetMeta(MetaRetryBudget)
+	n, _ := v.(int)
+	return n, ok
+}
+
 type Router struct {
 	*echo.Router
 
-	routes map[string]*echo.Route
+	routes     map[string]*Route
+	middleware []echo.MiddlewareFunc
+	replaced   []*Route // 被重复注册覆盖的路由
+}
+
+// Use 添加 host 级别的中间键，作用于该 host 的全部请求（包括未匹配到路由的请求），
+// 在 UEcho#Use 的中间键之后、分组及路由中间键之前执行
+func (r *Router) Use(middleware ...echo.MiddlewareFunc) {
+	r.middleware = append(r.middleware, middleware...)
 }
 
 func NewRouter(e *UEcho) *Router {
 	return &Router{
 		Router: echo.NewRouter(e.Echo),
-		routes: map[string]*echo.Route{},
+		routes: map[string]*Route{},
 	}
 }
 
@@ -41,3 +120,19 @@ func (r *Router) Add(method, path string, h Handler) {
 func (r *Router) Find(method, path string, c echo.Context) {
 	r.Router.Find(method, path, c)
 }
+
+// route 返回 method + path（注册时的路径）对应的 Route
+func (r *Router) route(method, path string) *Route {
+	return r.routes[method+path]
+}
+
+// allow 返回 path（注册时的路径）上已注册的 http method
+func (r *Router) allow(path string) []string {
+	var allowed []string
+	for _, m := range methods {
+		if _, ok := r.routes[m+path]; ok {
+			allowed = append(allowed, m)
+		}
+	}
+	return allowed
+}
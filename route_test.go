@@ -0,0 +1,224 @@
+package uecho
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRouteTimeout(t *testing.T) {
+	ue := New(nil)
+	ue.GET("/slow", HandlerFunc(func(c *Context) error {
+		deadline, ok := c.RequestContext().Deadline()
+		if !ok || time.Until(deadline) > 2*time.Second {
+			t.Errorf("deadline = %v, %v", deadline, ok)
+		}
+		return c.NoContent(http.StatusNoContent)
+	})).Timeout(2 * time.Second)
+	ue.GET("/fast", HandlerFunc(func(c *Context) error {
+		if _, ok := c.RequestContext().Deadline(); ok {
+			t.Error("unexpected deadline")
+		}
+		return c.NoContent(http.StatusNoContent)
+	}))
+
+	for _, path := range []string{"/slow", "/fast"} {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("%s: status = %d", path, rec.Code)
+		}
+	}
+}
+
+func TestRouteTimeoutResponse(t *testing.T) {
+	ue := New(nil)
+	ue.RetryBudget = 1
+	ue.GET("/report", HandlerFunc(func(c *Context) error {
+		<-c.RequestContext().Done()
+		return c.RequestContext().Err()
+	})).Timeout(10 * time.Millisecond)
+	ue.GET("/retry", HandlerFunc(func(c *Context) error {
+		b := c.RetryBudget()
+		if !b.Take() || b.Take() {
+			t.Error("expected exactly one retry")
+		}
+		return c.NoContent(http.StatusNoContent)
+	}))
+
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/report", nil))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/retry", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("status = %d", rec.Code)
+	}
+}
+
+func TestRouteTimeoutWithLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	ue := New(logger)
+	ue.RouteTimeout = 20 * time.Millisecond
+	ue.Use(Logger())
+	ue.GET("/report", HandlerFunc(func(c *Context) error {
+		<-c.RequestContext().Done()
+		return c.RequestContext().Err()
+	})).Name = "report"
+	ue.GET("/query", HandlerFunc(func(c *Context) error {
+		time.Sleep(60 * time.Millisecond)
+		return errors.New("query failed")
+	})).Name = "query"
+	ue.GET("/sleep", HandlerFunc(func(c *Context) error {
+		time.Sleep(60 * time.Millisecond)
+		return c.SetPayload(OK)
+	})).Name = "sleep"
+	ue.GET("/fast", HandlerFunc(func(c *Context) error {
+		return c.SetPayload(OK)
+	}))
+
+	for _, name := range []string{"report", "query"} {
+		buf.Reset()
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+name, nil))
+		if rec.Code != http.StatusGatewayTimeout || !strings.Contains(rec.Body.String(), `"ec":504`) {
+			t.Errorf("%s: status = %d, body = %s", name, rec.Code, rec.Body.String())
+		}
+		if !strings.Contains(buf.String(), "route="+name) || !strings.Contains(buf.String(), "status=504") {
+			t.Errorf("%s: log = %q", name, buf.String())
+		}
+	}
+
+	// 成功返回的请求即使超过时限也不转换为 504
+	for _, path := range []string{"/sleep", "/fast"} {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"ec":200`) {
+			t.Errorf("%s: status = %d, body = %s", path, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestHostMiddleware(t *testing.T) {
+	ue := New(nil)
+	mark := func(name string) echo.MiddlewareFunc {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				c.Response().Header().Add("X-Middleware", name)
+				return next(c)
+			}
+		}
+	}
+	ok := HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	ue.Use(mark("global"))
+	ue.Host("admin.example.com", mark("host")).GET("/a", ok)
+	ue.Host("admin.example.com").Group("/users", mark("group")).GET("/b", ok)
+
+	cases := []struct {
+		target string
+		status int
+		want   string
+	}{
+		{"http://admin.example.com/a", http.StatusNoContent, "global,host"},
+		{"http://admin.example.com/users/b", http.StatusNoContent, "global,host,group"},
+		{"http://admin.example.com/missing", http.StatusNotFound, "global,host"},
+		{"http://www.example.com/a", http.StatusNotFound, "global"},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.target, nil))
+		got := strings.Join(rec.Header()["X-Middleware"], ",")
+		if rec.Code != tc.status || got != tc.want {
+			t.Errorf("%s: status = %d, middleware = %q", tc.target, rec.Code, got)
+		}
+	}
+}
+
+func TestHostPattern(t *testing.T) {
+	ue := New(nil)
+	ue.Host("{tenant}.api.example.com").GET("/orders/:id", HandlerFunc(func(c *Context) error {
+		return c.String(http.StatusOK, c.Param("tenant")+" "+c.Param("id"))
+	}))
+	ue.Host("admin.api.example.com").GET("/orders/:id", HandlerFunc(func(c *Context) error {
+		return c.String(http.StatusOK, "admin")
+	}))
+
+	cases := []struct {
+		target string
+		status int
+		body   string
+	}{
+		{"http://acme.api.example.com/orders/7", http.StatusOK, "acme 7"},
+		{"http://acme.api.example.com:8080/orders/7", http.StatusOK, "acme 7"},
+		{"http://admin.api.example.com/orders/7", http.StatusOK, "admin"},
+		{"http://api.example.com/orders/7", http.StatusNotFound, ""},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.target, nil))
+		if rec.Code != tc.status || (tc.body != "" && rec.Body.String() != tc.body) {
+			t.Errorf("%s: status = %d, body = %q", tc.target, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	ue := New(nil)
+	ok := HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	ue.GET("/orders/:id", ok)
+	ue.PUT("/orders/:id", ok)
+	ue.DELETE("/orders/:id", ok)
+
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders/1", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "DELETE, GET, PUT" {
+		t.Fatalf("status = %d, Allow = %q", rec.Code, rec.Header().Get("Allow"))
+	}
+
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/missing", nil))
+	if rec.Code != http.StatusNotFound || rec.Header().Get("Allow") != "" {
+		t.Fatalf("not found: status = %d, Allow = %q", rec.Code, rec.Header().Get("Allow"))
+	}
+}
+
+func TestAutoOptions(t *testing.T) {
+	ue := New(nil)
+	ue.AutoOptions = true
+	ue.Use(middleware.CORS())
+	ue.GET("/orders", HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/orders", nil)
+	req.Header.Set("Origin", "https://example.com")
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Allow") != "GET, OPTIONS" ||
+		rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatalf("status = %d, headers = %v", rec.Code, rec.Header())
+	}
+
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", nil))
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, OPTIONS" {
+		t.Fatalf("405: status = %d, Allow = %q", rec.Code, rec.Header().Get("Allow"))
+	}
+}
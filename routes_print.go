@@ -0,0 +1,64 @@
+package uecho
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// RouteTable 返回全部路由（不含 Group#Use 注册的兜底路由），默认 Router 在前，host Router 按 host 排序
+func (e *UEcho) RouteTable() []*Route {
+	routers := []*Router{e.router}
+	for _, host := range sortedHosts(e.routers) {
+		routers = append(routers, e.routers[host])
+	}
+
+	var routes []*Route
+	for _, router := range routers {
+		for _, r := range router.sortedRoutes() {
+			if !r.catchAll {
+				routes = append(routes, r)
+			}
+		}
+	}
+	return routes
+}
+
+// PrintRoutes 以对齐的表格输出路由表：method、path、host、name、中间键数量
+func (e *UEcho) PrintRoutes(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tHOST\tNAME\tMIDDLEWARE")
+	for _, r := range e.RouteTable() {
+		host := r.Host
+		if host == "" {
+			host = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\n", r.Method, r.Path, host, r.Name, r.Middleware)
+	}
+	return tw.Flush()
+}
+
+// PrintRoutesJSON 以 JSON 数组输出路由表
+func (e *UEcho) PrintRoutesJSON(w io.Writer) error {
+	routes := e.RouteTable()
+	if routes == nil {
+		routes = []*Route{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(routes)
+}
+
+// printRoutesOnStart 开启 ShowRoutes 且未隐藏 banner 时，在 banner 下输出路由表
+func (e *UEcho) printRoutesOnStart() {
+	if !e.ShowRoutes || e.HideBanner {
+		return
+	}
+	if e.RoutesJSON {
+		e.PrintRoutesJSON(os.Stdout)
+		return
+	}
+	e.PrintRoutes(os.Stdout)
+}
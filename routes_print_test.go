@@ -0,0 +1,45 @@
+package uecho
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4/middleware"
+)
+
+func TestPrintRoutes(t *testing.T) {
+	ue := New(nil)
+	ok := HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	ue.GET("/ping", ok)
+	ue.Group("/api", middleware.BodyLimit("1M")).POST("/orders", ok, middleware.Gzip())
+	ue.Host("admin.example.com").GET("/", ok)
+
+	var buf bytes.Buffer
+	if err := ue.PrintRoutes(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "METHOD") {
+		t.Fatalf("table:\n%s", buf.String())
+	}
+	if fields := strings.Fields(lines[1]); fields[0] != "POST" || fields[1] != "/api/orders" || fields[2] != "*" || fields[4] != "2" {
+		t.Fatalf("row = %q", lines[1])
+	}
+	if fields := strings.Fields(lines[3]); fields[2] != "admin.example.com" {
+		t.Fatalf("row = %q", lines[3])
+	}
+
+	buf.Reset()
+	if err := ue.PrintRoutesJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var routes []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &routes); err != nil || len(routes) != 3 {
+		t.Fatalf("json = %s, err = %v", buf.String(), err)
+	}
+}
@@ -0,0 +1,150 @@
+package uecho
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// sparseFieldsKey 需要返回的字段在 Context 中的 key
+const sparseFieldsKey = "uecho.sparse_fields"
+
+type SparseFieldsConfig struct {
+	// Skipper defines a function to skip middleware.
+	Skipper middleware.Skipper
+
+	// Param 指定字段的 query 参数
+	// Optional. Default value "fields".
+	Param string
+}
+
+// SparseFields 按 ?fields= 过滤响应的 data，见 SparseFieldsWithConfig
+func SparseFields() echo.MiddlewareFunc {
+	return SparseFieldsWithConfig(SparseFieldsConfig{})
+}
+
+// SparseFieldsWithConfig 按 query 参数过滤 SetPayload（JSON、JSONP）响应的 data，只保留指定的字段，
+// 多个字段以逗号分隔，嵌套字段以 "." 连接，例如 ?fields=id,name,owner.email
+// 过滤作用于 data 的 JSON 表示（json tag 决定字段名），data 为数组时作用于每个元素，不存在的字段被忽略
+func SparseFieldsWithConfig(conf SparseFieldsConfig) echo.MiddlewareFunc {
+	if conf.Param == "" {
+		conf.Param = "fields"
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		f := func(c *Context) error {
+			if conf.Skipper != nil && conf.Skipper(c) {
+				return next(c)
+			}
+			if fields := c.QueryParam(conf.Param); fields != "" {
+				c.SetSparseFields(strings.Split(fields, ",")...)
+			}
+			return next(c)
+		}
+		return WrapHandler(HandlerFunc(f))
+	}
+}
+
+// SetSparseFields 指定本次请求响应 data 需要返回的字段（规则同 SparseFieldsWithConfig）
+func (c *Context) SetSparseFields(fields ...string) {
+	tree := fieldTree{}
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			tree.add(strings.Split(field, "."))
+		}
+	}
+	if len(tree) > 0 {
+		c.Set(sparseFieldsKey, tree)
+	} else {
+		c.Set(sparseFieldsKey, nil)
+	}
+}
+
+// fieldTree 需要保留的字段，值为 nil 时保留整个字段
+type fieldTree map[string]fieldTree
+
+func (t fieldTree) add(path []string) {
+	sub, ok := t[path[0]]
+	if len(path) == 1 {
+		t[path[0]] = nil // 保留整个字段
+		return
+	}
+	if ok && sub == nil {
+		return // 已保留整个字段
+	}
+	if !ok {
+		sub = fieldTree{}
+		t[path[0]] = sub
+	}
+	sub.add(path[1:])
+}
+
+// FilterFields 按 fields（规则同 SparseFieldsWithConfig）过滤 data 的 JSON 表示
+func FilterFields(data interface{}, fields ...string) (interface{}, error) {
+	tree := fieldTree{}
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			tree.add(strings.Split(field, "."))
+		}
+	}
+	return tree.filterData(data)
+}
+
+func (t fieldTree) filterData(data interface{}) (interface{}, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return t.filter(v), nil
+}
+
+func (t fieldTree) filter(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for name, sub := range t {
+			fv, ok := v[name]
+			if !ok {
+				continue
+			}
+			if sub != nil {
+				fv = sub.filter(fv)
+			}
+			out[name] = fv
+		}
+		return out
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = t.filter(elem)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// sparseData 按 SetSparseFields 指定的字段过滤 data，未指定或过滤失败时原样返回
+func sparseData(c *Context, data interface{}) interface{} {
+	if data == nil {
+		return nil
+	}
+	tree, _ := c.Get(sparseFieldsKey).(fieldTree)
+	if tree == nil {
+		return data
+	}
+	filtered, err := tree.filterData(data)
+	if err != nil {
+		c.Logrus().WithError(err).Warn("sparse fields: filter data failed")
+		return data
+	}
+	return filtered
+}
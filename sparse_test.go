@@ -0,0 +1,47 @@
+package uecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSparseFields(t *testing.T) {
+	type owner struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	type repo struct {
+		ID    int    `json:"id"`
+		Name  string `json:"name"`
+		Owner owner  `json:"owner"`
+	}
+	repos := []repo{
+		{ID: 1, Name: "uecho", Owner: owner{Name: "a", Email: "a@example.com"}},
+		{ID: 2, Name: "echo", Owner: owner{Name: "b", Email: "b@example.com"}},
+	}
+
+	ue := New(nil)
+	ue.Use(SparseFields())
+	ue.GET("/repos", HandlerFunc(func(c *Context) error {
+		return c.SetPayload(OK.WithData(repos))
+	}))
+	ue.GET("/repo", HandlerFunc(func(c *Context) error {
+		return c.SetPayload(OK.WithData(repos[0]))
+	}))
+
+	for _, tc := range []struct {
+		url, want string
+	}{
+		{"/repo?fields=id,owner.email", `{"ec":200,"em":"","data":{"id":1,"owner":{"email":"a@example.com"}}}`},
+		{"/repo?fields=owner,owner.email,missing", `{"ec":200,"em":"","data":{"owner":{"email":"a@example.com","name":"a"}}}`},
+		{"/repos?fields=name", `{"ec":200,"em":"","data":[{"name":"uecho"},{"name":"echo"}]}`},
+		{"/repo", `{"ec":200,"em":"","data":{"id":1,"name":"uecho","owner":{"name":"a","email":"a@example.com"}}}`},
+	} {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.url, nil))
+		if got := rec.Body.String(); got != tc.want+"\n" {
+			t.Errorf("%s: got %s, want %s", tc.url, got, tc.want)
+		}
+	}
+}
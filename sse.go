@@ -0,0 +1,117 @@
+package uecho
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// MIMETextEventStream SSE 响应类型
+const MIMETextEventStream = "text/event-stream"
+
+// SSEEvent 一个 SSE 事件
+type SSEEvent struct {
+	ID    string
+	Event string
+	// Data string、[]byte 原样输出（多行时拆分为多个 data 字段），其他类型以 JSON 编码
+	Data interface{}
+	// Retry 客户端断线重连的间隔
+	Retry time.Duration
+}
+
+// DefaultSSEShutdownEvent Shutdown 时 SSE 连接最后发送的事件，提示客户端稍后重连
+var DefaultSSEShutdownEvent = SSEEvent{Event: "shutdown", Data: "server shutting down", Retry: 3 * time.Second}
+
+func (ev SSEEvent) encode(buf *bytes.Buffer) error {
+	if ev.ID != "" {
+		buf.WriteString("id: " + ev.ID + "\n")
+	}
+	if ev.Event != "" {
+		buf.WriteString("event: " + ev.Event + "\n")
+	}
+	if ev.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(int64(ev.Retry/time.Millisecond), 10) + "\n")
+	}
+
+	var data string
+	switch d := ev.Data.(type) {
+	case nil:
+	case string:
+		data = d
+	case []byte:
+		data = string(d)
+	default:
+		b, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		buf.WriteString("data: " + line + "\n")
+	}
+	buf.WriteString("\n")
+	return nil
+}
+
+// SSEWriter SSE 连接
+type SSEWriter struct {
+	w   StreamWriter
+	ctx context.Context
+}
+
+// Send 发送事件并立即 Flush，客户端断开或服务 Shutdown 后返回 ctx 的错误
+func (s *SSEWriter) Send(ev SSEEvent) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	return s.send(ev)
+}
+
+func (s *SSEWriter) send(ev SSEEvent) error {
+	var buf bytes.Buffer
+	if err := ev.encode(&buf); err != nil {
+		return err
+	}
+	if _, err := s.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return s.w.Flush()
+}
+
+// Context 客户端断开连接、请求超时或服务 Shutdown 时 Done
+func (s *SSEWriter) Context() context.Context {
+	return s.ctx
+}
+
+// SSE 以 SSE 响应，fn 返回即结束连接。连接登记为长连接（见 TrackConn）：
+// Shutdown 时 w.Context() 结束，fn 返回后向客户端发送 SSEShutdownEvent
+func (c *Context) SSE(fn func(w *SSEWriter) error) error {
+	return c.StreamFunc(http.StatusOK, MIMETextEventStream, func(w StreamWriter) error {
+		ctx, cancel := context.WithCancel(w.Context())
+		defer cancel()
+
+		var shutdown int32
+		done := c.TrackConn(func() {
+			atomic.StoreInt32(&shutdown, 1)
+			cancel()
+		})
+		defer done()
+
+		sse := &SSEWriter{w: w, ctx: ctx}
+		err := fn(sse)
+		if atomic.LoadInt32(&shutdown) == 1 {
+			ev := DefaultSSEShutdownEvent
+			if c.ue.SSEShutdownEvent != nil {
+				ev = *c.ue.SSEShutdownEvent
+			}
+			return sse.send(ev)
+		}
+		return err
+	})
+}
@@ -0,0 +1,181 @@
+package uecho
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+type StaticCacheConfig struct {
+	// MaxEntries 最多缓存的文件数
+	// Optional. Default value 1024.
+	MaxEntries int
+
+	// MaxFileSize 只缓存不超过该大小的文件
+	// Optional. Default value 64KB.
+	MaxFileSize int64
+
+	// TTL 缓存有效期，过期后重新从磁盘读取
+	// Optional. Default value 1 minute.
+	TTL time.Duration
+}
+
+// StaticCache Static/File 使用的小文件 LRU 缓存，命中时不再访问磁盘（os.Stat + os.Open），
+// 并使用预先计算的 ETag。Debug 模式下不使用缓存。
+// 命中、未命中、淘汰次数记录在 DefaultMetrics 的 static_cache_hits/static_cache_misses/static_cache_evictions
+type StaticCache struct {
+	conf StaticCacheConfig
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+
+	hits      *Counter
+	misses    *Counter
+	evictions *Counter
+}
+
+type staticEntry struct {
+	name    string
+	data    []byte
+	modTime time.Time
+	etag    string
+	expires time.Time
+}
+
+func NewStaticCache(conf StaticCacheConfig) *StaticCache {
+	if conf.MaxEntries <= 0 {
+		conf.MaxEntries = 1024
+	}
+	if conf.MaxFileSize <= 0 {
+		conf.MaxFileSize = 64 << 10
+	}
+	if conf.TTL <= 0 {
+		conf.TTL = time.Minute
+	}
+	return &StaticCache{
+		conf:      conf,
+		ll:        list.New(),
+		items:     make(map[string]*list.Element),
+		hits:      DefaultMetrics.Counter("static_cache_hits"),
+		misses:    DefaultMetrics.Counter("static_cache_misses"),
+		evictions: DefaultMetrics.Counter("static_cache_evictions"),
+	}
+}
+
+// StaticCacheStats 缓存统计
+type StaticCacheStats struct {
+	Entries   int   `json:"entries"`
+	Bytes     int64 `json:"bytes"`
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+}
+
+// Stats 返回缓存统计（命中等计数为全部 StaticCache 的合计）
+func (sc *StaticCache) Stats() StaticCacheStats {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	stats := StaticCacheStats{
+		Entries:   sc.ll.Len(),
+		Hits:      sc.hits.Value(),
+		Misses:    sc.misses.Value(),
+		Evictions: sc.evictions.Value(),
+	}
+	for el := sc.ll.Front(); el != nil; el = el.Next() {
+		stats.Bytes += int64(len(el.Value.(*staticEntry).data))
+	}
+	return stats
+}
+
+func (sc *StaticCache) get(name string, now time.Time) (*staticEntry, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	el, ok := sc.items[name]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*staticEntry)
+	if now.After(entry.expires) {
+		sc.ll.Remove(el)
+		delete(sc.items, name)
+		return nil, false
+	}
+	sc.ll.MoveToFront(el)
+	return entry, true
+}
+
+func (sc *StaticCache) add(entry *staticEntry) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if el, ok := sc.items[entry.name]; ok {
+		el.Value = entry
+		sc.ll.MoveToFront(el)
+		return
+	}
+	sc.items[entry.name] = sc.ll.PushFront(entry)
+	for sc.ll.Len() > sc.conf.MaxEntries {
+		oldest := sc.ll.Back()
+		sc.ll.Remove(oldest)
+		delete(sc.items, oldest.Value.(*staticEntry).name)
+		sc.evictions.Inc()
+	}
+}
+
+// load 读取文件并加入缓存，目录、不存在或超过 MaxFileSize 的文件返回 false
+func (sc *StaticCache) load(name string, now time.Time) (*staticEntry, bool) {
+	fi, err := os.Stat(name)
+	if err != nil || fi.IsDir() || fi.Size() > sc.conf.MaxFileSize {
+		return nil, false
+	}
+	data, err := ioutil.ReadFile(name)
+	if err != nil || int64(len(data)) > sc.conf.MaxFileSize {
+		return nil, false
+	}
+
+	sum := sha1.Sum(data)
+	entry := &staticEntry{
+		name:    name,
+		data:    data,
+		modTime: fi.ModTime(),
+		etag:    `"` + hex.EncodeToString(sum[:]) + `"`,
+		expires: now.Add(sc.conf.TTL),
+	}
+	sc.add(entry)
+	return entry, true
+}
+
+// serve 从缓存输出文件，文件不可缓存时返回 false，由调用方按原流程处理
+func (sc *StaticCache) serve(c *Context, name string) (bool, error) {
+	now := time.Now()
+	entry, ok := sc.get(name, now)
+	if ok {
+		sc.hits.Inc()
+	} else {
+		sc.misses.Inc()
+		if entry, ok = sc.load(name, now); !ok {
+			return false, nil
+		}
+	}
+
+	c.SetRespHeader("ETag", entry.etag)
+	http.ServeContent(c.Response(), c.Request(), entry.name, entry.modTime, bytes.NewReader(entry.data))
+	return true, nil
+}
+
+// serveFile 输出文件，开启 StaticCache 时优先从缓存输出
+func serveFile(c *Context, name string) error {
+	if sc := c.ue.StaticCache; sc != nil && !c.Echo().Debug {
+		if ok, err := sc.serve(c, name); ok {
+			return err
+		}
+	}
+	noCacheInDebug(c)
+	return c.File(name)
+}
@@ -0,0 +1,106 @@
+package uecho
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStaticRange(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "video.bin"), []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ue := New(nil)
+	ue.Static("/assets", dir)
+
+	req := httptest.NewRequest(http.MethodGet, "/assets/video.bin", nil)
+	req.Header.Set("Range", "bytes=2-5")
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "2345" {
+		t.Fatalf("range: status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	if cr := rec.Header().Get("Content-Range"); cr != "bytes 2-5/10" {
+		t.Fatalf("Content-Range = %q", cr)
+	}
+
+	// If-Range 与文件不匹配时返回完整内容
+	req = httptest.NewRequest(http.MethodGet, "/assets/video.bin", nil)
+	req.Header.Set("Range", "bytes=2-5")
+	req.Header.Set("If-Range", "Mon, 02 Jan 2006 15:04:05 GMT")
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.Len() != 10 {
+		t.Fatalf("if-range: status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/assets/video.bin", nil))
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 ||
+		rec.Header().Get("Accept-Ranges") != "bytes" || rec.Header().Get("Content-Length") != "10" {
+		t.Fatalf("head: status = %d, headers = %v", rec.Code, rec.Header())
+	}
+}
+
+func TestStaticCache(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "app.js")
+	if err := ioutil.WriteFile(name, []byte("console.log(1)"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ue := New(nil)
+	ue.StaticCache = NewStaticCache(StaticCacheConfig{})
+	ue.Static("/assets", dir)
+
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/assets/app.js", nil))
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d, ETag = %q", rec.Code, etag)
+	}
+
+	// 删除文件后仍从缓存输出
+	if err := os.Remove(name); err != nil {
+		t.Fatal(err)
+	}
+	hits := ue.StaticCache.Stats().Hits
+	req := httptest.NewRequest(http.MethodGet, "/assets/app.js", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	ue.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if ue.StaticCache.Stats().Hits != hits+1 {
+		t.Fatal("expected a cache hit")
+	}
+}
+
+func TestStaticRedirectCode(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	ue := New(nil)
+	ue.Static("/assets", dir)
+
+	for code, want := range map[int]int{
+		0:                            http.StatusMovedPermanently,
+		http.StatusPermanentRedirect: http.StatusPermanentRedirect,
+		http.StatusOK:                http.StatusMovedPermanently,
+	} {
+		ue.RedirectCode = code
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/assets/docs", nil))
+		if rec.Code != want || rec.Header().Get("Location") != "/assets/docs/" {
+			t.Errorf("RedirectCode %d: status = %d, location = %q", code, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+}
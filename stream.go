@@ -0,0 +1,64 @@
+package uecho
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ErrStreamNotSupported 底层 ResponseWriter 不支持 Flush
+var ErrStreamNotSupported = errors.New("uecho: response writer does not support flushing")
+
+// StreamWriter 流式响应的 writer
+type StreamWriter interface {
+	// Write 写入数据，客户端断开后返回 ctx 的错误
+	Write(p []byte) (int, error)
+	// Flush 将已写入的数据立即发送给客户端
+	Flush() error
+	// Context 客户端断开连接（或请求超时）时 Done
+	Context() context.Context
+}
+
+type streamWriter struct {
+	c       *Context
+	flusher http.Flusher
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	if err := w.Context().Err(); err != nil {
+		return 0, err
+	}
+	return w.c.Response().Write(p)
+}
+
+func (w *streamWriter) Flush() error {
+	if err := w.Context().Err(); err != nil {
+		return err
+	}
+	w.flusher.Flush()
+	return nil
+}
+
+func (w *streamWriter) Context() context.Context {
+	return w.c.RequestContext()
+}
+
+// StreamFunc 以流式响应输出（日志 tail、NDJSON 等），由 fn 控制写入及 Flush 的时机
+// （echo.Context 已有 Stream(code, contentType, io.Reader)，故命名为 StreamFunc）
+func (c *Context) StreamFunc(code int, contentType string, fn func(w StreamWriter) error) error {
+	flusher, ok := c.Response().Writer.(http.Flusher)
+	if !ok {
+		return ErrStreamNotSupported
+	}
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, contentType)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no") // 关闭 nginx 的响应缓冲
+	c.Response().WriteHeader(code)
+	flusher.Flush()
+
+	return fn(&streamWriter{c: c, flusher: flusher})
+}
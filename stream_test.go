@@ -0,0 +1,34 @@
+package uecho
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStreamFunc(t *testing.T) {
+	ue := New(nil)
+	ue.GET("/feed", HandlerFunc(func(c *Context) error {
+		return c.StreamFunc(http.StatusOK, "application/x-ndjson", func(w StreamWriter) error {
+			for i := 0; i < 3; i++ {
+				if _, err := fmt.Fprintf(w, "{\"n\":%d}\n", i); err != nil {
+					return err
+				}
+				if err := w.Flush(); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}))
+
+	rec := httptest.NewRecorder()
+	ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed", nil))
+	if !rec.Flushed || rec.Body.String() != "{\"n\":0}\n{\"n\":1}\n{\"n\":2}\n" {
+		t.Fatalf("flushed = %v, body = %q", rec.Flushed, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("content type = %q", ct)
+	}
+}
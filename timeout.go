@@ -0,0 +1,157 @@
+package uecho
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// deadlineHandler 为 Request().Context() 加上 d 的处理时限，位于 UEcho#Use 的中间键之内（Logger 等可以看到 504）
+// 响应直接输出：超过时限后返回错误（因超时返回的错误或其他非 errReply 错误）且尚未写出响应时转换为 504，并记录路由名；
+// 已写出响应或成功返回（副作用可能已经提交）的请求不再转换，避免客户端重试非幂等请求
+func deadlineHandler(d time.Duration, h echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), d)
+		defer cancel()
+		c.SetRequest(c.Request().WithContext(ctx))
+
+		err := h(c)
+		if err == nil || c.Response().Committed {
+			return err
+		}
+		if _, replied := err.(*errReply); replied {
+			return err
+		}
+		if errors.Is(err, context.DeadlineExceeded) || ctx.Err() == context.DeadlineExceeded {
+			uc := c.(*Context)
+			er := uc.Abort(ErrGatewayTimeout).WithField("timeout", d.String())
+			if route := uc.Route(); route != nil {
+				er.WithField("route", route.Name)
+			}
+			return er.WithErr(err)
+		}
+		return err
+	}
+}
+
+// bufferWriter 缓冲响应状态码及响应体，由 flush 输出或 discard 丢弃；Flush、Hijack 之后直接输出
+type bufferWriter struct {
+	http.ResponseWriter
+	code      int
+	buf       bytes.Buffer
+	streaming bool
+}
+
+func (w *bufferWriter) WriteHeader(code int) {
+	if w.streaming {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *bufferWriter) Write(p []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(p)
+	}
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.buf.Write(p)
+}
+
+// flush 输出缓冲的响应
+func (w *bufferWriter) flush() error {
+	if w.streaming || w.code == 0 {
+		return nil
+	}
+	w.streaming = true
+	w.ResponseWriter.WriteHeader(w.code)
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// discard 丢弃缓冲的响应，之后可以重新写出响应（例如异常响应）
+func (w *bufferWriter) discard(res *echo.Response) {
+	w.code = 0
+	w.buf.Reset()
+	res.Committed, res.Status, res.Size = false, http.StatusOK, 0
+	res.Header().Del(echo.HeaderContentLength)
+}
+
+func (w *bufferWriter) Flush() {
+	w.flush()
+	w.streaming = true
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *bufferWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.streaming = true
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, errors.New("uecho: response writer does not support hijacking")
+}
+
+// RetryBudget 单个请求内下游调用可用的重试次数，可在多个 goroutine 中共享
+type RetryBudget struct {
+	remaining int32
+}
+
+// Take 消耗一次重试机会，预算用尽时返回 false
+func (b *RetryBudget) Take() bool {
+	if b == nil {
+		return false
+	}
+	for {
+		n := atomic.LoadInt32(&b.remaining)
+		if n <= 0 {
+			return false
+		}
+		if atomic.CompareAndSwapInt32(&b.remaining, n, n-1) {
+			return true
+		}
+	}
+}
+
+// Remaining 剩余的重试次数
+func (b *RetryBudget) Remaining() int {
+	if b == nil {
+		return 0
+	}
+	return int(atomic.LoadInt32(&b.remaining))
+}
+
+type retryBudgetKey struct{}
+
+// RetryBudgetFromContext 返回 ctx 中的重试预算，未设置时返回 nil（Take 总是返回 false）
+func RetryBudgetFromContext(ctx context.Context) *RetryBudget {
+	b, _ := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return b
+}
+
+// RetryBudget 当前请求的重试预算，下游调用重试前应先调用 Take
+func (c *Context) RetryBudget() *RetryBudget {
+	return RetryBudgetFromContext(c.RequestContext())
+}
+
+// retryBudgetHandler 为请求设置 n 次重试预算
+func retryBudgetHandler(n int, h echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		ctx := context.WithValue(c.Request().Context(), retryBudgetKey{}, &RetryBudget{remaining: int32(n)})
+		c.SetRequest(c.Request().WithContext(ctx))
+		return h(c)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -58,17 +59,11 @@ func WrapUHandler(h echo.HandlerFunc) Handler {
 
 var (
 	NotFoundHandler = HandlerFunc(func(c *Context) error {
-		err := errReplyPool.Get().(*errReply)
-		err.reset()
-		err.Reply = ErrNotFound
-		return err
+		return acquireErrReply(ErrNotFound)
 	})
 
 	MethodNotAllowedHandler = HandlerFunc(func(c *Context) error {
-		err := errReplyPool.Get().(*errReply)
-		err.reset()
-		err.Reply = ErrMethodNotAllowed
-		return err
+		return acquireErrReply(ErrMethodNotAllowed)
 	})
 )
 
@@ -79,7 +74,20 @@ https://github.com/sevennt/echo-pprof
 // Common struct for Echo & Group.
 type common struct{}
 
-func (common) static(prefix, root string, get func(string, Handler, ...echo.MiddlewareFunc) *echo.Route) *echo.Route {
+// addFunc UEcho#Add / Group#Add
+type addFunc func(method, path string, h Handler, m ...echo.MiddlewareFunc) *Route
+
+// getAndHead 同时注册 GET 与 HEAD 路由，返回 GET 路由
+// 文件经 c.File（http.ServeContent）输出，支持 Range/If-Range（206）及 HEAD
+func getAndHead(add addFunc) func(string, Handler, ...echo.MiddlewareFunc) *Route {
+	return func(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
+		add(http.MethodHead, path, h, m...)
+		return add(http.MethodGet, path, h, m...)
+	}
+}
+
+func (common) static(prefix, root string, add addFunc) *Route {
+	get := getAndHead(add)
 	hfunc := func(c *Context) error {
 		p, err := url.PathUnescape(c.Param("*"))
 		if err != nil {
@@ -87,6 +95,11 @@ func (common) static(prefix, root string, get func(string, Handler, ...echo.Midd
 		}
 
 		name := filepath.Join(root, filepath.Clean("/"+p)) // "/"+ for security
+		if sc := c.ue.StaticCache; sc != nil && !c.Echo().Debug {
+			if ok, err := sc.serve(c, name); ok {
+				return err
+			}
+		}
 		fi, err := os.Stat(name)
 		if err != nil {
 			// The access path does not exist
@@ -97,8 +110,9 @@ func (common) static(prefix, root string, get func(string, Handler, ...echo.Midd
 		p = c.Request().URL.Path // path must not be empty.
 		if fi.IsDir() && p[len(p)-1] != '/' {
 			// Redirect to ends with "/"
-			return c.Redirect(http.StatusMovedPermanently, p+"/")
+			return c.Redirect(c.ue.redirectCode(), p+"/")
 		}
+		noCacheInDebug(c)
 		return c.File(name)
 	}
 	h := HandlerFunc(hfunc)
@@ -115,10 +129,17 @@ func (common) static(prefix, root string, get func(string, Handler, ...echo.Midd
 	return get(prefix+"/*", h)
 }
 
-func (common) file(path, file string, get func(string, Handler, ...echo.MiddlewareFunc) *echo.Route,
-	m ...echo.MiddlewareFunc) *echo.Route {
+// noCacheInDebug Debug 模式下要求客户端每次都重新校验静态文件，避免前端修改后读到旧缓存
+func noCacheInDebug(c *Context) {
+	if c.Echo().Debug {
+		c.SetRespHeader("Cache-Control", "no-cache")
+	}
+}
+
+func (common) file(path, file string, add addFunc, m ...echo.MiddlewareFunc) *Route {
+	get := getAndHead(add)
 	f := func(c *Context) error {
-		return c.File(file)
+		return serveFile(c, file)
 	}
 	return get(path, HandlerFunc(f), m...)
 }
@@ -133,6 +154,100 @@ type UEcho struct {
 	pool          sync.Pool
 	router        *Router
 	routers       map[string]*Router
+	hostPatterns  []hostPattern
+	groups        []*Group
+
+	// ErrorPage 不为 nil 时，对偏好 text/html 的客户端渲染 HTML 错误页
+	ErrorPage *ErrorPageConfig
+
+	// Negotiator c.Respond 使用的响应格式协商
+	Negotiator *Negotiator
+
+	// ErrorRenderers DefaultHTTPErrorHandler 按 Accept 选择的异常响应格式
+	ErrorRenderers *ErrorRenderers
+
+	// ProblemJSON 为 true 时，原本以 JSON 信封输出的异常改为 application/problem+json（RFC 7807），
+	// 分组可通过 Group.ProblemJSON 单独开启
+	ProblemJSON bool
+
+	// ProblemType 生成 problem+json 的 type（例如文档地址 + ec），为 nil 时为 about:blank
+	ProblemType func(r Reply) string
+
+	// LangResolvers 解析请求语言的优先级列表，c.Lang() 依次尝试，为空时使用 LANG_DEFAULT
+	//  e.LangResolvers = []uecho.LangResolver{uecho.LangFromQuery("lang"), uecho.LangFromCookie("lang"), uecho.LangFromHeader()}
+	LangResolvers []LangResolver
+
+	// LocalizeEM 为 true 时，SetPayload 及异常响应的 em 使用 ec 在请求语言（c.Lang()）下的描述，
+	// 没有对应的描述时使用 Reply 自身的 em
+	LocalizeEM bool
+
+	// JSONPParam 不为空时开启 JSONP，请求携带该 query 参数时 SetPayload 及异常响应以 JSONP 形式输出
+	JSONPParam string
+
+	// RouteTimeout 未通过 Route.Timeout 单独设置时限的路由使用的默认处理时限，0 表示不限制
+	RouteTimeout time.Duration
+
+	// RetryBudget 未通过 Route.RetryBudget 单独设置的路由，每个请求内下游调用可用的重试次数
+	RetryBudget int
+
+	// DrainOnShutdown 为 true 时，进入 drain 状态后新到达的请求（keep-alive 连接上的）直接以 503 响应并关闭连接
+	DrainOnShutdown bool
+
+	// DrainDelay Shutdown 进入 drain 状态后、关闭服务前等待的时间，留给负载均衡摘除实例
+	DrainDelay time.Duration
+
+	// ShutdownGrace Shutdown 通知长连接（SSE、WebSocket，见 TrackConn）结束后，等待其处理函数返回的最长时间
+	// Optional. Default value 5s.
+	ShutdownGrace time.Duration
+
+	// SSEShutdownEvent Shutdown 时 SSE 连接最后发送的事件
+	// Optional. Default value DefaultSSEShutdownEvent.
+	SSEShutdownEvent *SSEEvent
+
+	// WarmupTimeout 预热任务（见 AddWarmup）全部执行完成的时限，0 表示不限制
+	WarmupTimeout time.Duration
+
+	// RequestIDGenerator 请求未携带或携带不合法（超长、含字母数字及 - _ . : 以外的字符）的 X-Request-Id 时生成请求 id（在 Pre 中间键之前）
+	// Optional. Default value 16 字节随机数的 hex.
+	RequestIDGenerator func() string
+
+	// Jobs c.Async 使用的异步任务池，为 nil 时第一次调用 c.Async 以默认配置创建
+	Jobs *Jobs
+
+	// AutoOptions 为 true 时，已注册路径上未注册 OPTIONS 路由的 OPTIONS 请求以 204 及 Allow 头响应
+	AutoOptions bool
+
+	// ShowRoutes 为 true 时，启动时在 banner 下输出路由表（HideBanner 时不输出）
+	ShowRoutes bool
+
+	// RoutesJSON 为 true 时，启动时输出的路由表为 JSON 格式
+	RoutesJSON bool
+
+	// ValidateRoutes 为 true 时，启动时检查路由表（见 Validate），问题以 warn 级别输出
+	ValidateRoutes bool
+
+	// RedirectCode Static 目录补全 "/" 等规范化重定向使用的状态码（301/302/303/307/308），
+	// 301 会被浏览器长期缓存且 POST 会变为 GET，需要时可改为 307/308
+	// Optional. Default value 301.
+	RedirectCode int
+
+	// StaticCache 不为 nil 时，Static/File 的小文件从内存缓存输出
+	StaticCache *StaticCache
+
+	// PoolDebug 不为 nil 时跟踪 Context 的获取与释放，检测泄漏及重复释放（见 NewPoolDebug），只用于排查问题
+	PoolDebug *PoolDebug
+
+	// Metrics c.Metric 使用的指标注册表
+	Metrics *Metrics
+
+	errorMappers []ErrorMapper
+	draining     int32
+	drainStart   int64 // unix nano
+	conns        connRegistry
+	hub          *Hub
+	hubOnce      sync.Once
+	jobsOnce     sync.Once
+	warmup       warmupRegistry
 }
 
 func New(logger *logrus.Logger) *UEcho {
@@ -143,17 +258,33 @@ func New(logger *logrus.Logger) *UEcho {
 	e.Server.Handler = e
 	e.TLSServer.Handler = e
 	e.pool.New = func() interface{} {
-		c := new(Context)
+		contextPoolNews.Inc()
+		c := &Context{ue: e}
 		c.setLogrus(logger)
 		c.init(e.Echo.AcquireContext())
 		return c
 	}
 	e.HTTPErrorHandler = e.DefaultHTTPErrorHandler
+	e.Negotiator = NewNegotiator()
+	e.Binder = NewBinder()
+	e.Validator = NewValidator()
+	e.ErrorRenderers = NewErrorRenderers()
+	e.Metrics = DefaultMetrics
 
 	e.router = NewRouter(e)
 	return e
 }
 
+// redirectCode 规范化重定向使用的状态码，未配置或不是重定向状态码时为 301
+func (e *UEcho) redirectCode() int {
+	switch e.RedirectCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return e.RedirectCode
+	}
+	return http.StatusMovedPermanently
+}
+
 // Router returns the default router.
 func (e *UEcho) Router() *Router {
 	return e.router
@@ -164,6 +295,42 @@ func (e *UEcho) Routers() map[string]*Router {
 	return e.routers
 }
 
+// ErrorMapper 将 error 转换为 Reply，不能处理时返回 false
+type ErrorMapper func(err error) (Reply, bool)
+
+// RegisterErrorMapper 注册 ErrorMapper，DefaultHTTPErrorHandler 按注册顺序依次尝试，
+// 用于集中将 sql.ErrNoRows、context.DeadlineExceeded 等错误转换为对应的 Reply
+//
+//	e.RegisterErrorMapper(func(err error) (uecho.Reply, bool) {
+//		if errors.Is(err, sql.ErrNoRows) {
+//			return uecho.ErrNotFound, true
+//		}
+//		return nil, false
+//	})
+func (e *UEcho) RegisterErrorMapper(mappers ...ErrorMapper) {
+	e.errorMappers = append(e.errorMappers, mappers...)
+}
+
+// toErrReply 将 err 转换为 *errReply：errReply 直接使用，其次依次尝试 ErrorMapper，
+// 然后是 echo.HTTPError，其余为 500
+func (e *UEcho) toErrReply(err error) *errReply {
+	if er, ok := err.(*errReply); ok {
+		return er
+	}
+	for _, mapper := range e.errorMappers {
+		if r, ok := mapper(err); ok {
+			er := acquireErrReply(r)
+			er.err = err
+			return er
+		}
+	}
+	if echoHttpErr, ok := err.(*echo.HTTPError); ok {
+		return acquireErrReply(NewReply(echoHttpErr.Code, echoHttpErr.Code, fmt.Sprint(echoHttpErr.Message)))
+	}
+	return acquireErrReply(NewReply(http.StatusInternalServerError,
+		http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError)))
+}
+
 // DefaultHTTPErrorHandler is the default HTTP error handler. It sends a JSON response
 // with status code.
 func (e *UEcho) DefaultHTTPErrorHandler(err error, c echo.Context) {
@@ -172,36 +339,48 @@ func (e *UEcho) DefaultHTTPErrorHandler(err error, c echo.Context) {
 	}
 
 	// Send response
-	var er *errReply
-	er, ok := err.(*errReply)
-	if !ok {
-		if echoHttpErr, ok := err.(*echo.HTTPError); ok {
-			er = errReplyPool.Get().(*errReply)
-			er.reset()
-			er.Reply = NewReply(echoHttpErr.Code, echoHttpErr.Code, fmt.Sprint(echoHttpErr.Message))
-		} else {
-			er = errReplyPool.Get().(*errReply)
-			er.reset()
-			er.Reply = NewReply(http.StatusInternalServerError,
-				http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
-		}
-	}
-	defer func() {
-		errReplyPool.Put(er)
-	}()
+	er := e.toErrReply(err)
 
+	uc, _ := c.(*Context)
 	code := er.EC()
 	message := er.EM()
+	if uc != nil {
+		message = uc.em(er.Reply)
+	}
 	if e.Debug {
 		message = er.Error()
 	}
 
+	if uc != nil {
+		uc.countReply(code)
+		uc.releaseOnFinish(er)
+	} else {
+		defer releaseErrReply(er)
+	}
 	if c.Request().Method == http.MethodHead { // Issue #608
 		err = c.NoContent(code)
+	} else if uc != nil && uc.rawMode() {
+		err = c.String(er.HTTPCode(), message)
+	} else if callback := uc.jsonpCallback(); callback != "" && validJSONPCallback(callback) {
+		err = c.JSONP(http.StatusOK, callback, &HttpApiResponse{
+			EC:        code,
+			EM:        message,
+			Data:      replyData(er.Reply),
+			RequestID: uc.RequestID(),
+		})
+	} else if uc != nil && e.ErrorRenderers != nil {
+		c.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+		mediaType, render := e.ErrorRenderers.negotiateHTML(c.Request().Header.Get(echo.HeaderAccept), e.ErrorPage != nil)
+		if mediaType == echo.MIMEApplicationJSON && e.problemJSON(uc) {
+			render = e.ErrorRenderers.problemJSON()
+		}
+		err = render(uc, NewReply(er.HTTPCode(), code, message).WithData(replyData(er.Reply)))
 	} else {
 		err = c.JSON(er.HTTPCode(), &HttpApiResponse{
-			EC: code,
-			EM: message,
+			EC:        code,
+			EM:        message,
+			Data:      replyData(er.Reply),
+			RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
 		})
 	}
 	if err != nil {
@@ -221,62 +400,62 @@ func (e *UEcho) Use(middleware ...echo.MiddlewareFunc) {
 
 // CONNECT registers a new CONNECT route for a path with matching handler in the
 // router with optional route-level middleware.
-func (e *UEcho) CONNECT(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (e *UEcho) CONNECT(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return e.Add(http.MethodConnect, path, h, m...)
 }
 
 // DELETE registers a new DELETE route for a path with matching handler in the router
 // with optional route-level middleware.
-func (e *UEcho) DELETE(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (e *UEcho) DELETE(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return e.Add(http.MethodDelete, path, h, m...)
 }
 
 // GET registers a new GET route for a path with matching handler in the router
 // with optional route-level middleware.
-func (e *UEcho) GET(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (e *UEcho) GET(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return e.Add(http.MethodGet, path, h, m...)
 }
 
 // HEAD registers a new HEAD route for a path with matching handler in the
 // router with optional route-level middleware.
-func (e *UEcho) HEAD(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (e *UEcho) HEAD(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return e.Add(http.MethodHead, path, h, m...)
 }
 
 // OPTIONS registers a new OPTIONS route for a path with matching handler in the
 // router with optional route-level middleware.
-func (e *UEcho) OPTIONS(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (e *UEcho) OPTIONS(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return e.Add(http.MethodOptions, path, h, m...)
 }
 
 // PATCH registers a new PATCH route for a path with matching handler in the
 // router with optional route-level middleware.
-func (e *UEcho) PATCH(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (e *UEcho) PATCH(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return e.Add(http.MethodPatch, path, h, m...)
 }
 
 // POST registers a new POST route for a path with matching handler in the
 // router with optional route-level middleware.
-func (e *UEcho) POST(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (e *UEcho) POST(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return e.Add(http.MethodPost, path, h, m...)
 }
 
 // PUT registers a new PUT route for a path with matching handler in the
 // router with optional route-level middleware.
-func (e *UEcho) PUT(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (e *UEcho) PUT(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return e.Add(http.MethodPut, path, h, m...)
 }
 
 // TRACE registers a new TRACE route for a path with matching handler in the
 // router with optional route-level middleware.
-func (e *UEcho) TRACE(path string, h Handler, m ...echo.MiddlewareFunc) *echo.Route {
+func (e *UEcho) TRACE(path string, h Handler, m ...echo.MiddlewareFunc) *Route {
 	return e.Add(http.MethodTrace, path, h, m...)
 }
 
 // Any registers a new route for all HTTP methods and path with matching handler
 // in the router with optional route-level middleware.
-func (e *UEcho) Any(path string, handler Handler, middleware ...echo.MiddlewareFunc) []*echo.Route {
-	routes := make([]*echo.Route, len(methods))
+func (e *UEcho) Any(path string, handler Handler, middleware ...echo.MiddlewareFunc) []*Route {
+	routes := make([]*Route, len(methods))
 	for i, m := range methods {
 		routes[i] = e.Add(m, path, handler, middleware...)
 	}
@@ -285,8 +464,8 @@ func (e *UEcho) Any(path string, handler Handler, middleware ...echo.MiddlewareF
 
 // Match registers a new route for multiple HTTP methods and path with matching
 // handler in the router with optional route-level middleware.
-func (e *UEcho) Match(methods []string, path string, handler Handler, middleware ...echo.MiddlewareFunc) []*echo.Route {
-	routes := make([]*echo.Route, len(methods))
+func (e *UEcho) Match(methods []string, path string, handler Handler, middleware ...echo.MiddlewareFunc) []*Route {
+	routes := make([]*Route, len(methods))
 	for i, m := range methods {
 		routes[i] = e.Add(m, path, handler, middleware...)
 	}
@@ -295,35 +474,44 @@ func (e *UEcho) Match(methods []string, path string, handler Handler, middleware
 
 // Static registers a new route with path prefix to serve static files from the
 // provided root directory.
-func (e *UEcho) Static(prefix, root string) *echo.Route {
+func (e *UEcho) Static(prefix, root string) *Route {
 	if root == "" {
 		root = "." // For security we want to restrict to CWD.
 	}
-	return e.static(prefix, root, e.GET)
+	return e.static(prefix, root, e.Add)
 }
 
 // File registers a new route with path to serve a static file with optional route-level middleware.
-func (e *UEcho) File(path, file string, m ...echo.MiddlewareFunc) *echo.Route {
-	return e.file(path, file, e.GET, m...)
+func (e *UEcho) File(path, file string, m ...echo.MiddlewareFunc) *Route {
+	return e.file(path, file, e.Add, m...)
 }
 
 // Add registers a new route for an HTTP method and path with matching handler
 // in the router with optional route-level middleware.
-func (e *UEcho) Add(method, path string, handler Handler, middleware ...echo.MiddlewareFunc) *echo.Route {
+func (e *UEcho) Add(method, path string, handler Handler, middleware ...echo.MiddlewareFunc) *Route {
 	return e.add("", method, path, handler, middleware...) //e.Echo.Add(method, path, WrapHandler(handler), middleware...)
 }
 
 // Host creates a new router group for the provided host and optional host-level middleware.
+// name 可包含参数，例如 {tenant}.api.example.com，参数通过 c.Param("tenant") 获取
+// host 级别的中间键保存在 host 对应的 Router 上，作用于该 host 的全部请求；重复调用复用同一个 Router
 func (e *UEcho) Host(name string, m ...echo.MiddlewareFunc) (g *Group) {
-	e.routers[name] = NewRouter(e)
-	g = &Group{host: name, echo: e}
-	g.Use(m...)
-	return
+	router, ok := e.routers[name]
+	if !ok {
+		router = NewRouter(e)
+		e.routers[name] = router
+		if isHostPattern(name) {
+			e.hostPatterns = append(e.hostPatterns, newHostPattern(name, router))
+		}
+	}
+	router.Use(m...)
+	return &Group{host: name, echo: e}
 }
 
 // Group creates a new router group with prefix and optional group-level middleware.
 func (e *UEcho) Group(prefix string, m ...echo.MiddlewareFunc) (g *Group) {
 	g = &Group{prefix: prefix, echo: e}
+	e.groups = append(e.groups, g)
 	g.Use(m...)
 	return
 }
@@ -345,37 +533,151 @@ func handlerName(h Handler) string {
 	return t.String()
 }
 
-func (e *UEcho) add(host, method, path string, handler Handler, middleware ...echo.MiddlewareFunc) *echo.Route {
+func (e *UEcho) add(host, method, path string, handler Handler, middleware ...echo.MiddlewareFunc) *Route {
+	// 与 echo.Router#Add 保持一致，保证与 c.Path() 相同
+	if path == "" {
+		path = "/"
+	}
+	if path[0] != '/' {
+		path = "/" + path
+	}
+
 	name := handlerName(handler)
 	router := e.findRouter(host)
 	router.Add(method, path, HandlerFunc(func(c *Context) error {
 		h := applyMiddleware(WrapHandler(handler), middleware...)
 		return h(c)
 	}))
-	r := &echo.Route{
-		Method: method,
-		Path:   path,
-		Name:   name,
-	}
-	e.router.routes[method+path] = r
+	r := &Route{
+		Route: echo.Route{
+			Method: method,
+			Path:   path,
+			Name:   name,
+		},
+		Host:       host,
+		CallSite:   callSite(),
+		Middleware: len(middleware),
+	}
+	if old, ok := router.routes[method+path]; ok {
+		router.replaced = append(router.replaced, old)
+	}
+	router.routes[method+path] = r
 	return r
 }
 
+// Context 池及路由查找的计数，通过 DefaultMetrics 导出
+// router_find_nanoseconds / router_finds 为路由查找的平均耗时
+var (
+	contextPoolGets = DefaultMetrics.Counter("context_pool_gets")
+	contextPoolPuts = DefaultMetrics.Counter("context_pool_puts")
+	contextPoolNews = DefaultMetrics.Counter("context_pool_news")
+	routerFinds     = DefaultMetrics.Counter("router_finds")
+	routerFindNanos = DefaultMetrics.Counter("router_find_nanoseconds")
+)
+
 // AcquireContext returns an empty `Context` instance from the pool.
 // You must return the context by calling `ReleaseContext()`.
 func (e *UEcho) AcquireContext() *Context {
+	contextPoolGets.Inc()
 	c := e.pool.Get().(*Context)
 	c.init(e.Echo.AcquireContext())
+	if e.PoolDebug != nil {
+		e.PoolDebug.acquire(c)
+	}
 	return c
 }
 
 // ReleaseContext returns the `Context` instance back to the pool.
 // You must call it after `AcquireContext()`.
 func (e *UEcho) ReleaseContext(c *Context) {
+	// 调试模式下释放的 Context 不再放回池中，重复释放时直接返回
+	if e.PoolDebug != nil && !e.PoolDebug.release(c) {
+		return
+	}
 	ec := c.Context
 	c.reset()
 	e.Echo.ReleaseContext(ec)
-	e.pool.Put(c)
+	contextPoolPuts.Inc()
+	if e.PoolDebug == nil {
+		e.pool.Put(c)
+	}
+}
+
+// find 查找请求对应的 handler，并将匹配到的 Route 记录到 Context，返回请求 host 对应的 Router
+func (e *UEcho) find(c *Context) *Router {
+	r := c.Request()
+	router := e.matchRouter(c, r.Host)
+	start := time.Now()
+	router.Find(r.Method, GetPath(r), c.Context)
+	routerFinds.Inc()
+	routerFindNanos.Add(int64(time.Since(start)))
+	c.route = router.route(r.Method, c.Path())
+	if c.route == nil && isEchoMethodNotAllowed(c.Handler()) {
+		if allowed := router.allow(c.Path()); len(allowed) > 0 {
+			if e.AutoOptions {
+				allowed = append(allowed, http.MethodOptions)
+			}
+			// Allow 头在执行中间键前设置，CORS 中间键直接响应预检请求时同样带有该头
+			c.Response().Header().Set(echo.HeaderAllow, strings.Join(allowed, ", "))
+			if e.AutoOptions && r.Method == http.MethodOptions {
+				c.SetHandler(optionsHandler)
+			} else {
+				c.SetHandler(methodNotAllowedHandler)
+			}
+		}
+	}
+	return router
+}
+
+var echoMethodNotAllowed = reflect.ValueOf(echo.MethodNotAllowedHandler).Pointer()
+
+// isEchoMethodNotAllowed h 是否为 echo.Router 在路径匹配但 method 不匹配时设置的 handler
+func isEchoMethodNotAllowed(h echo.HandlerFunc) bool {
+	return h != nil && reflect.ValueOf(h).Pointer() == echoMethodNotAllowed
+}
+
+// optionsHandler 未注册 OPTIONS 路由时的 OPTIONS 响应（204），
+// 经过 UEcho#Use 的中间键，启用 CORS 中间键时由其补充跨域响应头或直接响应预检请求
+func optionsHandler(c echo.Context) error {
+	return c.NoContent(http.StatusNoContent)
+}
+
+// methodNotAllowedHandler 路径匹配但 method 不匹配时以 MethodNotAllowedHandler 响应
+func methodNotAllowedHandler(c echo.Context) error {
+	return MethodNotAllowedHandler.Handle(c.(*Context))
+}
+
+// routeHandler 查找路由并返回完整的处理链
+func (e *UEcho) routeHandler(c *Context) echo.HandlerFunc {
+	router := e.find(c)
+	h := applyMiddleware(c.Handler(), router.middleware...)
+
+	d := c.route.timeout()
+	if d == 0 {
+		d = e.RouteTimeout
+	}
+	if d > 0 {
+		h = deadlineHandler(d, h)
+	}
+	h = applyMiddleware(h, e.middleware...)
+
+	n, ok := c.route.retries()
+	if !ok {
+		n = e.RetryBudget
+	}
+	if n > 0 {
+		h = retryBudgetHandler(n, h)
+	}
+	return h
+}
+
+// handler 返回 Pre 中间键之后的处理链：drain 状态下（见 DrainOnShutdown）新请求经过 UEcho#Use 的中间键后
+// 以 drainHandler 响应，访问日志、指标同样可以看到 503；否则查找路由
+func (e *UEcho) handler(c *Context) echo.HandlerFunc {
+	if e.DrainOnShutdown && e.Draining() {
+		return applyMiddleware(drainHandler, e.middleware...)
+	}
+	return e.routeHandler(c)
 }
 
 // ServeHTTP implements `http.Handler` interface, which serves HTTP requests.
@@ -383,27 +685,28 @@ func (e *UEcho) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Acquire context
 	c := e.AcquireContext()
 	c.Reset(r, w)
+	if e.PoolDebug != nil {
+		e.PoolDebug.started(c)
+	}
+	e.ensureRequestID(c)
 	h := echo.NotFoundHandler
 
 	if e.premiddleware == nil {
-		e.findRouter(r.Host).Find(r.Method, GetPath(r), c.Context)
-		h = c.Handler()
-		h = applyMiddleware(h, e.middleware...)
+		h = e.handler(c)
 	} else {
 		h = func(c echo.Context) error {
-			uc := c.(*Context)
-			e.findRouter(r.Host).Find(r.Method, GetPath(r), uc.Context)
-			h = c.Handler()
-			h = applyMiddleware(h, e.middleware...)
+			h = e.handler(c.(*Context))
 			return h(c)
 		}
 		h = applyMiddleware(h, e.premiddleware...)
 	}
 
 	// Execute chain
-	if err := h(c); err != nil {
+	err := h(c)
+	if err != nil {
 		e.HTTPErrorHandler(err, c)
 	}
+	c.finish(err)
 
 	// Release context
 	e.ReleaseContext(c)
@@ -517,6 +820,8 @@ func (e *UEcho) configureServer(s *http.Server) (err error) {
 	if !e.HideBanner {
 		fmt.Printf(banner, "v"+echo.Version, website)
 	}
+	e.printRoutesOnStart()
+	e.validateOnStart()
 
 	if s.TLSConfig == nil {
 		if e.Listener == nil {
@@ -528,6 +833,7 @@ func (e *UEcho) configureServer(s *http.Server) (err error) {
 		if !e.HidePort {
 			fmt.Printf("⇨ http server started on %s\n", e.Listener.Addr())
 		}
+		go e.WarmUp(context.Background())
 		return nil
 	}
 	if e.TLSListener == nil {
@@ -540,6 +846,7 @@ func (e *UEcho) configureServer(s *http.Server) (err error) {
 	if !e.HidePort {
 		fmt.Printf("⇨ https server started on %s\n", e.TLSListener.Addr())
 	}
+	go e.WarmUp(context.Background())
 	return nil
 }
 
@@ -559,6 +866,8 @@ func (e *UEcho) StartH2CServer(address string, h2s *http2.Server) (err error) {
 	if !e.HideBanner {
 		fmt.Printf(banner, "v"+echo.Version, website)
 	}
+	e.printRoutesOnStart()
+	e.validateOnStart()
 
 	if e.Listener == nil {
 		e.Listener, err = newListener(s.Addr, e.ListenerNetwork)
@@ -570,6 +879,7 @@ func (e *UEcho) StartH2CServer(address string, h2s *http2.Server) (err error) {
 	if !e.HidePort {
 		fmt.Printf("⇨ http server started on %s\n", e.Listener.Addr())
 	}
+	go e.WarmUp(context.Background())
 	e.startupMutex.Unlock()
 	return s.Serve(e.Listener)
 }
@@ -587,13 +897,31 @@ func (e *UEcho) Close() error {
 
 // Shutdown stops the server gracefully.
 // It internally calls `http.Server#Shutdown()`.
+// 关闭前会先进入 drain 状态（见 Drain），并等待 DrainDelay，再通知长连接结束并等待 ShutdownGrace；
+// 服务关闭后等待异步任务（Jobs）处理完成。
+// ctx 先于 DrainDelay 结束时同样关闭监听及异步任务，之后返回 ctx 的错误
 func (e *UEcho) Shutdown(ctx context.Context) error {
+	e.Drain()
+	delayErr := e.waitDrainDelay(ctx)
+	e.shutdownConns(ctx)
+
 	e.startupMutex.Lock()
 	defer e.startupMutex.Unlock()
-	if err := e.TLSServer.Shutdown(ctx); err != nil {
-		return err
+	err := e.TLSServer.Shutdown(ctx)
+	if serr := e.Server.Shutdown(ctx); err == nil {
+		err = serr
+	}
+	// 通过 jobsOnce 与第一次 c.Async 时的创建同步：没有使用过 c.Async 时不再创建任务池，之后的 c.Async 返回 503
+	e.jobsOnce.Do(func() {})
+	if e.Jobs != nil {
+		if jerr := e.Jobs.Close(ctx); err == nil {
+			err = jerr
+		}
+	}
+	if delayErr != nil {
+		return delayErr
 	}
-	return e.Server.Shutdown(ctx)
+	return err
 }
 
 // GetPath returns RawPath, if it's empty returns Path from URL
@@ -0,0 +1,262 @@
+package uecho
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// HeaderChecksumSHA256 multipart 文件 part 的 sha256 校验和（hex），与 Content-MD5（base64）一样由 Upload 校验
+const HeaderChecksumSHA256 = "X-Checksum-Sha256"
+
+type UploadConfig struct {
+	// Dir 上传文件的临时目录
+	// Optional. Default value os.TempDir().
+	Dir string
+
+	// MaxPartSize 单个文件的大小上限
+	// Optional. Default value 32MB.
+	MaxPartSize int64
+
+	// MaxFieldSize 单个普通表单字段的大小上限，普通字段保存在内存中
+	// Optional. Default value 1MB.
+	MaxFieldSize int64
+
+	// MaxTotalSize 请求体的大小上限，小于 0 表示不限制
+	// Optional. Default value 64MB.
+	MaxTotalSize int64
+
+	// MaxParts part（文件及普通字段）数量上限，小于 0 表示不限制
+	// Optional. Default value 32.
+	MaxParts int
+
+	// Progress 每次从请求体读取数据后调用，可将进度保存下来供查询上传进度的接口使用
+	Progress func(p UploadProgress)
+}
+
+// UploadProgress 上传进度
+type UploadProgress struct {
+	// Field、Filename 正在读取的文件 part
+	Field    string
+	Filename string
+	// Bytes 已读取的请求体字节数
+	Bytes int64
+	// Total 请求体总字节数（Content-Length），未知时为 -1
+	Total int64
+	// Done 请求体已读取完毕
+	Done bool
+}
+
+// UploadedFile 保存到临时目录的上传文件
+type UploadedFile struct {
+	Field       string `json:"field"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	// Path 临时文件路径，处理完成后应移动或调用 Remove 删除
+	Path   string `json:"-"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Open 打开临时文件
+func (f *UploadedFile) Open() (*os.File, error) {
+	return os.Open(f.Path)
+}
+
+// Remove 删除临时文件
+func (f *UploadedFile) Remove() error {
+	return os.Remove(f.Path)
+}
+
+// UploadResult 上传的文件及普通表单字段
+type UploadResult struct {
+	Files  []*UploadedFile
+	Values url.Values
+}
+
+// File 返回表单字段 field 的第一个文件
+func (r *UploadResult) File(field string) *UploadedFile {
+	for _, f := range r.Files {
+		if f.Field == field {
+			return f
+		}
+	}
+	return nil
+}
+
+// RemoveAll 删除全部临时文件
+func (r *UploadResult) RemoveAll() {
+	for _, f := range r.Files {
+		f.Remove()
+	}
+}
+
+// DefaultUploadConfig is the default upload config.
+var DefaultUploadConfig = UploadConfig{
+	MaxPartSize:  32 << 20,
+	MaxFieldSize: 1 << 20,
+	MaxTotalSize: 64 << 20,
+	MaxParts:     32,
+}
+
+// Upload 逐个 part 读取 multipart 请求体，文件直接写入临时目录（不会整个读入内存），
+// 并校验 part 携带的 Content-MD5 / X-Checksum-Sha256；超过大小或数量限制时返回 ErrRequestEntityTooLarge，
+// 出错时已写入的临时文件会被删除
+func (c *Context) Upload(conf UploadConfig) (*UploadResult, error) {
+	if conf.Dir == "" {
+		conf.Dir = os.TempDir()
+	}
+	if conf.MaxPartSize <= 0 {
+		conf.MaxPartSize = DefaultUploadConfig.MaxPartSize
+	}
+	if conf.MaxFieldSize <= 0 {
+		conf.MaxFieldSize = DefaultUploadConfig.MaxFieldSize
+	}
+	if conf.MaxTotalSize == 0 {
+		conf.MaxTotalSize = DefaultUploadConfig.MaxTotalSize
+	}
+	if conf.MaxParts == 0 {
+		conf.MaxParts = DefaultUploadConfig.MaxParts
+	}
+
+	req := c.Request()
+	if conf.MaxTotalSize > 0 && req.ContentLength > conf.MaxTotalSize {
+		return nil, c.Abort(ErrRequestEntityTooLarge).WithField("max_total_size", conf.MaxTotalSize)
+	}
+
+	body := &uploadReader{r: req.Body, max: conf.MaxTotalSize, conf: &conf}
+	body.progress.Total = req.ContentLength
+	if req.ContentLength <= 0 {
+		body.progress.Total = -1
+	}
+	req.Body = ioutil.NopCloser(body)
+	mr, err := req.MultipartReader()
+	if err != nil {
+		return nil, c.Abort(ErrIllegalparams).WithErr(err)
+	}
+
+	result := &UploadResult{Values: url.Values{}}
+	fail := func(err error) (*UploadResult, error) {
+		result.RemoveAll()
+		if body.exceeded {
+			// 保留原始异常（携带的 err 及字段），只将响应改为 413
+			if er, ok := err.(*errReply); ok {
+				er.Reply = ErrRequestEntityTooLarge
+				return nil, er.WithField("max_total_size", conf.MaxTotalSize)
+			}
+			return nil, c.Abort(ErrRequestEntityTooLarge).WithErr(err).WithField("max_total_size", conf.MaxTotalSize)
+		}
+		return nil, err
+	}
+
+	for parts := 1; ; parts++ {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fail(c.Abort(ErrIllegalparams).WithErr(err))
+		}
+		if conf.MaxParts > 0 && parts > conf.MaxParts {
+			return fail(c.Abort(ErrRequestEntityTooLarge).WithField("max_parts", conf.MaxParts))
+		}
+
+		name := part.FormName()
+		if part.FileName() == "" {
+			b, err := ioutil.ReadAll(io.LimitReader(part, conf.MaxFieldSize+1))
+			if err != nil {
+				return fail(c.Abort(ErrIllegalparams).WithErr(err))
+			}
+			if int64(len(b)) > conf.MaxFieldSize {
+				return fail(c.Abort(ErrRequestEntityTooLarge).WithField("field", name).WithField("max_field_size", conf.MaxFieldSize))
+			}
+			result.Values.Add(name, string(b))
+			continue
+		}
+
+		body.progress.Field, body.progress.Filename = name, part.FileName()
+		file := &UploadedFile{Field: name, Filename: part.FileName(), ContentType: part.Header.Get("Content-Type")}
+		if err := c.saveUploadPart(file, part.Header.Get("Content-MD5"), part.Header.Get(HeaderChecksumSHA256), part, &conf); err != nil {
+			if file.Path != "" {
+				file.Remove()
+			}
+			return fail(err)
+		}
+		result.Files = append(result.Files, file)
+		body.progress.Field, body.progress.Filename = "", ""
+	}
+
+	body.progress.Done = true
+	if conf.Progress != nil {
+		conf.Progress(body.progress)
+	}
+	return result, nil
+}
+
+// saveUploadPart 将文件 part 写入临时文件，同时计算并校验校验和
+func (c *Context) saveUploadPart(file *UploadedFile, contentMD5, checksum string, r io.Reader, conf *UploadConfig) error {
+	f, err := ioutil.TempFile(conf.Dir, "uecho-upload-*")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	file.Path = f.Name()
+
+	sum := sha256.New()
+	hashes := []io.Writer{f, sum}
+	var md5sum hash.Hash
+	if contentMD5 != "" {
+		md5sum = md5.New()
+		hashes = append(hashes, md5sum)
+	}
+
+	n, err := io.Copy(io.MultiWriter(hashes...), io.LimitReader(r, conf.MaxPartSize+1))
+	if err != nil {
+		return c.Abort(ErrIllegalparams).WithErr(err)
+	}
+	if n > conf.MaxPartSize {
+		return c.Abort(ErrRequestEntityTooLarge).WithField("file", file.Filename).WithField("max_part_size", conf.MaxPartSize)
+	}
+	file.Size = n
+	file.SHA256 = hex.EncodeToString(sum.Sum(nil))
+
+	if checksum != "" && !strings.EqualFold(checksum, file.SHA256) {
+		return c.Abort(ErrIllegalparams).WithField("file", file.Filename).WithField("checksum", "sha256 mismatch")
+	}
+	if md5sum != nil && contentMD5 != base64.StdEncoding.EncodeToString(md5sum.Sum(nil)) {
+		return c.Abort(ErrIllegalparams).WithField("file", file.Filename).WithField("checksum", "md5 mismatch")
+	}
+	return nil
+}
+
+// uploadReader 统计读取的请求体字节数，超过 max 时返回错误并回调上传进度
+type uploadReader struct {
+	r        io.Reader
+	max      int64
+	exceeded bool
+	conf     *UploadConfig
+	progress UploadProgress
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	if r.exceeded {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n, err := r.r.Read(p)
+	r.progress.Bytes += int64(n)
+	if r.max > 0 && r.progress.Bytes > r.max {
+		r.exceeded = true
+		return 0, io.ErrUnexpectedEOF
+	}
+	if n > 0 && r.conf.Progress != nil {
+		r.conf.Progress(r.progress)
+	}
+	return n, err
+}
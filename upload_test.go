@@ -0,0 +1,144 @@
+package uecho
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func multipartBody(t *testing.T, checksum string, files map[string]string) (*bytes.Buffer, string) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	mw.WriteField("title", "report")
+	for name, content := range files {
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Disposition", `form-data; name="file"; filename="`+name+`"`)
+		h.Set("Content-Type", "text/plain")
+		if checksum != "" {
+			h.Set(HeaderChecksumSHA256, checksum)
+		}
+		w, err := mw.CreatePart(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	mw.Close()
+	return body, mw.FormDataContentType()
+}
+
+func TestUpload(t *testing.T) {
+	dir := t.TempDir()
+	content := strings.Repeat("uecho", 1000)
+	sum := sha256.Sum256([]byte(content))
+
+	var (
+		result   *UploadResult
+		progress []UploadProgress
+	)
+	ue := New(nil)
+	ue.POST("/upload", HandlerFunc(func(c *Context) error {
+		var err error
+		result, err = c.Upload(UploadConfig{
+			Dir:          dir,
+			MaxPartSize:  int64(len(content)),
+			MaxTotalSize: 64 << 10,
+			Progress:     func(p UploadProgress) { progress = append(progress, p) },
+		})
+		if err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	}))
+
+	do := func(checksum string, files map[string]string) *httptest.ResponseRecorder {
+		result, progress = nil, nil
+		body, ctype := multipartBody(t, checksum, files)
+		req := httptest.NewRequest(http.MethodPost, "/upload", body)
+		req.Header.Set("Content-Type", ctype)
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(hex.EncodeToString(sum[:]), map[string]string{"a.txt": content})
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("got %d %s", rec.Code, rec.Body.String())
+	}
+	f := result.File("file")
+	if f == nil || f.Filename != "a.txt" || f.Size != int64(len(content)) || f.SHA256 != hex.EncodeToString(sum[:]) || result.Values.Get("title") != "report" {
+		t.Fatalf("unexpected result %+v %+v", f, result.Values)
+	}
+	if b, _ := ioutil.ReadFile(f.Path); string(b) != content {
+		t.Error("temp file content mismatch")
+	}
+	if last := progress[len(progress)-1]; !last.Done || last.Bytes != last.Total {
+		t.Errorf("unexpected final progress %+v", last)
+	}
+	f.Remove()
+
+	if rec := do("deadbeef", map[string]string{"a.txt": content}); rec.Code != http.StatusBadRequest {
+		t.Errorf("checksum mismatch: got %d", rec.Code)
+	}
+	if rec := do("", map[string]string{"a.txt": content + "x"}); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("part too large: got %d", rec.Code)
+	}
+
+	// 失败时删除已写入的临时文件
+	entries, _ := ioutil.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("%d temp files left", len(entries))
+	}
+}
+
+func TestUploadLimits(t *testing.T) {
+	var (
+		cause error
+		field interface{}
+	)
+	ue := New(nil)
+	ue.POST("/upload", HandlerFunc(func(c *Context) error {
+		max, _ := strconv.ParseInt(c.QueryParam("max"), 10, 64)
+		_, err := c.Upload(UploadConfig{Dir: t.TempDir(), MaxTotalSize: max})
+		// errReply 在请求结束后回收，在处理函数中取出
+		if er, ok := err.(*errReply); ok {
+			cause, field = er.err, er.fields["max_total_size"]
+		}
+		return err
+	}))
+
+	do := func(max string, files map[string]string) *httptest.ResponseRecorder {
+		body, ctype := multipartBody(t, "", files)
+		req := httptest.NewRequest(http.MethodPost, "/upload?max="+max, body)
+		req.Header.Set("Content-Type", ctype)
+		req.ContentLength = -1
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 默认限制 part 数量
+	files := make(map[string]string, DefaultUploadConfig.MaxParts)
+	for i := 0; i < DefaultUploadConfig.MaxParts; i++ {
+		files[strconv.Itoa(i)+".txt"] = "uecho"
+	}
+	if rec := do("0", files); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("too many parts: got %d", rec.Code)
+	}
+
+	// 超过 MaxTotalSize 时返回 413，并保留原始异常
+	if rec := do("1024", map[string]string{"a.txt": strings.Repeat("uecho", 1000)}); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("body too large: got %d", rec.Code)
+	}
+	if cause == nil || field != int64(1024) {
+		t.Errorf("unexpected error cause %v, max_total_size %v", cause, field)
+	}
+}
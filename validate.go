@@ -0,0 +1,183 @@
+package uecho
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// RouteIssue 路由表检查发现的问题
+type RouteIssue struct {
+	// Kind 问题类型：duplicate、param_conflict、empty_group、wildcard_shadow
+	Kind string `json:"kind"`
+	// Route 有问题的路由，empty_group 时为 nil
+	Route *Route `json:"route,omitempty"`
+	// Other 与 Route 冲突的路由
+	Other *Route `json:"other,omitempty"`
+	// Message 问题描述
+	Message string `json:"message"`
+}
+
+func (i RouteIssue) String() string {
+	return i.Kind + ": " + i.Message
+}
+
+const (
+	RouteIssueDuplicate      = "duplicate"
+	RouteIssueParamConflict  = "param_conflict"
+	RouteIssueEmptyGroup     = "empty_group"
+	RouteIssueWildcardShadow = "wildcard_shadow"
+)
+
+// Validate 检查路由表：重复注册、同一位置的路径参数名不一致、只有中间键没有路由的分组、
+// 通配符 * 之后的部分（永远不会被匹配）
+func (e *UEcho) Validate() []RouteIssue {
+	var issues []RouteIssue
+	routers := []*Router{e.router}
+	for _, host := range sortedHosts(e.routers) {
+		routers = append(routers, e.routers[host])
+	}
+
+	for _, router := range routers {
+		routes := router.sortedRoutes()
+		for _, old := range router.replaced {
+			if old.catchAll {
+				continue
+			}
+			cur := router.routes[old.Method+old.Path]
+			issues = append(issues, RouteIssue{
+				Kind:    RouteIssueDuplicate,
+				Route:   cur,
+				Other:   old,
+				Message: fmt.Sprintf("%s %s registered at %s (%s) overrides %s (%s)", cur.Method, displayPath(cur), cur.CallSite, cur.Name, old.CallSite, old.Name),
+			})
+		}
+
+		// 前缀（参数替换为 :）=> 该位置第一个出现的参数名及路由
+		params := make(map[string]*Route)
+		names := make(map[string]string)
+		for _, r := range routes {
+			if r.catchAll {
+				continue
+			}
+			if i := strings.Index(r.Path, "*"); i >= 0 && i != len(r.Path)-1 {
+				issues = append(issues, RouteIssue{
+					Kind:    RouteIssueWildcardShadow,
+					Route:   r,
+					Message: fmt.Sprintf("%s %s registered at %s: %q after * is never matched", r.Method, displayPath(r), r.CallSite, r.Path[i+1:]),
+				})
+			}
+
+			segments := strings.Split(r.Path, "/")
+			for i, seg := range segments {
+				if !strings.HasPrefix(seg, ":") {
+					continue
+				}
+				key := normalizedPrefix(segments[:i+1])
+				if other, ok := params[key]; ok {
+					if names[key] != seg {
+						issues = append(issues, RouteIssue{
+							Kind:  RouteIssueParamConflict,
+							Route: r,
+							Other: other,
+							Message: fmt.Sprintf("%s %s registered at %s names the parameter %s, but %s %s registered at %s names it %s",
+								r.Method, displayPath(r), r.CallSite, seg, other.Method, displayPath(other), other.CallSite, names[key]),
+						})
+					}
+					continue
+				}
+				params[key], names[key] = r, seg
+			}
+		}
+	}
+
+	for _, g := range e.groups {
+		if len(g.middleware) == 0 || e.groupHasRoutes(g) {
+			continue
+		}
+		issues = append(issues, RouteIssue{
+			Kind:    RouteIssueEmptyGroup,
+			Message: fmt.Sprintf("group %s%s has %d middleware but no routes; the middleware only runs for unmatched requests", g.host, g.prefix, len(g.middleware)),
+		})
+	}
+	return issues
+}
+
+// groupHasRoutes 分组（含子分组）是否注册了路由
+func (e *UEcho) groupHasRoutes(g *Group) bool {
+	for _, r := range e.findRouter(g.host).routes {
+		if !r.catchAll && r.Host == g.host && groupHasPrefix(r.Group, g.prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateOnStart 启动时检查路由表，问题以 warn 级别输出
+func (e *UEcho) validateOnStart() {
+	if !e.ValidateRoutes {
+		return
+	}
+	for _, issue := range e.Validate() {
+		e.Logger.Warnf("route %s", issue)
+	}
+}
+
+func (r *Router) sortedRoutes() []*Route {
+	routes := make([]*Route, 0, len(r.routes))
+	for _, route := range r.routes {
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+func sortedHosts(routers map[string]*Router) []string {
+	hosts := make([]string, 0, len(routers))
+	for host := range routers {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// normalizedPrefix 将路径参数统一替换为 :
+func normalizedPrefix(segments []string) string {
+	normalized := make([]string, len(segments))
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") {
+			seg = ":"
+		}
+		normalized[i] = seg
+	}
+	return strings.Join(normalized, "/")
+}
+
+func displayPath(r *Route) string {
+	return r.Host + r.Path
+}
+
+// callSite 返回 uecho 包外第一个调用者的位置（file:line）
+func callSite() string {
+	_, self, _, _ := runtime.Caller(0)
+	dir := filepath.Dir(self)
+
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if filepath.Dir(frame.File) != dir || strings.HasSuffix(frame.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package uecho
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4/middleware"
+)
+
+func TestValidate(t *testing.T) {
+	ue := New(nil)
+	ok := HandlerFunc(func(c *Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	ue.GET("/users/:id", ok)
+	ue.GET("/users/:uid/orders", ok)
+	ue.GET("/files/*/raw", ok)
+	ue.POST("/orders", ok)
+	ue.POST("/orders", ok)
+	ue.Group("/admin", middleware.BodyLimit("1M"))
+	api := ue.Group("/api", middleware.BodyLimit("1M"))
+	api.Group("/v1").GET("/ping", ok)
+
+	kinds := make(map[string]int)
+	for _, issue := range ue.Validate() {
+		kinds[issue.Kind]++
+		if issue.Route != nil && !strings.Contains(issue.Route.CallSite, "validate_test.go:") {
+			t.Errorf("call site = %q", issue.Route.CallSite)
+		}
+		if issue.Kind == RouteIssueEmptyGroup && !strings.Contains(issue.Message, "/admin") {
+			t.Errorf("empty group: %s", issue)
+		}
+	}
+	want := map[string]int{
+		RouteIssueDuplicate:      1,
+		RouteIssueParamConflict:  1,
+		RouteIssueWildcardShadow: 1,
+		RouteIssueEmptyGroup:     1,
+	}
+	for kind, n := range want {
+		if kinds[kind] != n {
+			t.Errorf("%s issues = %d, want %d (%v)", kind, kinds[kind], n, ue.Validate())
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package uecho
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ValidationFunc 校验规则，param 为规则参数（例如 min=3 中的 3）
+type ValidationFunc func(field reflect.Value, param string) bool
+
+// ValidationError 未通过校验的字段
+type ValidationError struct {
+	// Field 字段名（json、query、form、param tag 或字段名），嵌套结构体以 . 连接
+	Field string
+	// Rule 未通过的规则
+	Rule string
+	// Param 规则参数
+	Param string
+}
+
+func (e ValidationError) Error() string {
+	return e.Message(LANG_EN_US)
+}
+
+// Message 返回 lang 对应的提示信息，模板见 RegisterValidationMessage
+func (e ValidationError) Message(lang string) string {
+	tmpl, ok := eci18n[validationKey(e.Rule, lang)]
+	if !ok {
+		tmpl, ok = eci18n[validationKey(e.Rule, LANG_DEFAULT)]
+	}
+	if !ok {
+		tmpl = "{field} failed on the '" + e.Rule + "' rule"
+	}
+	return strings.NewReplacer("{field}", e.Field, "{param}", e.Param).Replace(tmpl)
+}
+
+// ValidationErrors Validator 返回的全部未通过校验的字段
+type ValidationErrors []ValidationError
+
+func (es ValidationErrors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// RegisterValidationMessage 注册校验规则在 lang 下的提示信息模板，{field} 替换为字段名，{param} 替换为规则参数
+// 提示信息与业务码共用 i18n 词条，应在启动服务前注册
+func RegisterValidationMessage(rule, lang, template string) {
+	eci18n[validationKey(rule, lang)] = template
+}
+
+func validationKey(rule, lang string) string {
+	return "validate." + rule + "." + lang
+}
+
+// Validator 按 `validate:"required,min=3,oneof=a b"` tag 校验结构体，实现 echo.Validator
+// 内置 required、min、max、len、oneof 规则；规则对零值字段同样生效（min=18 不接受 0），
+// omitempty 放在最前面时零值（含 nil 指针）字段跳过其余规则，例如 `validate:"omitempty,min=18"`
+// 每个结构体类型第一次校验时检查 tag（未知规则、min、max、len 参数不是数字、oneof 没有候选值），
+// 可以在启动时调用 Check 提前发现 tag 错误
+type Validator struct {
+	mu    sync.RWMutex
+	rules map[string]ValidationFunc
+	types sync.Map // reflect.Type => error，tag 检查结果
+}
+
+// NewValidator 创建 Validator，New 默认使用该 Validator
+func NewValidator() *Validator {
+	return &Validator{rules: map[string]ValidationFunc{
+		"required": validateRequired,
+		"min":      validateMin,
+		"max":      validateMax,
+		"len":      validateLen,
+		"oneof":    validateOneOf,
+	}}
+}
+
+// Register 注册（或覆盖）校验规则，messages 为 lang => 提示信息模板（见 RegisterValidationMessage）
+// 规则可以在服务运行时注册；messages 写入全局的 i18n 词条，不是并发安全的，带 messages 时应在启动服务前注册
+func (v *Validator) Register(rule string, fn ValidationFunc, messages map[string]string) {
+	v.mu.Lock()
+	v.rules[rule] = fn
+	v.mu.Unlock()
+	// 清除 tag 检查结果，重新检查使用新规则的结构体
+	v.types.Range(func(t, _ interface{}) bool {
+		v.types.Delete(t)
+		return true
+	})
+	for lang, tmpl := range messages {
+		RegisterValidationMessage(rule, lang, tmpl)
+	}
+}
+
+// RegisterValidation 向默认的 Validator 注册校验规则，e.Validator 不是 *Validator 时 panic
+//
+//	e.RegisterValidation("phone", func(f reflect.Value, _ string) bool {
+//		return phoneRegexp.MatchString(f.String())
+//	}, map[string]string{
+//		uecho.LANG_ZH_CN: "{field} 不是有效的手机号",
+//		uecho.LANG_EN_US: "{field} is not a valid phone number",
+//	})
+func (e *UEcho) RegisterValidation(rule string, fn ValidationFunc, messages map[string]string) {
+	v, ok := e.Validator.(*Validator)
+	if !ok {
+		panic("uecho: RegisterValidation requires e.Validator to be *uecho.Validator")
+	}
+	v.Register(rule, fn, messages)
+}
+
+func (v *Validator) rule(name string) (ValidationFunc, bool) {
+	v.mu.RLock()
+	fn, ok := v.rules[name]
+	v.mu.RUnlock()
+	return fn, ok
+}
+
+// Validate 校验结构体（或结构体指针），返回 ValidationErrors
+func (v *Validator) Validate(i interface{}) error {
+	rv := reflect.ValueOf(i)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	if err := v.checkType(rv.Type()); err != nil {
+		return err
+	}
+
+	var errs ValidationErrors
+	if err := v.validateStruct(rv, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (v *Validator) validateStruct(rv reflect.Value, prefix string, errs *ValidationErrors) error {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf, field := t.Field(i), rv.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+
+		name := prefix + fieldName(sf)
+		if tag := sf.Tag.Get("validate"); tag != "" && tag != "-" {
+			for _, rule := range strings.Split(tag, ",") {
+				rule, param := splitRule(rule)
+				if rule == "omitempty" {
+					if field.IsZero() {
+						break
+					}
+					continue
+				}
+				fn, ok := v.rule(rule)
+				if !ok {
+					return fmt.Errorf("uecho: unknown validation rule %q on %s.%s", rule, t.Name(), sf.Name)
+				}
+				if !fn(indirect(field), param) {
+					*errs = append(*errs, ValidationError{Field: name, Rule: rule, Param: param})
+					break
+				}
+			}
+		}
+
+		// 嵌套结构体，匿名字段沿用外层前缀
+		nested := indirect(field)
+		if nested.Kind() == reflect.Struct && !isScalarType(nested.Type()) {
+			p := name + "."
+			if sf.Anonymous {
+				p = prefix
+			}
+			if err := v.validateStruct(nested, p, errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Check 检查结构体（或结构体指针）及其嵌套结构体的 validate tag，返回第一个错误
+//
+//	if err := v.Check(&SignupRequest{}); err != nil {
+//		panic(err)
+//	}
+func (v *Validator) Check(i interface{}) error {
+	t := reflect.TypeOf(i)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+	return v.checkType(t)
+}
+
+// checkType 检查 t 的 validate tag，结果按类型缓存
+func (v *Validator) checkType(t reflect.Type) error {
+	if err, ok := v.types.Load(t); ok {
+		if err == nil {
+			return nil
+		}
+		return err.(error)
+	}
+	err := v.checkStruct(t, map[reflect.Type]bool{})
+	v.types.Store(t, err)
+	return err
+}
+
+func (v *Validator) checkStruct(t reflect.Type, seen map[reflect.Type]bool) error {
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		if tag := sf.Tag.Get("validate"); tag != "" && tag != "-" {
+			for j, rule := range strings.Split(tag, ",") {
+				rule, param := splitRule(rule)
+				if err := v.checkRule(rule, param, j); err != nil {
+					return fmt.Errorf("uecho: %v on %s.%s", err, t.Name(), sf.Name)
+				}
+			}
+		}
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && !isScalarType(ft) {
+			if err := v.checkStruct(ft, seen); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkRule 检查规则名及内置规则的参数，i 为规则在 tag 中的位置
+func (v *Validator) checkRule(rule, param string, i int) error {
+	if rule == "omitempty" {
+		if i != 0 {
+			return errors.New("validation rule omitempty must come first")
+		}
+		return nil
+	}
+	if _, ok := v.rule(rule); !ok {
+		return fmt.Errorf("unknown validation rule %q", rule)
+	}
+	switch rule {
+	case "min", "max", "len":
+		if _, err := strconv.ParseFloat(param, 64); err != nil {
+			return fmt.Errorf("validation rule %s requires a numeric parameter, got %q", rule, param)
+		}
+	case "oneof":
+		if len(strings.Fields(param)) == 0 {
+			return errors.New("validation rule oneof requires candidates")
+		}
+	}
+	return nil
+}
+
+// fieldName 返回字段对外的名称：json、query、form、param tag 中第一个非空的名称，否则为字段名
+func fieldName(sf reflect.StructField) string {
+	for _, tag := range []string{"json", "query", "form", "param"} {
+		if name := strings.Split(sf.Tag.Get(tag), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+func splitRule(rule string) (string, string) {
+	rule = strings.TrimSpace(rule)
+	if i := strings.IndexByte(rule, '='); i >= 0 {
+		return rule[:i], rule[i+1:]
+	}
+	return rule, ""
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+func validateRequired(v reflect.Value, _ string) bool {
+	return v.IsValid() && !v.IsZero()
+}
+
+// size 字符串返回字符数，切片、map 返回长度，数字返回数值
+func size(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func compareSize(v reflect.Value, param string, fn func(n, p float64) bool) bool {
+	p, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return false
+	}
+	n, ok := size(v)
+	return ok && fn(n, p)
+}
+
+func validateMin(v reflect.Value, param string) bool {
+	return compareSize(v, param, func(n, p float64) bool { return n >= p })
+}
+
+func validateMax(v reflect.Value, param string) bool {
+	return compareSize(v, param, func(n, p float64) bool { return n <= p })
+}
+
+func validateLen(v reflect.Value, param string) bool {
+	return compareSize(v, param, func(n, p float64) bool { return n == p })
+}
+
+// validateOneOf 值为 param 中以空格分隔的候选值之一
+func validateOneOf(v reflect.Value, param string) bool {
+	s := fmt.Sprint(v.Interface())
+	for _, candidate := range strings.Fields(param) {
+		if s == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// BindAndValidate 绑定并校验请求参数，未通过校验的字段以当前语言（c.Lang()）的提示信息
+// 作为 ErrIllegalparams 的 data（[]FieldError）返回
+func (c *Context) BindAndValidate(i interface{}) error {
+	if err := c.Bind(i); err != nil {
+		return err
+	}
+	err := c.Validate(i)
+	ves, ok := err.(ValidationErrors)
+	if !ok {
+		return err
+	}
+
+	lang := c.Lang()
+	errs := make([]FieldError, len(ves))
+	for j, ve := range ves {
+		errs[j] = FieldError{Field: ve.Field, Message: ve.Message(lang)}
+	}
+	return fieldErrors(errs)
+}
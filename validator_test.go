@@ -0,0 +1,156 @@
+package uecho
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+func TestBindAndValidate(t *testing.T) {
+	phone := regexp.MustCompile(`^1\d{10}$`)
+	ue := New(nil)
+	ue.LangResolvers = []LangResolver{LangFromHeader()}
+	ue.RegisterValidation("phone", func(f reflect.Value, _ string) bool {
+		return phone.MatchString(f.String())
+	}, map[string]string{
+		LANG_ZH_CN: "{field} 不是有效的手机号",
+		LANG_EN_US: "{field} is not a valid phone number",
+	})
+
+	type address struct {
+		City string `json:"city" validate:"required"`
+	}
+	type signup struct {
+		Name    string   `query:"name" validate:"required,min=2"`
+		Phone   string   `query:"phone" validate:"phone"`
+		Channel string   `query:"channel" validate:"oneof=web app"`
+		Age     int      `query:"age" validate:"max=150"`
+		Address *address `json:"address"`
+	}
+	ue.GET("/signup", HandlerFunc(func(c *Context) error {
+		req := signup{Address: &address{}}
+		if err := c.BindAndValidate(&req); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	}))
+
+	do := func(query, lang string) map[string]string {
+		req := httptest.NewRequest(http.MethodGet, "/signup?"+query, nil)
+		req.Header.Set(HeaderAcceptLanguage, lang)
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, req)
+		if rec.Code == http.StatusNoContent {
+			return nil
+		}
+		var resp struct {
+			Data []FieldError `json:"data"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		fields := make(map[string]string)
+		for _, fe := range resp.Data {
+			fields[fe.Field] = fe.Message
+		}
+		return fields
+	}
+
+	fields := do("name=a&phone=123&channel=tv&age=200", LANG_EN_US)
+	want := map[string]string{
+		"name":         "name must be at least 2",
+		"phone":        "phone is not a valid phone number",
+		"channel":      "channel must be one of [web app]",
+		"age":          "age must be at most 150",
+		"address.city": "address.city is required",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("got %v, want %v", fields, want)
+	}
+
+	if fields := do("name=ab&phone=123", LANG_ZH_CN); fields["phone"] != "phone 不是有效的手机号" || fields["name"] != "" {
+		t.Errorf("zh-CN: got %v", fields)
+	}
+	// 没有 zh-TW 模板时使用默认语言
+	if fields := do("name=ab&phone=123", LANG_ZH_TW); fields["phone"] != "phone 不是有效的手机号" {
+		t.Errorf("zh-TW: got %v", fields)
+	}
+}
+
+func TestValidatorZeroValues(t *testing.T) {
+	v := NewValidator()
+	type adult struct {
+		Age      int    `json:"age" validate:"min=18"`
+		Nickname string `json:"nickname" validate:"omitempty,min=2"`
+		Referrer *int   `json:"referrer" validate:"omitempty,min=1"`
+	}
+	errs, ok := v.Validate(adult{}).(ValidationErrors)
+	if !ok || len(errs) != 1 || errs[0].Field != "age" || errs[0].Rule != "min" {
+		t.Errorf("zero age: got %v", errs)
+	}
+	if err := v.Validate(adult{Age: 18}); err != nil {
+		t.Errorf("omitempty: got %v", err)
+	}
+	if err := v.Validate(adult{Age: 18, Nickname: "a"}); err == nil {
+		t.Error("non-empty nickname should still be validated")
+	}
+}
+
+func TestValidatorRegisterConcurrent(t *testing.T) {
+	v := NewValidator()
+	type user struct {
+		Name string `validate:"required,min=2"`
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			v.Register("even", func(f reflect.Value, _ string) bool { return f.Int()%2 == 0 }, nil)
+		}()
+		go func() {
+			defer wg.Done()
+			if err := v.Validate(user{Name: "uecho"}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestValidatorCheck(t *testing.T) {
+	v := NewValidator()
+	type inner struct {
+		Code string `validate:"oneof"`
+	}
+	for name, i := range map[string]interface{}{
+		"unknown rule": &struct {
+			A string `validate:"requried"`
+		}{},
+		"non-numeric param": &struct {
+			A int `validate:"min=ten"`
+		}{},
+		"omitempty position": &struct {
+			A int `validate:"min=1,omitempty"`
+		}{},
+		"nested": &struct{ In *inner }{},
+	} {
+		if err := v.Check(i); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		if err := v.Validate(i); err == nil {
+			t.Errorf("%s: Validate should report the tag error", name)
+		} else if _, ok := err.(ValidationErrors); ok {
+			t.Errorf("%s: got ValidationErrors %v", name, err)
+		}
+	}
+	if err := v.Check(&struct {
+		A int `validate:"omitempty,min=1,max=9"`
+	}{}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,225 @@
+package uecho
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WarmupState 预热任务状态
+type WarmupState string
+
+const (
+	WarmupPending WarmupState = "pending"
+	WarmupRunning WarmupState = "running"
+	WarmupDone    WarmupState = "done"
+	WarmupFailed  WarmupState = "failed"
+)
+
+// WarmupTask 预热任务的进度
+type WarmupTask struct {
+	Name    string      `json:"name"`
+	State   WarmupState `json:"state"`
+	Error   string      `json:"error,omitempty"`
+	Elapsed string      `json:"elapsed,omitempty"`
+}
+
+// WarmupProgress 预热进度，健康检查（/readyz、/startupz）响应的 data
+type WarmupProgress struct {
+	Done     int          `json:"done"`
+	Total    int          `json:"total"`
+	Finished bool         `json:"finished"`
+	Ready    bool         `json:"ready"`
+	Draining bool         `json:"draining"`
+	Tasks    []WarmupTask `json:"tasks"`
+}
+
+type warmupTask struct {
+	WarmupTask
+	fn func(ctx context.Context) error
+}
+
+// warmupRegistry 预热任务及其执行状态
+type warmupRegistry struct {
+	mu       sync.Mutex
+	tasks    []*warmupTask
+	once     sync.Once
+	done     chan struct{}
+	finished bool
+	err      error
+}
+
+// AddWarmup 注册预热任务（缓存预热、连接池初始化等），应在启动前注册
+// 任务在监听端口之后按注册顺序依次执行，全部成功完成前 /readyz、/startupz 为未就绪
+// 不通过 Start 系列方法启动（自行创建 http.Server、直接调用 ServeHTTP 等）时需要显式调用 WarmUp
+func (e *UEcho) AddWarmup(name string, fn func(ctx context.Context) error) {
+	w := &e.warmup
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.tasks = append(w.tasks, &warmupTask{WarmupTask: WarmupTask{Name: name, State: WarmupPending}, fn: fn})
+}
+
+// WarmUp 执行预热任务并返回第一个失败任务的错误，失败后不再执行后续任务
+// 只执行一次，重复调用等待第一次执行完成并返回相同的结果；Start 系列方法监听端口后自动调用
+// WarmupTimeout 大于 0 时作为全部任务的执行时限
+func (e *UEcho) WarmUp(ctx context.Context) error {
+	w := &e.warmup
+	w.mu.Lock()
+	if w.done == nil {
+		w.done = make(chan struct{})
+	}
+	done := w.done
+	w.mu.Unlock()
+
+	w.once.Do(func() {
+		defer close(done)
+		runCtx := ctx
+		if e.WarmupTimeout > 0 {
+			var cancel context.CancelFunc
+			runCtx, cancel = context.WithTimeout(ctx, e.WarmupTimeout)
+			defer cancel()
+		}
+		err := e.runWarmup(runCtx)
+
+		w.mu.Lock()
+		w.finished, w.err = true, err
+		w.mu.Unlock()
+		if err != nil {
+			e.Logger.Errorf("warm-up failed: %v", err)
+		}
+	})
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+func (e *UEcho) runWarmup(ctx context.Context) error {
+	w := &e.warmup
+	w.mu.Lock()
+	tasks := w.tasks
+	w.mu.Unlock()
+
+	for _, t := range tasks {
+		w.mu.Lock()
+		t.State = WarmupRunning
+		w.mu.Unlock()
+
+		start := time.Now()
+		err := runWarmupTask(ctx, t.fn)
+		if err == nil {
+			err = ctx.Err()
+		}
+
+		w.mu.Lock()
+		t.Elapsed = time.Since(start).String()
+		if err != nil {
+			t.State, t.Error = WarmupFailed, err.Error()
+		} else {
+			t.State = WarmupDone
+		}
+		w.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runWarmupTask 执行预热任务，panic 视为任务失败
+func runWarmupTask(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// Warmed 预热任务是否已全部成功完成，没有注册预热任务时始终为 true
+func (e *UEcho) Warmed() bool {
+	w := &e.warmup
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.warmedLocked()
+}
+
+// finishedLocked 预热是否已结束，没有注册预热任务时视为已结束，调用方需持有 w.mu
+func (w *warmupRegistry) finishedLocked() bool {
+	return w.finished || len(w.tasks) == 0
+}
+
+// warmedLocked 预热任务是否已全部成功完成，调用方需持有 w.mu
+func (w *warmupRegistry) warmedLocked() bool {
+	return w.finishedLocked() && w.err == nil
+}
+
+// Ready 是否可以接收流量：预热完成且不处于 drain 状态
+func (e *UEcho) Ready() bool {
+	return e.Warmed() && !e.Draining()
+}
+
+// WarmupProgress 返回预热进度
+func (e *UEcho) WarmupProgress() WarmupProgress {
+	w := &e.warmup
+	w.mu.Lock()
+	p := WarmupProgress{
+		Total:    len(w.tasks),
+		Finished: w.finishedLocked(),
+		Tasks:    make([]WarmupTask, 0, len(w.tasks)),
+	}
+	for _, t := range w.tasks {
+		if t.State == WarmupDone {
+			p.Done++
+		}
+		p.Tasks = append(p.Tasks, t.WarmupTask)
+	}
+	warmed := w.warmedLocked()
+	w.mu.Unlock()
+
+	p.Draining = e.Draining()
+	p.Ready = warmed && !p.Draining
+	return p
+}
+
+// LivezHandler 存活检查，进程能处理请求即返回 200
+func (e *UEcho) LivezHandler() HandlerFunc {
+	return func(c *Context) error {
+		return c.SetPayload(OK)
+	}
+}
+
+// ReadyzHandler 就绪检查，预热完成且不处于 drain 状态时返回 200，否则返回 503；data 为预热进度
+func (e *UEcho) ReadyzHandler() HandlerFunc {
+	return func(c *Context) error {
+		p := e.WarmupProgress()
+		if !p.Ready {
+			return c.Abort(ErrServiceUnavailable.WithData(p))
+		}
+		return c.SetPayload(OK.WithData(p))
+	}
+}
+
+// StartupzHandler 启动检查（Kubernetes startupProbe），预热完成前返回 503；data 为预热进度
+func (e *UEcho) StartupzHandler() HandlerFunc {
+	return func(c *Context) error {
+		p := e.WarmupProgress()
+		if !p.Finished || p.Done < p.Total {
+			return c.Abort(ErrServiceUnavailable.WithData(p))
+		}
+		return c.SetPayload(OK.WithData(p))
+	}
+}
+
+// HealthRoutes 注册 /livez、/readyz、/startupz 健康检查路由
+func (e *UEcho) HealthRoutes() {
+	e.GET("/livez", e.LivezHandler())
+	e.GET("/readyz", e.ReadyzHandler())
+	e.GET("/startupz", e.StartupzHandler())
+}
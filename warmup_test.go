@@ -0,0 +1,79 @@
+package uecho
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWarmUp(t *testing.T) {
+	ue := New(nil)
+	ue.HealthRoutes()
+	release := make(chan struct{})
+	ue.AddWarmup("cache", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	ue.AddWarmup("pool", func(ctx context.Context) error { return nil })
+
+	probe := func(path string) (int, WarmupProgress) {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var body struct {
+			Data WarmupProgress `json:"data"`
+		}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body.Data
+	}
+
+	if code, _ := probe("/livez"); code != http.StatusOK {
+		t.Errorf("livez: got %d", code)
+	}
+	done := make(chan error)
+	go func() { done <- ue.WarmUp(context.Background()) }()
+	if code, p := probe("/readyz"); code != http.StatusServiceUnavailable || p.Total != 2 || p.Done != 0 {
+		t.Errorf("readyz before warm-up: got %d %+v", code, p)
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/readyz", "/startupz"} {
+		if code, p := probe(path); code != http.StatusOK || !p.Ready || p.Done != 2 || p.Tasks[1].State != WarmupDone {
+			t.Errorf("%s: got %d %+v", path, code, p)
+		}
+	}
+
+	ue.Drain()
+	if code, _ := probe("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("readyz while draining: got %d", code)
+	}
+}
+
+func TestWarmUpFailed(t *testing.T) {
+	ue := New(nil)
+	ue.AddWarmup("db", func(ctx context.Context) error { return errors.New("dial failed") })
+	ue.AddWarmup("never", func(ctx context.Context) error { panic("unreachable") })
+	if err := ue.WarmUp(context.Background()); err == nil {
+		t.Fatal("expected error")
+	}
+	p := ue.WarmupProgress()
+	if ue.Ready() || p.Tasks[0].State != WarmupFailed || p.Tasks[1].State != WarmupPending {
+		t.Errorf("got %+v", p)
+	}
+}
+
+func TestWarmUpNoTasks(t *testing.T) {
+	ue := New(nil)
+	ue.HealthRoutes()
+	for _, path := range []string{"/readyz", "/startupz"} {
+		rec := httptest.NewRecorder()
+		ue.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s without warm-up tasks: got %d", path, rec.Code)
+		}
+	}
+}
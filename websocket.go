@@ -0,0 +1,78 @@
+package uecho
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/websocket"
+)
+
+type WebSocketConfig struct {
+	// Origins 允许的 Origin（scheme://host[:port]，例如 https://app.example.com），
+	// 为空时只允许与请求 Host 相同的 Origin
+	// Optional.
+	Origins []string
+
+	// CheckOrigin 校验握手请求的 Origin，设置后忽略 Origins
+	// Optional.
+	CheckOrigin func(c *Context, origin *url.URL) bool
+}
+
+// WebSocket 升级为 WebSocket 连接（golang.org/x/net/websocket），fn 返回即关闭连接
+// 只接受与请求 Host 同源的握手请求（防止跨站 WebSocket 劫持），允许其他 Origin 时使用 WebSocketWithConfig
+// 连接登记为长连接（见 TrackConn）：Shutdown 时向客户端发送 close 帧并关闭连接，fn 中的读写随之返回错误
+func (c *Context) WebSocket(fn func(ws *websocket.Conn) error) error {
+	return c.WebSocketWithConfig(WebSocketConfig{}, fn)
+}
+
+// WebSocketWithConfig 升级为 WebSocket 连接，Origin 不被允许（或缺少 Origin）时以 403 拒绝握手
+func (c *Context) WebSocketWithConfig(conf WebSocketConfig, fn func(ws *websocket.Conn) error) error {
+	check := conf.CheckOrigin
+	if check == nil {
+		check = originChecker(conf.Origins)
+	}
+
+	var err error
+	websocket.Server{
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			origin, oerr := websocket.Origin(config, req)
+			if oerr != nil {
+				return oerr
+			}
+			if origin == nil {
+				return errors.New("uecho: websocket handshake without origin")
+			}
+			if !check(c, origin) {
+				return fmt.Errorf("uecho: websocket origin %s not allowed", origin)
+			}
+			config.Origin = origin
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			done := c.TrackConn(func() { ws.Close() })
+			defer done()
+			err = fn(ws)
+		},
+	}.ServeHTTP(c.Response(), c.Request())
+
+	// 连接已被接管，异常不能再写入响应
+	c.Response().Committed = true
+	return err
+}
+
+// originChecker origins 为空时只允许与请求 Host 同源的 Origin
+func originChecker(origins []string) func(c *Context, origin *url.URL) bool {
+	allowed := make(map[string]bool, len(origins))
+	for _, o := range origins {
+		allowed[strings.ToLower(strings.TrimSuffix(o, "/"))] = true
+	}
+	return func(c *Context, origin *url.URL) bool {
+		if len(allowed) == 0 {
+			return strings.EqualFold(origin.Host, c.Request().Host)
+		}
+		return allowed[strings.ToLower(origin.Scheme+"://"+origin.Host)]
+	}
+}
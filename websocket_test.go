@@ -0,0 +1,54 @@
+package uecho
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestWebSocketOrigin(t *testing.T) {
+	ue := New(nil)
+	echoWS := func(ws *websocket.Conn) error {
+		var msg string
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			return err
+		}
+		return websocket.Message.Send(ws, msg)
+	}
+	ue.GET("/ws", HandlerFunc(func(c *Context) error {
+		return c.WebSocket(echoWS)
+	}))
+	ue.GET("/partner", HandlerFunc(func(c *Context) error {
+		return c.WebSocketWithConfig(WebSocketConfig{Origins: []string{"https://partner.example.com"}}, echoWS)
+	}))
+	srv := httptest.NewServer(ue)
+	defer srv.Close()
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	for _, tc := range []struct {
+		path, origin string
+		ok           bool
+	}{
+		{"/ws", srv.URL, true},
+		{"/ws", "https://evil.example.com", false},
+		{"/partner", "https://partner.example.com", true},
+		{"/partner", srv.URL, false},
+	} {
+		ws, err := websocket.Dial(wsURL+tc.path, "", tc.origin)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s origin %s: err = %v", tc.path, tc.origin, err)
+		}
+		if err != nil {
+			continue
+		}
+		var reply string
+		if err := websocket.Message.Send(ws, "ping"); err != nil {
+			t.Error(err)
+		} else if err := websocket.Message.Receive(ws, &reply); err != nil || reply != "ping" {
+			t.Errorf("%s: reply = %q, err = %v", tc.path, reply, err)
+		}
+		ws.Close()
+	}
+}